@@ -0,0 +1,356 @@
+// Package conformance checks that an ExchangeInterface returns what the
+// unified API promises, whatever exchange is behind it.
+//
+// The Check functions validate a single result and report every violation
+// they find. RunConfig drives an exchange through a full order lifecycle
+// against fixtures and applies them to each result, Run does so with the
+// fixtures of a fake server, so every adapter package can run the same
+// suite:
+//
+//	func TestConformance(t *testing.T) {
+//		server := fake.NewBinance(config)
+//		defer server.Close()
+//		conformance.Run(t, newExchange(server), server)
+//	}
+//
+// RunErrors checks how an adapter maps error responses to error classes.
+package conformance
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// Config describes the fixtures an exchange is checked against
+type Config struct {
+	// Symbol is the unified symbol to trade
+	Symbol string
+	// Price and Amount of a limit buy that rests on the book without filling
+	Price  float64
+	Amount float64
+	// Cross, if set, moves the market through Price so that a resting buy
+	// at Price fills completely; the filled order is checked as well
+	Cross func()
+}
+
+// Statuses are the order statuses of the unified API
+var Statuses = []string{"open", "closed", "canceled"}
+
+var (
+	unifiedSymbol   = regexp.MustCompile(`^[A-Z0-9]+/[A-Z0-9]+$`)
+	unifiedCurrency = regexp.MustCompile(`^[A-Z0-9]+$`)
+	iso8601         = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{3}Z$`)
+)
+
+// violations collects the broken invariants of one result
+type violations []string
+
+func (v *violations) add(format string, args ...interface{}) {
+	*v = append(*v, fmt.Sprintf(format, args...))
+}
+
+func (v violations) err() error {
+	if len(v) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%s", strings.Join(v, "; "))
+}
+
+// equal compares amounts with a tolerance relative to their size
+func equal(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+}
+
+func (v *violations) datetime(timestamp int64, datetime string) {
+	if timestamp <= 0 {
+		v.add("timestamp %d is not set", timestamp)
+		return
+	}
+	if !iso8601.MatchString(datetime) {
+		v.add("datetime %q is not ISO 8601 UTC with milliseconds", datetime)
+		return
+	}
+	t, _ := time.Parse(time.RFC3339, datetime)
+	if ms := t.UnixNano() / int64(time.Millisecond); ms != timestamp {
+		v.add("datetime %s does not match timestamp %d", datetime, timestamp)
+	}
+}
+
+// CheckSymbol checks that symbol is a unified BASE/QUOTE symbol
+func CheckSymbol(symbol string) error {
+	if !unifiedSymbol.MatchString(symbol) {
+		return fmt.Errorf("symbol %q is not unified BASE/QUOTE", symbol)
+	}
+	return nil
+}
+
+// CheckMarket checks a loaded market
+func CheckMarket(market *Market) error {
+	var v violations
+	if err := CheckSymbol(market.Symbol); err != nil {
+		v.add("%v", err)
+	}
+	if market.Id == "" {
+		v.add("market %s has no id", market.Symbol)
+	}
+	if market.Base+"/"+market.Quote != market.Symbol {
+		v.add("market %s has base %q and quote %q", market.Symbol, market.Base, market.Quote)
+	}
+	return v.err()
+}
+
+// CheckOrderBook checks that both sides are sorted best first, do not
+// cross and only hold positive prices and amounts
+func CheckOrderBook(book *OrderBook) error {
+	if book == nil {
+		return fmt.Errorf("order book is nil")
+	}
+	var v violations
+	check := func(name string, side [][2]float64, better func(a, b float64) bool) {
+		for i, level := range side {
+			if level[0] <= 0 || level[1] <= 0 {
+				v.add("%s[%d] = %v is not positive", name, i, level)
+			}
+			if i > 0 && !better(side[i-1][0], level[0]) {
+				v.add("%s[%d] price %v is not sorted after %v", name, i, level[0], side[i-1][0])
+			}
+		}
+	}
+	check("bids", book.Bids, func(a, b float64) bool { return a > b })
+	check("asks", book.Asks, func(a, b float64) bool { return a < b })
+	if len(book.Bids) > 0 && len(book.Asks) > 0 && book.Bids[0][0] >= book.Asks[0][0] {
+		v.add("book is crossed: bid %v >= ask %v", book.Bids[0][0], book.Asks[0][0])
+	}
+	if book.Timestamp != 0 || book.Datetime != "" {
+		v.datetime(book.Timestamp, book.Datetime)
+	}
+	return v.err()
+}
+
+// CheckBalance checks that every currency is unified, nothing is negative
+// and free + used == total
+func CheckBalance(balance *Account) error {
+	if balance == nil {
+		return fmt.Errorf("balance is nil")
+	}
+	var v violations
+	codes := map[string]bool{}
+	for _, amounts := range []map[string]float64{balance.Free, balance.Used, balance.Total} {
+		for code := range amounts {
+			codes[code] = true
+		}
+	}
+	for code := range codes {
+		free, used, total := balance.Free[code], balance.Used[code], balance.Total[code]
+		if !unifiedCurrency.MatchString(code) {
+			v.add("currency %q is not a unified code", code)
+		}
+		if free < 0 || used < 0 || total < 0 {
+			v.add("%s free %v used %v total %v has a negative amount", code, free, used, total)
+		}
+		if !equal(free+used, total) {
+			v.add("%s free %v + used %v != total %v", code, free, used, total)
+		}
+	}
+	return v.err()
+}
+
+// CheckOrder checks an order returned for symbol
+func CheckOrder(order *Order, symbol string) error {
+	if order == nil {
+		return fmt.Errorf("order is nil")
+	}
+	var v violations
+	if order.Id == "" {
+		v.add("order has no id")
+	}
+	if order.Symbol != symbol {
+		v.add("symbol %q, want %q", order.Symbol, symbol)
+	}
+	if !contains(Statuses, order.Status) {
+		v.add("status %q is not one of %v", order.Status, Statuses)
+	}
+	if order.Side != "buy" && order.Side != "sell" {
+		v.add("side %q is not buy or sell", order.Side)
+	}
+	if order.Type != strings.ToLower(order.Type) || order.Type == "" {
+		v.add("type %q is not a lowercase unified type", order.Type)
+	}
+	if order.Amount <= 0 || order.Filled < 0 || order.Remaining < 0 {
+		v.add("amount %v filled %v remaining %v must not be negative", order.Amount, order.Filled, order.Remaining)
+	}
+	if !equal(order.Filled+order.Remaining, order.Amount) {
+		v.add("filled %v + remaining %v != amount %v", order.Filled, order.Remaining, order.Amount)
+	}
+	if order.Status == "open" && order.Remaining == 0 {
+		v.add("open order has nothing remaining")
+	}
+	if order.Status == "closed" && order.Remaining != 0 {
+		v.add("closed order still has %v remaining", order.Remaining)
+	}
+	v.datetime(order.Timestamp, order.Datetime)
+	return v.err()
+}
+
+// CheckPlacedOrder checks the result of CreateOrder. Many exchanges only
+// acknowledge a placement with the order id, so only the fields the caller
+// supplied are required to be set.
+func CheckPlacedOrder(order *Order, symbol, side string, amount float64) error {
+	if order == nil {
+		return fmt.Errorf("order is nil")
+	}
+	var v violations
+	if order.Id == "" {
+		v.add("order has no id")
+	}
+	if order.Symbol != symbol {
+		v.add("symbol %q, want %q", order.Symbol, symbol)
+	}
+	if order.Side != "" && order.Side != side {
+		v.add("side %q, want %q", order.Side, side)
+	}
+	if order.Amount != 0 && !equal(order.Amount, amount) {
+		v.add("amount %v, want %v", order.Amount, amount)
+	}
+	if order.Status != "" && !contains(Statuses, order.Status) {
+		v.add("status %q is not one of %v", order.Status, Statuses)
+	}
+	return v.err()
+}
+
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Server is the part of a fake exchange server Run needs, see package fake
+type Server interface {
+	SetBook(symbol string, book *OrderBook)
+}
+
+// Run is RunConfig against a fake server whose BTC/USDT book is above 9000,
+// so a 0.01 buy at 9000 rests on it until Run moves the book to 8980/8990
+func Run(t *testing.T, ex ExchangeInterface, server Server) {
+	RunConfig(t, ex, Config{
+		Symbol: "BTC/USDT",
+		Price:  9000,
+		Amount: 0.01,
+		Cross: func() {
+			server.SetBook("BTC/USDT", &OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+		},
+	})
+}
+
+// RunConfig checks markets, the order book and the balance of ex, then
+// places, fetches and cancels a resting order, and fills one if
+// config.Cross is set
+func RunConfig(t *testing.T, ex ExchangeInterface, config Config) {
+	symbol := config.Symbol
+	if err := CheckSymbol(symbol); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Markets", func(t *testing.T) {
+		for _, market := range ex.LoadMarkets() {
+			if err := CheckMarket(market); err != nil {
+				t.Error(err)
+			}
+		}
+	})
+
+	t.Run("OrderBook", func(t *testing.T) {
+		book, err := ex.FetchOrderBook(symbol, 5, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = CheckOrderBook(book); err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("Balance", func(t *testing.T) {
+		balance, err := ex.FetchBalance(nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = CheckBalance(balance); err != nil {
+			t.Error(err)
+		}
+	})
+
+	fetch := func(t *testing.T, id string, status string) *Order {
+		order, err := ex.FetchOrder(id, symbol, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = CheckOrder(order, symbol); err != nil {
+			t.Error(err)
+		}
+		if order.Id != id || order.Status != status {
+			t.Errorf("FetchOrder(%s) = id %s status %s, want status %s", id, order.Id, order.Status, status)
+		}
+		return order
+	}
+
+	t.Run("OrderLifecycle", func(t *testing.T) {
+		placed, err := ex.CreateOrder(symbol, "limit", "buy", config.Amount, config.Price, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = CheckPlacedOrder(placed, symbol, "buy", config.Amount); err != nil {
+			t.Error(err)
+		}
+		order := fetch(t, placed.Id, "open")
+		if order.Filled != 0 || !equal(order.Amount, config.Amount) || !equal(order.Price, config.Price) {
+			t.Errorf("resting order filled %v amount %v price %v", order.Filled, order.Amount, order.Price)
+		}
+
+		open, err := ex.FetchOpenOrders(symbol, 0, 0, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, o := range open {
+			if err = CheckOrder(o, symbol); err != nil {
+				t.Error(err)
+			}
+			if o.Status != "open" {
+				t.Errorf("FetchOpenOrders returned order %s with status %s", o.Id, o.Status)
+			}
+			found = found || o.Id == placed.Id
+		}
+		if !found {
+			t.Errorf("FetchOpenOrders does not contain order %s", placed.Id)
+		}
+
+		if _, err = ex.CancelOrder(placed.Id, symbol, nil); err != nil {
+			t.Fatal(err)
+		}
+		fetch(t, placed.Id, "canceled")
+	})
+
+	if config.Cross == nil {
+		return
+	}
+	t.Run("OrderFill", func(t *testing.T) {
+		placed, err := ex.CreateOrder(symbol, "limit", "buy", config.Amount, config.Price, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		config.Cross()
+		order := fetch(t, placed.Id, "closed")
+		if !equal(order.Filled, config.Amount) {
+			t.Errorf("filled %v, want %v", order.Filled, config.Amount)
+		}
+	})
+}
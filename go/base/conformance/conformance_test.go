@@ -0,0 +1,94 @@
+package conformance
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+func TestCheckOrderBook(t *testing.T) {
+	good := &OrderBook{Bids: [][2]float64{{99, 1}, {98, 2}}, Asks: [][2]float64{{101, 1}, {102, 2}}}
+	if err := CheckOrderBook(good); err != nil {
+		t.Fatal("good book:", err)
+	}
+	for name, test := range map[string]struct {
+		book *OrderBook
+		want string
+	}{
+		"nil":         {nil, "nil"},
+		"unsorted":    {&OrderBook{Bids: [][2]float64{{98, 1}, {99, 1}}}, "bids[1] price 99 is not sorted"},
+		"crossed":     {&OrderBook{Bids: [][2]float64{{101, 1}}, Asks: [][2]float64{{100, 1}}}, "crossed"},
+		"zero amount": {&OrderBook{Asks: [][2]float64{{101, 0}}}, "asks[0]"},
+		"datetime":    {&OrderBook{Timestamp: 1600000000000, Datetime: "2020-09-13 12:26:40"}, "ISO 8601"},
+	} {
+		if err := CheckOrderBook(test.book); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: %v, want %q", name, err, test.want)
+		}
+	}
+}
+
+func TestCheckBalance(t *testing.T) {
+	good := &Account{
+		Free:  map[string]float64{"USDT": 90},
+		Used:  map[string]float64{"USDT": 10},
+		Total: map[string]float64{"USDT": 100},
+	}
+	if err := CheckBalance(good); err != nil {
+		t.Fatal("good balance:", err)
+	}
+	for name, test := range map[string]struct {
+		balance *Account
+		want    string
+	}{
+		"nil":      {nil, "nil"},
+		"total":    {&Account{Free: map[string]float64{"USDT": 90}, Total: map[string]float64{"USDT": 100}}, "!= total"},
+		"negative": {&Account{Free: map[string]float64{"USDT": -1}, Used: map[string]float64{"USDT": 1}}, "negative"},
+		"code":     {&Account{Free: map[string]float64{"usdt": 1}, Total: map[string]float64{"usdt": 1}}, "not a unified code"},
+	} {
+		if err := CheckBalance(test.balance); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: %v, want %q", name, err, test.want)
+		}
+	}
+}
+
+func TestCheckOrder(t *testing.T) {
+	order := func(change func(o *Order)) *Order {
+		o := &Order{
+			Id:        "1",
+			Symbol:    "BTC/USDT",
+			Status:    "open",
+			Side:      "buy",
+			Type:      "limit",
+			Amount:    1,
+			Remaining: 1,
+			Timestamp: 1600000000000,
+			Datetime:  "2020-09-13T12:26:40.000Z",
+		}
+		if change != nil {
+			change(o)
+		}
+		return o
+	}
+	if err := CheckOrder(order(nil), "BTC/USDT"); err != nil {
+		t.Fatal("good order:", err)
+	}
+	for name, test := range map[string]struct {
+		order *Order
+		want  string
+	}{
+		"nil":       {nil, "nil"},
+		"id":        {order(func(o *Order) { o.Id = "" }), "no id"},
+		"symbol":    {order(func(o *Order) { o.Symbol = "ETH/USDT" }), "want \"BTC/USDT\""},
+		"status":    {order(func(o *Order) { o.Status = "NEW" }), "status \"NEW\""},
+		"side":      {order(func(o *Order) { o.Side = "BUY" }), "side \"BUY\""},
+		"type":      {order(func(o *Order) { o.Type = "LIMIT" }), "type \"LIMIT\""},
+		"remaining": {order(func(o *Order) { o.Filled = 0.5 }), "!= amount"},
+		"closed":    {order(func(o *Order) { o.Status = "closed" }), "still has 1 remaining"},
+		"datetime":  {order(func(o *Order) { o.Timestamp++ }), "does not match"},
+	} {
+		if err := CheckOrder(test.order, "BTC/USDT"); err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s: %v, want %q", name, err, test.want)
+		}
+	}
+}
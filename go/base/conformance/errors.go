@@ -0,0 +1,51 @@
+package conformance
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// Fetcher is the part of an adapter ResponseError needs, every adapter gets
+// it from the embedded Exchange
+type Fetcher interface {
+	Fetch(url string, method string, headers map[string]interface{}, body interface{}) interface{}
+	PanicToError(e interface{}) error
+}
+
+// ErrorCase is a response and the error class it must raise, "" for none
+type ErrorCase struct {
+	Status int
+	Body   string
+	Class  string
+}
+
+// ResponseError returns the error ex raises for a response
+func ResponseError(ex Fetcher, status int, body string) (err error) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+	defer func() {
+		if e := recover(); e != nil {
+			err = ex.PanicToError(e)
+		}
+	}()
+	ex.Fetch(server.URL, "GET", map[string]interface{}{}, nil)
+	return
+}
+
+// RunErrors checks that every response of cases raises its error class
+func RunErrors(t *testing.T, ex Fetcher, cases []ErrorCase) {
+	for _, c := range cases {
+		err := ResponseError(ex, c.Status, c.Body)
+		var e *Error
+		if c.Class == "" && err != nil || c.Class != "" && (!errors.As(err, &e) || e.Class != c.Class) {
+			t.Errorf("%d %s: got %v, want %s", c.Status, c.Body, err, c.Class)
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package base
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// Credentials sign the private requests of an exchange
+type Credentials struct {
+	ApiKey   string `json:"apiKey"`
+	Secret   string `json:"secret"`
+	Password string `json:"password"`
+}
+
+// CredentialProvider is asked for the credentials every time a request is
+// signed, so rotated credentials are used without rebuilding the exchange.
+// It must be safe for concurrent use and should cache what is slow to load.
+type CredentialProvider interface {
+	Credentials() (Credentials, error)
+}
+
+// Credentials returns the credentials to sign with, from the
+// CredentialProvider if one is set, else from ApiKey, Secret and Password.
+// A provider that fails raises AuthenticationError.
+func (self *Exchange) Credentials() Credentials {
+	credentials, err := self.currentCredentials()
+	if err != nil {
+		self.RaiseException("AuthenticationError", fmt.Sprintf("%s credentials: %v", self.Id, err))
+	}
+	return credentials
+}
+
+func (self *Exchange) currentCredentials() (Credentials, error) {
+	self.credentialsMu.RLock()
+	provider := self.CredentialProvider
+	self.credentialsMu.RUnlock()
+	if provider == nil {
+		return Credentials{ApiKey: self.ApiKey, Secret: self.Secret, Password: self.Password}, nil
+	}
+	return provider.Credentials()
+}
+
+// SetCredentialProvider replaces the provider while the exchange is in use,
+// nil goes back to ApiKey, Secret and Password
+func (self *Exchange) SetCredentialProvider(provider CredentialProvider) {
+	self.credentialsMu.Lock()
+	self.CredentialProvider = provider
+	self.credentialsMu.Unlock()
+}
+
+// defaultRequiredCredentials apply to exchanges whose describe does not
+// list its "requiredCredentials"
+var defaultRequiredCredentials = map[string]interface{}{"apiKey": true, "secret": true}
+
+// CheckRequiredCredentials raises AuthenticationError if a credential the
+// exchange requires is empty
+func (self *Exchange) CheckRequiredCredentials() {
+	required, ok := self.DescribeMap["requiredCredentials"].(map[string]interface{})
+	if !ok {
+		required = defaultRequiredCredentials
+	}
+	credentials := self.Credentials()
+	for _, credential := range []struct{ name, value string }{
+		{"apiKey", credentials.ApiKey},
+		{"secret", credentials.Secret},
+		{"password", credentials.Password},
+	} {
+		if self.ToBool(required[credential.name]) && credential.value == "" {
+			self.RaiseException("AuthenticationError", self.Id+" requires `"+credential.name+"` credential")
+		}
+	}
+}
+
+// EnvCredentials reads <Prefix>_API_KEY, <Prefix>_SECRET and
+// <Prefix>_PASSWORD, e.g. OKEX_API_KEY for the prefix "OKEX"
+type EnvCredentials struct {
+	Prefix string
+}
+
+func (p EnvCredentials) Credentials() (Credentials, error) {
+	prefix := strings.ToUpper(p.Prefix) + "_"
+	return Credentials{
+		ApiKey:   os.Getenv(prefix + "API_KEY"),
+		Secret:   os.Getenv(prefix + "SECRET"),
+		Password: os.Getenv(prefix + "PASSWORD"),
+	}, nil
+}
+
+// FileCredentials reads a JSON file with "apiKey", "secret" and "password",
+// like the api.json of the recording tests. The file is read again when it
+// changes, rotate credentials by replacing it.
+type FileCredentials struct {
+	path   string
+	decode func(data []byte) (Credentials, error)
+
+	mu          sync.Mutex
+	modTime     time.Time
+	size        int64
+	credentials Credentials
+}
+
+// NewFileCredentials returns a provider for the plain JSON file at path
+func NewFileCredentials(path string) *FileCredentials {
+	return &FileCredentials{path: path, decode: func(data []byte) (credentials Credentials, err error) {
+		err = json.Unmarshal(data, &credentials)
+		return
+	}}
+}
+
+// NewEncryptedFileCredentials returns a provider for a file written with
+// EncryptCredentials and passphrase
+func NewEncryptedFileCredentials(path string, passphrase string) *FileCredentials {
+	return &FileCredentials{path: path, decode: func(data []byte) (Credentials, error) {
+		return DecryptCredentials(data, passphrase)
+	}}
+}
+
+func (p *FileCredentials) Credentials() (Credentials, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	info, err := os.Stat(p.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	if info.ModTime().Equal(p.modTime) && info.Size() == p.size {
+		return p.credentials, nil
+	}
+	data, err := ioutil.ReadFile(p.path)
+	if err != nil {
+		return Credentials{}, err
+	}
+	credentials, err := p.decode(data)
+	if err != nil {
+		return Credentials{}, fmt.Errorf("%s: %v", p.path, err)
+	}
+	p.modTime, p.size, p.credentials = info.ModTime(), info.Size(), credentials
+	return credentials, nil
+}
+
+// encryptedCredentials is the format of an encrypted credentials file, the
+// JSON credentials sealed with AES-256-GCM under a PBKDF2-SHA256 key
+type encryptedCredentials struct {
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+const credentialsIterations = 100000
+
+// EncryptCredentials seals credentials with passphrase, for
+// NewEncryptedFileCredentials
+func EncryptCredentials(credentials Credentials, passphrase string) ([]byte, error) {
+	plaintext, err := json.Marshal(credentials)
+	if err != nil {
+		return nil, err
+	}
+	sealed := encryptedCredentials{Iterations: credentialsIterations, Salt: make([]byte, 16)}
+	if _, err = rand.Read(sealed.Salt); err != nil {
+		return nil, err
+	}
+	gcm, err := credentialsCipher(passphrase, sealed.Salt, sealed.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	sealed.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(sealed.Nonce); err != nil {
+		return nil, err
+	}
+	sealed.Ciphertext = gcm.Seal(nil, sealed.Nonce, plaintext, nil)
+	return json.Marshal(sealed)
+}
+
+// DecryptCredentials opens what EncryptCredentials sealed
+func DecryptCredentials(data []byte, passphrase string) (credentials Credentials, err error) {
+	var sealed encryptedCredentials
+	if err = json.Unmarshal(data, &sealed); err != nil {
+		return
+	}
+	if sealed.Iterations <= 0 || len(sealed.Salt) == 0 {
+		err = fmt.Errorf("not an encrypted credentials file")
+		return
+	}
+	gcm, err := credentialsCipher(passphrase, sealed.Salt, sealed.Iterations)
+	if err != nil {
+		return
+	}
+	if len(sealed.Nonce) != gcm.NonceSize() {
+		err = fmt.Errorf("invalid nonce")
+		return
+	}
+	plaintext, err := gcm.Open(nil, sealed.Nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		err = fmt.Errorf("wrong passphrase or corrupted file")
+		return
+	}
+	err = json.Unmarshal(plaintext, &credentials)
+	return
+}
+
+func credentialsCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(pbkdf2.Key([]byte(passphrase), salt, iterations, 32, sha256.New))
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
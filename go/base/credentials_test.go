@@ -0,0 +1,81 @@
+package base
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEnvCredentials(t *testing.T) {
+	os.Setenv("CCXT_TEST_API_KEY", "k1")
+	os.Setenv("CCXT_TEST_SECRET", "s1")
+	defer os.Unsetenv("CCXT_TEST_API_KEY")
+	defer os.Unsetenv("CCXT_TEST_SECRET")
+
+	ex := &Exchange{}
+	ex.SetCredentialProvider(EnvCredentials{Prefix: "ccxt_test"})
+	if c := ex.Credentials(); c.ApiKey != "k1" || c.Secret != "s1" || c.Password != "" {
+		t.Fatal(c)
+	}
+	ex.SetCredentialProvider(nil)
+	ex.ApiKey = "k2"
+	if c := ex.Credentials(); c.ApiKey != "k2" {
+		t.Fatal("config credentials:", c)
+	}
+}
+
+func TestEncryptedFileCredentials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "api.enc")
+	data, err := EncryptCredentials(Credentials{ApiKey: "k1", Secret: "secret-5678", Password: "p1"}, "passphrase")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret-5678") {
+		t.Fatal("plaintext secret in", string(data))
+	}
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := NewEncryptedFileCredentials(path, "passphrase").Credentials()
+	if err != nil || c != (Credentials{ApiKey: "k1", Secret: "secret-5678", Password: "p1"}) {
+		t.Fatal(c, err)
+	}
+	if _, err := NewEncryptedFileCredentials(path, "wrong").Credentials(); err == nil {
+		t.Fatal("opened with the wrong passphrase")
+	}
+}
+
+func TestCheckRequiredCredentials(t *testing.T) {
+	check := func(ex *Exchange) (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = ex.PanicToError(e)
+			}
+		}()
+		ex.CheckRequiredCredentials()
+		return
+	}
+
+	ex := &Exchange{}
+	ex.ApiKey = "k1"
+	if err := check(ex); !errors.Is(err, AuthenticationError) || !strings.Contains(err.Error(), "`secret`") {
+		t.Fatal("default requirements:", err)
+	}
+	ex.Secret = "s1"
+	if err := check(ex); err != nil {
+		t.Fatal(err)
+	}
+	ex.DescribeMap = map[string]interface{}{"requiredCredentials": map[string]interface{}{"apiKey": true, "secret": true, "password": true}}
+	if err := check(ex); !errors.Is(err, AuthenticationError) || !strings.Contains(err.Error(), "`password`") {
+		t.Fatal("password:", err)
+	}
+}
@@ -0,0 +1,186 @@
+package base
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultHttpExceptions maps HTTP statuses to error classes for responses
+// the exchange's own rules did not recognize
+var DefaultHttpExceptions = map[string]string{
+	"400": "BadRequest",
+	"401": "AuthenticationError",
+	"403": "PermissionDenied",
+	"404": "ExchangeNotAvailable",
+	"405": "ExchangeNotAvailable",
+	"408": "RequestTimeout",
+	"409": "ExchangeNotAvailable",
+	"410": "ExchangeNotAvailable",
+	"418": "DDoSProtection",
+	"422": "ExchangeError",
+	"429": "RateLimitExceeded",
+	"500": "ExchangeNotAvailable",
+	"501": "ExchangeNotAvailable",
+	"502": "ExchangeNotAvailable",
+	"503": "ExchangeNotAvailable",
+	"504": "RequestTimeout",
+	"511": "AuthenticationError",
+	"520": "ExchangeNotAvailable",
+	"521": "ExchangeNotAvailable",
+	"522": "ExchangeNotAvailable",
+	"525": "ExchangeNotAvailable",
+	"526": "ExchangeNotAvailable",
+	"530": "ExchangeNotAvailable",
+}
+
+// ddosProtection recognizes the pages of the anti-DDoS services in front of
+// an exchange that is unavailable
+var ddosProtection = regexp.MustCompile(`(?i)(cloudflare|incapsula|overload|ddos)`)
+
+type broadRule struct {
+	substring string
+	class     string
+}
+
+type regexRule struct {
+	pattern *regexp.Regexp
+	class   string
+}
+
+// ErrorRules maps the error codes and messages of an exchange to error
+// classes. It is compiled from the describe's "exceptions", either a flat
+// map of exact rules or a map with "exact", "broad" and "regex" sections,
+// and from "httpExceptions", which override DefaultHttpExceptions.
+//
+// Match gives exact rules precedence over broad rules, and broad rules over
+// regex rules. Among broad and regex rules the longest key wins, since it is
+// the most specific.
+type ErrorRules struct {
+	exact map[string]string
+	broad []broadRule
+	regex []regexRule
+	http  map[int]string
+}
+
+// NewErrorRules compiles exceptions and httpExceptions, either may be nil.
+// Unknown classes and invalid patterns are errors.
+func NewErrorRules(exceptions interface{}, httpExceptions interface{}) (rules *ErrorRules, err error) {
+	rules = &ErrorRules{exact: map[string]string{}, http: map[int]string{}}
+	section := func(name string, m interface{}, add func(key, class string) error) error {
+		entries, ok := m.(map[string]interface{})
+		if m != nil && !ok {
+			return fmt.Errorf("exceptions %s must be an object, got %T", name, m)
+		}
+		for key, value := range entries {
+			class, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("exceptions %s %q must map to a class name, got %T", name, key, value)
+			}
+			if _, known := errorClasses[class]; !known {
+				return fmt.Errorf("exceptions %s %q maps to unknown class %q", name, key, class)
+			}
+			if err := add(key, class); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	addExact := func(key, class string) error {
+		rules.exact[key] = class
+		return nil
+	}
+
+	sections, _ := exceptions.(map[string]interface{})
+	_, hasExact := sections["exact"]
+	_, hasBroad := sections["broad"]
+	_, hasRegex := sections["regex"]
+	if hasExact || hasBroad || hasRegex {
+		if err = section("exact", sections["exact"], addExact); err != nil {
+			return nil, err
+		}
+		err = section("broad", sections["broad"], func(key, class string) error {
+			rules.broad = append(rules.broad, broadRule{key, class})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		err = section("regex", sections["regex"], func(key, class string) error {
+			pattern, err := regexp.Compile(key)
+			if err != nil {
+				return fmt.Errorf("exceptions regex %q: %v", key, err)
+			}
+			rules.regex = append(rules.regex, regexRule{pattern, class})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err = section("exact", exceptions, addExact); err != nil {
+		return nil, err
+	}
+	sort.Slice(rules.broad, func(i, j int) bool {
+		a, b := rules.broad[i].substring, rules.broad[j].substring
+		return len(a) > len(b) || len(a) == len(b) && a < b
+	})
+	sort.Slice(rules.regex, func(i, j int) bool {
+		a, b := rules.regex[i].pattern.String(), rules.regex[j].pattern.String()
+		return len(a) > len(b) || len(a) == len(b) && a < b
+	})
+
+	addHttp := func(key, class string) error {
+		status, err := strconv.Atoi(key)
+		if err != nil {
+			return fmt.Errorf("httpExceptions key %q is not a status", key)
+		}
+		rules.http[status] = class
+		return nil
+	}
+	for key, class := range DefaultHttpExceptions {
+		addHttp(key, class)
+	}
+	if err = section("httpExceptions", httpExceptions, addHttp); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Match returns the class of the first rule that matches any of s, trying
+// every string against the exact rules before the broad and regex ones, or
+// "" if none does. Empty strings never match.
+func (r *ErrorRules) Match(s ...string) string {
+	for _, text := range s {
+		if class, ok := r.exact[text]; ok && text != "" {
+			return class
+		}
+	}
+	for _, rule := range r.broad {
+		for _, text := range s {
+			if text != "" && strings.Contains(text, rule.substring) {
+				return rule.class
+			}
+		}
+	}
+	for _, rule := range r.regex {
+		for _, text := range s {
+			if text != "" && rule.pattern.MatchString(text) {
+				return rule.class
+			}
+		}
+	}
+	return ""
+}
+
+// MatchHttp returns the class for an HTTP status, "" for one that is not
+// an error. An unavailable exchange behind an anti-DDoS page is reported as
+// DDoSProtection.
+func (r *ErrorRules) MatchHttp(status int, body string) string {
+	class := r.http[status]
+	if class == "ExchangeNotAvailable" && ddosProtection.MatchString(body) {
+		return "DDoSProtection"
+	}
+	return class
+}
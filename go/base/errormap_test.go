@@ -0,0 +1,67 @@
+package base
+
+import (
+	"testing"
+)
+
+func TestErrorRulesPrecedence(t *testing.T) {
+	rules, err := NewErrorRules(map[string]interface{}{
+		"exact": map[string]interface{}{"1001": "InsufficientFunds", "order not found": "OrderNotFound"},
+		"broad": map[string]interface{}{"order": "InvalidOrder", "order amount": "BadRequest"},
+		"regex": map[string]interface{}{`^rate limit \d+$`: "RateLimitExceeded", `limit`: "DDoSProtection"},
+	}, map[string]interface{}{"418": "OnMaintenance"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, c := range []struct {
+		s    []string
+		want string
+	}{
+		{[]string{"order not found", "1001"}, "OrderNotFound"},
+		{[]string{"bad order", "1001"}, "InsufficientFunds"},
+		{[]string{"bad order amount"}, "BadRequest"},
+		{[]string{"rate limit 5 order"}, "InvalidOrder"},
+		{[]string{"rate limit 5"}, "RateLimitExceeded"},
+		{[]string{"limit"}, "DDoSProtection"},
+		{[]string{"", "nothing"}, ""},
+	} {
+		if got := rules.Match(c.s...); got != c.want {
+			t.Errorf("Match(%q) = %q, want %q", c.s, got, c.want)
+		}
+	}
+	for _, c := range []struct {
+		status int
+		body   string
+		want   string
+	}{
+		{200, "", ""},
+		{400, "", "BadRequest"},
+		{403, "", "PermissionDenied"},
+		{418, "", "OnMaintenance"},
+		{502, "", "ExchangeNotAvailable"},
+		{502, "Cloudflare says no", "DDoSProtection"},
+	} {
+		if got := rules.MatchHttp(c.status, c.body); got != c.want {
+			t.Errorf("MatchHttp(%d, %q) = %q, want %q", c.status, c.body, got, c.want)
+		}
+	}
+}
+
+func TestErrorRulesFlatAndInvalid(t *testing.T) {
+	rules, err := NewErrorRules(map[string]interface{}{"-2013": "OrderNotFound"}, nil)
+	if err != nil || rules.Match("-2013") != "OrderNotFound" {
+		t.Fatal("flat exceptions:", err)
+	}
+	for _, exceptions := range []map[string]interface{}{
+		{"exact": map[string]interface{}{"1": "NoSuchClass"}},
+		{"regex": map[string]interface{}{"(": "BadRequest"}},
+		{"broad": "BadRequest"},
+	} {
+		if _, err := NewErrorRules(exceptions, nil); err == nil {
+			t.Error("accepted", exceptions)
+		}
+	}
+	if _, err := NewErrorRules(nil, map[string]interface{}{"teapot": "BadRequest"}); err == nil {
+		t.Error("accepted a status that is not a number")
+	}
+}
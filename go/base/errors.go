@@ -3,6 +3,7 @@ package base
 import (
 	"errors"
 	"fmt"
+	"time"
 )
 
 var (
@@ -13,99 +14,124 @@ var (
 	ExchangeError = fmt.Errorf("%w", BaseError)
 
 	AuthenticationError = fmt.Errorf("%w", ExchangeError)
-	PermissionDenied = fmt.Errorf("%w", AuthenticationError)
-	AccountSuspended = fmt.Errorf("%w", AuthenticationError)
+	PermissionDenied    = fmt.Errorf("%w", AuthenticationError)
+	AccountSuspended    = fmt.Errorf("%w", AuthenticationError)
 
 	ArgumentsRequired = fmt.Errorf("%w", ExchangeError)
-	BadRequest = fmt.Errorf("%w", ExchangeError)
-	BadSymbol = fmt.Errorf("%w", BadRequest)
+	BadRequest        = fmt.Errorf("%w", ExchangeError)
+	BadSymbol         = fmt.Errorf("%w", BadRequest)
 
-	BadResponse = fmt.Errorf("%w", ExchangeError)
+	BadResponse  = fmt.Errorf("%w", ExchangeError)
 	NullResponse = fmt.Errorf("%w", BadResponse)
 
 	InsufficientFunds = fmt.Errorf("%w", ExchangeError)
-	InvalidAddress = fmt.Errorf("%w", ExchangeError)
-	AddressPending = fmt.Errorf("%w", InvalidAddress)
+	InvalidAddress    = fmt.Errorf("%w", ExchangeError)
+	AddressPending    = fmt.Errorf("%w", InvalidAddress)
 
-	InvalidOrder = fmt.Errorf("%w", ExchangeError)
-	OrderNotFound = fmt.Errorf("%w", InvalidOrder)
-	OrderNotCached = fmt.Errorf("%w", InvalidOrder)
-	CancelPending = fmt.Errorf("%w", InvalidOrder)
+	InvalidOrder             = fmt.Errorf("%w", ExchangeError)
+	OrderNotFound            = fmt.Errorf("%w", InvalidOrder)
+	OrderNotCached           = fmt.Errorf("%w", InvalidOrder)
+	CancelPending            = fmt.Errorf("%w", InvalidOrder)
 	OrderImmediatelyFillable = fmt.Errorf("%w", InvalidOrder)
-	OrderNotFillable = fmt.Errorf("%w", InvalidOrder)
-	DuplicateOrderId = fmt.Errorf("%w", InvalidOrder)
+	OrderNotFillable         = fmt.Errorf("%w", InvalidOrder)
+	DuplicateOrderId         = fmt.Errorf("%w", InvalidOrder)
 
 	NotSupported = fmt.Errorf("%w", ExchangeError)
 
-	NetworkError = fmt.Errorf("%w", BaseError)
-	DDoSProtection = fmt.Errorf("%w", NetworkError)
-	RateLimitExceeded = fmt.Errorf("%w", DDoSProtection)
+	NetworkError         = fmt.Errorf("%w", BaseError)
+	DDoSProtection       = fmt.Errorf("%w", NetworkError)
+	RateLimitExceeded    = fmt.Errorf("%w", DDoSProtection)
 	ExchangeNotAvailable = fmt.Errorf("%w", NetworkError)
-	OnMaintenance = fmt.Errorf("%w", ExchangeNotAvailable)
-	InvalidNonce = fmt.Errorf("%w", NetworkError)
-	RequestTimeout = fmt.Errorf("%w", NetworkError)
+	OnMaintenance        = fmt.Errorf("%w", ExchangeNotAvailable)
+	InvalidNonce         = fmt.Errorf("%w", NetworkError)
+	RequestTimeout       = fmt.Errorf("%w", NetworkError)
 )
 
+// errorClasses maps every class name to its sentinel
+var errorClasses = map[string]error{
+	"BaseError":                BaseError,
+	"InternalError":            InternalError,
+	"ExchangeError":            ExchangeError,
+	"AuthenticationError":      AuthenticationError,
+	"PermissionDenied":         PermissionDenied,
+	"AccountSuspended":         AccountSuspended,
+	"ArgumentsRequired":        ArgumentsRequired,
+	"BadRequest":               BadRequest,
+	"BadSymbol":                BadSymbol,
+	"BadResponse":              BadResponse,
+	"NullResponse":             NullResponse,
+	"InsufficientFunds":        InsufficientFunds,
+	"InvalidAddress":           InvalidAddress,
+	"AddressPending":           AddressPending,
+	"InvalidOrder":             InvalidOrder,
+	"OrderNotFound":            OrderNotFound,
+	"OrderNotCached":           OrderNotCached,
+	"CancelPending":            CancelPending,
+	"OrderImmediatelyFillable": OrderImmediatelyFillable,
+	"OrderNotFillable":         OrderNotFillable,
+	"DuplicateOrderId":         DuplicateOrderId,
+	"NotSupported":             NotSupported,
+	"NetworkError":             NetworkError,
+	"DDoSProtection":           DDoSProtection,
+	"RateLimitExceeded":        RateLimitExceeded,
+	"ExchangeNotAvailable":     ExchangeNotAvailable,
+	"OnMaintenance":            OnMaintenance,
+	"InvalidNonce":             InvalidNonce,
+	"RequestTimeout":           RequestTimeout,
+}
 
-// TypedError creates a typed error from type t and message, if type does
-// not match a known error type, fmt.Errorf will be used
-func TypedError(t string, msg string) error {
-	var err error
-	switch t {
-	case "BaseError":
-		err = BaseError
-	case "AccountSuspended":
-		err = AccountSuspended
-	case "NullResponse":
-		err = NullResponse
-	case "AddressPending":
-		err = AddressPending
-	case "OrderImmediatelyFillable":
-		err = OrderImmediatelyFillable
-	case "OrderNotFillable":
-		err = OrderNotFillable
-	case "DuplicateOrderId":
-		err = DuplicateOrderId
-	case "OnMaintenance":
-		err = OnMaintenance
-	case "NotSupported":
-		err = NotSupported
-	case "RateLimitExceeded":
-		err = RateLimitExceeded
-	case "ArgumentsRequired":
-		err = ArgumentsRequired
-	case "AuthenticationError":
-		err = AuthenticationError
-	case "InvalidNonce":
-		err = InvalidNonce
-	case "InsufficientFunds":
-		err = InsufficientFunds
-	case "InvalidOrder":
-		err = InvalidOrder
-	case "OrderNotFound":
-		err = OrderNotFound
-	case "OrderNotCached":
-		err = OrderNotCached
-	case "PermissionDenied":
-		err = PermissionDenied
-	case "CancelPending":
-		err = CancelPending
-	case "NetworkError":
-		err = NetworkError
-	case "DDoSProtection":
-		err = DDoSProtection
-	case "RequestTimeout":
-		err = RequestTimeout
-	case "ExchangeNotAvailable":
-		err = ExchangeNotAvailable
-	case "BadSymbol":
-		err = BadSymbol
-	case "InternalError":
-		err = InternalError
-	default:
-		err = errors.New(t)
-	}
+// Error is the concrete type of the errors returned by the exchanges. Test
+// its class with errors.Is against the sentinels above and read the details
+// with errors.As:
+//
+//	var e *base.Error
+//	if errors.As(err, &e) && e.Status == 429 { ... }
+//
+// The request fields are empty when the error did not come from a response.
+type Error struct {
+	// Class is the name of the sentinel, e.g. "InsufficientFunds"
+	Class   string
+	Message string
+	// Exchange is the id of the exchange that raised it
+	Exchange string
+	// Code is the exchange specific error code from the response body
+	Code string
+	// Status is the HTTP status of the response, 0 without one
+	Status int
+	Method string
+	Url    string
+	// Body is the raw response body
+	Body string
+	// RequestId matches the request's log records
+	RequestId string
+	// Retryable is set for a NetworkError, which may succeed if sent again
+	Retryable bool
+	// RetryAfter is the delay the exchange asked for, 0 if it did not
+	RetryAfter time.Duration
 
-	return fmt.Errorf("%w%v: %v", err, t, msg)
+	class error
 }
 
+func (e *Error) Error() string {
+	return e.Class + ": " + e.Message
+}
+
+// Unwrap returns the sentinel of the class
+func (e *Error) Unwrap() error {
+	return e.class
+}
+
+// TypedError creates an *Error of class t. A class that is not known is
+// treated as a plain ExchangeError.
+func TypedError(t string, msg string) error {
+	class, ok := errorClasses[t]
+	if !ok {
+		class = ExchangeError
+	}
+	return &Error{
+		Class:     t,
+		Message:   msg,
+		Retryable: errors.Is(class, NetworkError),
+		class:     class,
+	}
+}
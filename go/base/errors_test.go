@@ -0,0 +1,28 @@
+package base
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTypedErrorCoversEveryClass(t *testing.T) {
+	for name, class := range errorClasses {
+		err := TypedError(name, "message")
+		var e *Error
+		if !errors.As(err, &e) || e.Class != name || !errors.Is(err, class) || !errors.Is(err, BaseError) {
+			t.Fatalf("%s: %#v", name, err)
+		}
+		if e.Retryable != errors.Is(err, NetworkError) {
+			t.Fatalf("%s retryable %v", name, e.Retryable)
+		}
+		if err.Error() != name+": message" {
+			t.Fatalf("%s: %q", name, err.Error())
+		}
+	}
+	if err := TypedError("BadRequest", ""); !errors.Is(err, ExchangeError) || errors.Is(err, NetworkError) {
+		t.Fatal("BadRequest:", err)
+	}
+	if err := TypedError("SomethingNew", ""); !errors.Is(err, ExchangeError) {
+		t.Fatal("unknown class:", err)
+	}
+}
@@ -2,6 +2,7 @@ package base
 
 import (
 	"bytes"
+	"crypto"
 	"crypto/hmac"
 	"crypto/md5"
 	"crypto/sha1"
@@ -18,15 +19,15 @@ import (
 	"fmt"
 	"hash"
 	"io/ioutil"
-	"log"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
 	"reflect"
 	"regexp"
 	"sync"
-	"syscall"
 
+	"runtime"
 	"runtime/debug"
 	"sort"
 	"strconv"
@@ -102,6 +103,44 @@ type ExchangeConfig struct {
 	EnableRateLimit bool          `json:"enableRateLimit"`
 	Test            bool          `json:"test"`
 	Verbose         bool          `json:"verbose"`
+	// AdjustForTimeDifference loads the exchange's clock offset with the
+	// markets, see LoadTimeDifference
+	AdjustForTimeDifference bool `json:"adjustForTimeDifference"`
+	// Retry retries transient failures, off by default
+	Retry RetryPolicy `json:"retry"`
+
+	// HttpClient, if set, sends every request, e.g. to share a connection
+	// pool. Timeout and the transport settings below are left to it then.
+	HttpClient *http.Client `json:"-"`
+	// Transport, if set, replaces the default transport, e.g. for custom TLS.
+	// ProxyUrl and LocalAddr cannot be applied to it and must be empty.
+	Transport http.RoundTripper `json:"-"`
+	// ProxyUrl is an http, https or socks5 proxy for this exchange only,
+	// ExchangeInfo.Proxy or else the environment's proxy is used when empty
+	ProxyUrl string `json:"proxyUrl"`
+	// LocalAddr binds outgoing connections to a local IP address
+	LocalAddr string `json:"localAddr"`
+	// Headers are sent with every request over ExchangeInfo.Header, request
+	// specific headers win
+	Headers map[string]string `json:"headers"`
+	// UserAgent defaults to ExchangeInfo.UserAgents["chrome"], if any
+	UserAgent string `json:"userAgent"`
+	// Logger receives requests, responses and retries with credentials
+	// redacted, see Log
+	Logger Logger `json:"-"`
+	// Metrics, if set, receives the latency, status and error class of
+	// every request and the delay before every retry
+	Metrics Metrics `json:"-"`
+	// CredentialProvider, if set, replaces ApiKey, Secret and Password
+	CredentialProvider CredentialProvider `json:"-"`
+	// KeyType is "hmac", the default, or "rsa" or "ed25519" for an API key
+	// whose Secret is a PEM private key, on the exchanges that support them
+	KeyType string `json:"keyType"`
+	// AccountType is the account orders and balances go to: "spot", the
+	// default, "margin" for cross margin, "isolated-margin", or an exchange
+	// account id such as a sub-account's. An "accountType" param overrides
+	// it per call, see SelectAccountType.
+	AccountType string `json:"accountType"`
 }
 
 // ExchangeInfo for the exchange
@@ -175,6 +214,7 @@ type HasDescription struct {
 	CancelAllOrders      bool `json:"cancelAllOrders"`
 	CancelOrder          bool `json:"cancelOrder"`
 	CancelOrders         bool `json:"cancelOrders"`
+	Borrow               bool `json:"borrow"`
 	CORS                 bool `json:"CORS"`
 	CreateDepositAddress bool `json:"createDepositAddress"`
 	CreateLimitOrder     bool `json:"createLimitOrder"`
@@ -184,14 +224,18 @@ type HasDescription struct {
 	EditOrder            bool `json:"editOrder"`
 	FetchBalance         bool `json:"fetchBalance"`
 	FetchBidsAsks        bool `json:"fetchBidsAsks"`
+	FetchBorrowInterest  bool `json:"fetchBorrowInterest"`
+	FetchBorrowRate      bool `json:"fetchBorrowRate"`
 	FetchClosedOrders    bool `json:"fetchClosedOrders"`
 	FetchCurrencies      bool `json:"fetchCurrencies"`
 	FetchDepositAddress  bool `json:"fetchDepositAddress"`
 	FetchDeposits        bool `json:"fetchDeposits"`
 	FetchFundingFees     bool `json:"fetchFundingFees"`
 	FetchL2OrderBook     bool `json:"fetchL2OrderBook"`
+	FetchLeverage        bool `json:"fetchLeverage"`
 	FetchLedger          bool `json:"fetchLedger"`
 	FetchMarkets         bool `json:"fetchMarkets"`
+	FetchMaxBorrowable   bool `json:"fetchMaxBorrowable"`
 	FetchMyTrades        bool `json:"fetchMyTrades"`
 	FetchOHLCV           bool `json:"fetchOHLCV"`
 	FetchOpenOrders      bool `json:"fetchOpenOrders"`
@@ -199,6 +243,7 @@ type HasDescription struct {
 	FetchOrderBook       bool `json:"fetchOrderBook"`
 	FetchOrderBooks      bool `json:"fetchOrderBooks"`
 	FetchOrders          bool `json:"fetchOrders"`
+	FetchPositions       bool `json:"fetchPositions"`
 	FetchTicker          bool `json:"fetchTicker"`
 	FetchTickers         bool `json:"fetchTickers"`
 	FetchTrades          bool `json:"fetchTrades"`
@@ -206,9 +251,14 @@ type HasDescription struct {
 	FetchTradingFees     bool `json:"fetchTradingFees"`
 	FetchTradingLimits   bool `json:"fetchTradingLimits"`
 	FetchTransactions    bool `json:"fetchTransactions"`
+	FetchTransfers       bool `json:"fetchTransfers"`
 	FetchWithdrawals     bool `json:"fetchWithdrawals"`
 	PrivateApi           bool `json:"privateApi"`
 	PublicApi            bool `json:"publicApi"`
+	Repay                bool `json:"repay"`
+	SetLeverage          bool `json:"setLeverage"`
+	SetMarginMode        bool `json:"setMarginMode"`
+	Transfer             bool `json:"transfer"`
 	Withdraw             bool `json:"withdraw"`
 }
 
@@ -303,14 +353,20 @@ type Balance struct {
 	Free  float64 `json:"free"`
 	Used  float64 `json:"used"`
 	Total float64 `json:"total"`
+	// Debt is the borrowed principal and Interest the unpaid interest on it,
+	// for margin accounts
+	Debt     float64 `json:"debt,omitempty"`
+	Interest float64 `json:"interest,omitempty"`
 }
 
 // Account details
 type Account struct {
-	Free    map[string]float64 `json:"free"`
-	Used    map[string]float64 `json:"used"`
-	Total   map[string]float64 `json:"total"`
-	Account map[string]*Balance
+	Free     map[string]float64 `json:"free"`
+	Used     map[string]float64 `json:"used"`
+	Total    map[string]float64 `json:"total"`
+	Debt     map[string]float64 `json:"debt,omitempty"`
+	Interest map[string]float64 `json:"interest,omitempty"`
+	Account  map[string]*Balance
 }
 
 // Order structure
@@ -381,6 +437,10 @@ func (o *Order) InitFromMap(m map[string]interface{}) (result *Order) {
 			// ignore
 		}
 	}
+	// like upstream safeOrder, derive remaining when the exchange leaves it out
+	if m["remaining"] == nil && o.Amount > 0 {
+		o.Remaining = math.Max(0, o.Amount-o.Filled)
+	}
 	result = o
 	return
 }
@@ -469,10 +529,15 @@ type Currency struct {
 
 // DepositAddress struct
 type DepositAddress struct {
-	Currency string      `json:"currency"`
-	Address  string      `json:"address"`
-	Status   string      `json:"status"`
-	Info     interface{} `json:"info"`
+	Currency string `json:"currency"`
+	Address  string `json:"address"`
+	// Tag is the memo, destination tag or payment id some currencies need
+	// next to the address, empty for the others
+	Tag string `json:"tag"`
+	// Network is the chain of the address, empty for the currency's default
+	Network string      `json:"network"`
+	Status  string      `json:"status"`
+	Info    interface{} `json:"info"`
 }
 
 type ApiDecode struct {
@@ -566,8 +631,29 @@ type ExchangeInterface interface {
 	SetSecret(string)
 	SetPassword(string)
 	SetUid(string)
+	SetCredentialProvider(CredentialProvider)
+
+	FetchDepositAddress(code string, network string, params map[string]interface{}) (*DepositAddress, error)
+	CreateDepositAddress(code string, network string, params map[string]interface{}) (*DepositAddress, error)
+	Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (*Transaction, error)
+	FetchDeposits(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error)
+	FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error)
+	FetchTransactions(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error)
+	Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (*TransferEntry, error)
+	FetchTransfers(code string, since int64, limit int64, params map[string]interface{}) ([]*TransferEntry, error)
+	Borrow(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error)
+	Repay(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error)
+	FetchBorrowRate(code string, params map[string]interface{}) (*BorrowRate, error)
+	FetchBorrowInterest(code string, symbol string, since int64, limit int64, params map[string]interface{}) ([]*BorrowInterest, error)
+	FetchMaxBorrowable(code string, symbol string, params map[string]interface{}) (float64, error)
+	FetchPositions(symbols []string, params map[string]interface{}) ([]*Position, error)
+	FetchLeverage(symbol string, params map[string]interface{}) (*Leverage, error)
+	SetLeverage(symbol string, leverage float64, params map[string]interface{}) error
+	SetMarginMode(symbol string, marginMode string, params map[string]interface{}) error
 	SetBaseUrl(string)
 	BaseUrl() string
+	Milliseconds() int64
+	FetchTime(params map[string]interface{}) (int64, error)
 
 	FetchCurrencies(params map[string]interface{}) map[string]interface{}
 }
@@ -578,7 +664,6 @@ type ExchangeInterfaceInternal interface {
 	ApiFuncDecode(function string) (path string, api string, method string)
 	ApiFunc(function string, params interface{}, headers map[string]interface{}, body interface{}) (response map[string]interface{})
 	ApiFuncReturnList(function string, params interface{}, headers map[string]interface{}, body interface{}) (response []interface{})
-	Fetch(url string, method string, headers map[string]interface{}, body interface{}) (response interface{})
 	Request(path string, api string, method string, params map[string]interface{}, headers map[string]interface{}, body interface{}) (response interface{})
 	Describe() []byte
 	ParseOrder(interface{}, interface{}) map[string]interface{}
@@ -607,8 +692,25 @@ type Exchange struct {
 	//ApiUrls        map[string]string
 	DescribeMap    map[string]interface{}
 	Options        map[string]interface{}
-	httpExceptions map[string]string
+	// ErrorRules maps error codes, messages and HTTP statuses to error classes
+	ErrorRules     *ErrorRules
 	Hostname       string
+	// Clock replaces the wall clock behind Milliseconds when set, e.g. by a backtest
+	Clock func() int64
+
+	interceptors []Interceptor
+
+	credentialsMu sync.RWMutex
+
+	signerMu sync.Mutex
+	// signer is the parsed PEM key of signerSecret, see privateKey
+	signer       crypto.Signer
+	signerSecret string
+
+	clockMu sync.Mutex
+	// timeDifference is how far the local clock is ahead of the exchange's, in ms
+	timeDifference int64
+	lastNonce      int64
 }
 
 func (self *Exchange) Init(config *ExchangeConfig) (err error) {
@@ -618,47 +720,80 @@ func (self *Exchange) Init(config *ExchangeConfig) (err error) {
 		self.ExchangeConfig = *config
 	}
 
-	tr := &http.Transport{
-		Proxy: http.ProxyFromEnvironment,
-		//TLSClientConfig: &tls.Config{InsecureSkipVerify: false},
-	}
-	self.Client = &http.Client{
-		Transport: tr,
-		Timeout:   time.Second * 10, // 默认超时时间 10 秒
-	}
-	if self.ExchangeConfig.Timeout > 0 {
-		self.Client.Timeout = self.ExchangeConfig.Timeout
-	}
-
-	self.httpExceptions = map[string]string{
-		"422": "ExchangeError",
-		"418": "DDoSProtection",
-		"429": "RateLimitExceeded",
-		"404": "ExchangeNotAvailable",
-		"409": "ExchangeNotAvailable",
-		"410": "ExchangeNotAvailable",
-		"500": "ExchangeNotAvailable",
-		"501": "ExchangeNotAvailable",
-		"502": "ExchangeNotAvailable",
-		"520": "ExchangeNotAvailable",
-		"521": "ExchangeNotAvailable",
-		"522": "ExchangeNotAvailable",
-		"525": "ExchangeNotAvailable",
-		"526": "ExchangeNotAvailable",
-		"400": "ExchangeNotAvailable",
-		"403": "ExchangeNotAvailable",
-		"405": "ExchangeNotAvailable",
-		"503": "ExchangeNotAvailable",
-		"530": "ExchangeNotAvailable",
-		"408": "RequestTimeout",
-		"504": "RequestTimeout",
-		"401": "AuthenticationError",
-		"511": "AuthenticationError",
+	if self.HttpClient != nil {
+		self.Client = self.HttpClient
+	} else {
+		tr := self.ExchangeConfig.Transport
+		if tr != nil && (self.ProxyUrl != "" || self.LocalAddr != "") {
+			return TypedError("BadRequest", "proxyUrl and localAddr cannot be applied to an injected transport")
+		}
+		if tr == nil {
+			if tr, err = self.newTransport(); err != nil {
+				return
+			}
+		}
+		self.Client = &http.Client{
+			Transport: tr,
+			Timeout:   time.Second * 10, // 默认超时时间 10 秒
+		}
+		if self.ExchangeConfig.Timeout > 0 {
+			self.Client.Timeout = self.ExchangeConfig.Timeout
+		}
 	}
 
+	switch self.KeyType {
+	case "", KeyTypeHmac, KeyTypeRsa, KeyTypeEd25519:
+	default:
+		return TypedError("BadRequest", fmt.Sprintf("invalid keyType %q", self.KeyType))
+	}
+
+	self.ErrorRules, err = NewErrorRules(nil, nil)
+
 	return
 }
 
+// newTransport builds the default transport with the configured proxy and
+// local address. Without a ProxyUrl, ExchangeInfo.Proxy is read per request
+// as it is only known once the child has described itself.
+func (self *Exchange) newTransport() (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if self.ProxyUrl != "" {
+		proxy, err := url.Parse(self.ProxyUrl)
+		if err != nil || proxy.Host == "" {
+			return nil, TypedError("BadRequest", fmt.Sprintf("invalid proxyUrl %q", self.ProxyUrl))
+		}
+		tr.Proxy = http.ProxyURL(proxy)
+	} else {
+		tr.Proxy = func(req *http.Request) (*url.URL, error) {
+			if self.ExchangeInfo.Proxy != "" {
+				return url.Parse(self.ExchangeInfo.Proxy)
+			}
+			return http.ProxyFromEnvironment(req)
+		}
+	}
+	if self.LocalAddr != "" {
+		ip := net.ParseIP(self.LocalAddr)
+		if ip == nil {
+			return nil, TypedError("BadRequest", fmt.Sprintf("invalid localAddr %q", self.LocalAddr))
+		}
+		dialer := &net.Dialer{
+			LocalAddr: &net.TCPAddr{IP: ip},
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}
+		tr.DialContext = dialer.DialContext
+	}
+	return tr, nil
+}
+
+// SetTransport replaces the transport of this exchange only, a shared
+// HttpClient is left untouched
+func (self *Exchange) SetTransport(transport http.RoundTripper) {
+	client := *self.Client
+	client.Transport = transport
+	self.Client = &client
+}
+
 func (self *Exchange) Describe() []byte {
 	return nil
 }
@@ -667,7 +802,7 @@ func (self *Exchange) FetchMarkets(params map[string]interface{}) []interface{}
 	return nil
 }
 func (self *Exchange) FetchOrderBook(symbol string, limit int64, params map[string]interface{}) (*OrderBook, error) {
-	return nil, errors.New("FetchOrderBook not supported yet")
+	return nil, TypedError("NotSupported", self.Id+" FetchOrderBook not supported yet")
 }
 
 func (self *Exchange) Sign(path string, api string, method string, params map[string]interface{}, headers interface{}, body interface{}) interface{} {
@@ -688,6 +823,9 @@ func MarketFromMap(o interface{}) *Market {
 
 	if m, ok := o.(map[string]interface{}); ok {
 		p.Info = o
+		if m["info"] != nil {
+			p.Info = m["info"]
+		}
 		p.Id = m["id"].(string)
 		p.Symbol = m["symbol"].(string)
 		p.Base = m["base"].(string)
@@ -764,7 +902,7 @@ func (self *Exchange) SetMarkets(markets []interface{}, currencies map[string]in
 		quoteCurrency := new(Currency)
 		if market.Quote != "" {
 			quoteCurrency.Id = market.QuoteId
-			if baseCurrency.Id == "" {
+			if quoteCurrency.Id == "" {
 				quoteCurrency.Id = market.Quote
 			}
 			quoteCurrency.NumericId = market.QuoteNumericId
@@ -783,12 +921,16 @@ func (self *Exchange) SetMarkets(markets []interface{}, currencies map[string]in
 	allCurrencies := append(baseCurrencies, quoteCurrencies...)
 	groupedCurrencies := make(map[string][]*Currency)
 	for _, currency := range allCurrencies {
+		if currency.Code == "" {
+			continue
+		}
 		groupedCurrencies[currency.Code] = append(groupedCurrencies[currency.Code], currency)
 	}
 	sortedCurrencies := make(map[string]*Currency)
 	for code, currencies := range groupedCurrencies {
 		for _, currency := range currencies {
 			if sortedCurrencies[code] == nil {
+				sortedCurrencies[code] = currency
 				continue
 			}
 			if sortedCurrencies[code].Id == "" {
@@ -844,7 +986,13 @@ func (self *Exchange) LoadMarkets() map[string]*Market {
 	}
 
 	markets := self.Child.FetchMarkets(nil)
-	return self.Child.SetMarkets(markets, currencies)
+	result := self.Child.SetMarkets(markets, currencies)
+	if self.AdjustForTimeDifference || self.Options["adjustForTimeDifference"] == true {
+		if _, err := self.LoadTimeDifference(); err != nil {
+			panic(err)
+		}
+	}
+	return result
 }
 
 func (self *Exchange) LoadAccounts() []interface{} {
@@ -856,9 +1004,10 @@ func (self *Exchange) LoadAccounts() []interface{} {
 	accounts := self.Child.FetchAccounts(nil)
 	for _, account := range accounts {
 		one := map[string]interface{}{
-			"id":    account.(map[string]interface{})["id"],
-			"state": account.(map[string]interface{})["state"],
-			"type":  account.(map[string]interface{})["type"],
+			"id":      account.(map[string]interface{})["id"],
+			"state":   account.(map[string]interface{})["state"],
+			"type":    account.(map[string]interface{})["type"],
+			"subtype": account.(map[string]interface{})["subtype"],
 		}
 		self.Accounts = append(self.Accounts, one)
 	}
@@ -874,24 +1023,111 @@ func (self *Exchange) Request(
 	headers map[string]interface{},
 	body interface{},
 ) (response interface{}) {
-	signInfo := self.Child.Sign(path, api, method, params, headers, body)
-	return self.Child.Fetch(
-		self.Member(signInfo, "url").(string),
-		self.Member(signInfo, "method").(string),
-		self.Member(signInfo, "headers").(map[string]interface{}),
-		self.Member(signInfo, "body"),
-	)
+	// retries share the id, so their records can be told apart from others
+	id := newRequestId()
+	send := func() interface{} {
+		signInfo := self.Child.Sign(path, api, method, params, headers, body)
+		call := &Call{
+			Id:      id,
+			Api:     api,
+			Path:    path,
+			Params:  params,
+			Url:     self.Member(signInfo, "url").(string),
+			Method:  self.Member(signInfo, "method").(string),
+			Headers: self.Member(signInfo, "headers").(map[string]interface{}),
+			Body:    self.Member(signInfo, "body"),
+		}
+		if len(self.interceptors) == 0 {
+			return self.fetch(call)
+		}
+		response, err := self.intercept(call)
+		if err != nil {
+			panic(err)
+		}
+		return response
+	}
+	if self.Retry.MaxAttempts <= 1 {
+		return send()
+	}
+	for attempt := 1; ; attempt++ {
+		err := func() (err error) {
+			defer func() {
+				if e := recover(); e != nil {
+					err = self.PanicToError(e)
+				}
+			}()
+			response = send()
+			return
+		}()
+		if err == nil {
+			return
+		}
+		delay, retry := self.retryDelay(err, attempt, method, params)
+		if !retry {
+			panic(err)
+		}
+		self.Log().Info("retry", "request_id", id, "exchange", self.Id, "attempt", attempt,
+			"method", method, "path", path, "delay", delay, "error", err)
+		if self.Metrics != nil {
+			self.Metrics.ObserveRetryDelay(self.Id, self.apiFunctionName(api, method, path), delay)
+		}
+		time.Sleep(delay)
+	}
 }
 
 func (self *Exchange) PrepareRequestHeaders(req *http.Request, headers map[string]interface{}) {
 	//req.Header.Set("Accept-Encoding", "gzip, deflate")
+	userAgent := self.UserAgent
+	if userAgent == "" {
+		userAgent = self.UserAgents["chrome"]
+	}
+	if userAgent != "" {
+		req.Header.Set("User-Agent", userAgent)
+	}
+	for k, values := range self.Header {
+		req.Header.Del(k)
+		for _, v := range values {
+			req.Header.Add(k, v)
+		}
+	}
+	for k, v := range self.Headers {
+		req.Header.Set(k, v)
+	}
 
 	for k, v := range headers {
 		req.Header.Set(k, v.(string))
 	}
 }
 
+// Fetch sends a request as is, without Request's interceptors or retries.
+// Adapters do not override it, Request does not go through it; wrap every
+// request of an exchange with Use instead.
 func (self *Exchange) Fetch(url string, method string, headers map[string]interface{}, body interface{}) (response interface{}) {
+	return self.fetch(&Call{Id: newRequestId(), Url: url, Method: method, Headers: headers, Body: body})
+}
+
+// fetch sends a signed call, logging it under its id and reporting it to
+// Metrics
+func (self *Exchange) fetch(call *Call) (response interface{}) {
+	id, url, method, headers, body := call.Id, call.Url, call.Method, call.Headers, call.Body
+	var resp *http.Response
+	var strRawResp string
+	start := time.Now()
+	defer func() {
+		var err error
+		if e := recover(); e != nil {
+			err = self.requestError(self.PanicToError(e), method, url, resp, strRawResp, response)
+			var typed *Error
+			if errors.As(err, &typed) {
+				typed.RequestId = id
+			}
+		}
+		self.observeRequest(call, resp, err, time.Since(start))
+		if err != nil {
+			panic(err)
+		}
+	}()
+
 	var rbody []byte
 	if body != nil {
 		switch body.(type) {
@@ -913,23 +1149,17 @@ func (self *Exchange) Fetch(url string, method string, headers map[string]interf
 
 	self.PrepareRequestHeaders(req, headers)
 
-	if self.Verbose {
-		log.Println("Request:", method, url, headers, body)
-	}
+	logger := self.Log()
+	logger.Debug("request", "request_id", id, "exchange", self.Id, "method", method,
+		"url", self.redact(url), "headers", self.redactHeaders(req.Header), "body", self.redact(string(rbody)))
 
-	resp, err := self.Client.Do(req)
+	resp, err = self.Client.Do(req)
 	if err != nil {
 		if err, ok := err.(net.Error); ok && err.Timeout() {
 			self.RaiseException("RequestTimeout", fmt.Sprintf("%v %v %v", method, url, err))
 		}
-		switch t := err.(type) {
-		case syscall.Errno:
-			if t == syscall.ECONNREFUSED {
-				self.RaiseException("NetworkError", fmt.Sprintf("%v %v %v", method, url, err))
-			}
-		default:
-			self.RaiseException("ExchangeError", fmt.Sprintf("%v %v %v", method, url, err))
-		}
+		// refused, reset or unresolvable: the request may be sent again
+		self.RaiseException("NetworkError", fmt.Sprintf("%v %v %v", method, url, err))
 	}
 
 	defer resp.Body.Close()
@@ -939,10 +1169,9 @@ func (self *Exchange) Fetch(url string, method string, headers map[string]interf
 		self.RaiseException("InternalError", fmt.Sprintf("read response err: %v", err))
 	}
 
-	strRawResp := string(respRaw)
-	if self.Verbose {
-		log.Println("Response:", method, url, resp.StatusCode, resp.Header, strRawResp)
-	}
+	strRawResp = string(respRaw)
+	logger.Debug("response", "request_id", id, "exchange", self.Id, "status", resp.StatusCode,
+		"duration", time.Since(start), "body", self.redact(strRawResp))
 
 	// ignore error
 	_ = json.Unmarshal(respRaw, &response)
@@ -957,6 +1186,36 @@ func (self *Exchange) Fetch(url string, method string, headers map[string]interf
 	return
 }
 
+// errorCodeKeys are where the exchanges put their error code in a response
+var errorCodeKeys = []string{"code", "err-code", "error_code", "errorCode"}
+
+// requestError adds the exchange, the request and the response, if there
+// was one, to an *Error raised while fetching
+func (self *Exchange) requestError(err error, method string, url string, resp *http.Response, body string, response interface{}) error {
+	var e *Error
+	if !errors.As(err, &e) {
+		return err
+	}
+	e.Exchange = self.Id
+	e.Method = method
+	e.Url = url
+	if resp == nil {
+		return err
+	}
+	e.Status = resp.StatusCode
+	e.Body = body
+	e.RetryAfter = parseRetryAfter(resp.Header, time.Now())
+	if m, ok := response.(map[string]interface{}); ok {
+		for _, key := range errorCodeKeys {
+			if code := self.SafeString(m, key, ""); code != "" {
+				e.Code = code
+				break
+			}
+		}
+	}
+	return err
+}
+
 func (self *Exchange) RegSplit(text string, delimeter string) (result []string) {
 	reg := regexp.MustCompile(delimeter)
 	indexes := reg.FindAllStringIndex(text, -1)
@@ -980,16 +1239,7 @@ func (self *Exchange) DefineRestApi() (err error) {
 					if pathList, ok := methodInfo.([]interface{}); ok {
 						for _, path := range pathList {
 							if strPath, ok := path.(string); ok {
-								var strDealPath string
-								splitParts := self.RegSplit(strPath, "[^a-zA-Z0-9]")
-								for _, part := range splitParts {
-									strDealPath += strings.Title(part)
-								}
-								self.ApiDecodeInfo[strApi+strings.Title(strMethod)+strDealPath] = &ApiDecode{Api: strApi, Method: strings.ToUpper(strMethod), Path: strPath}
-
-								if self.Verbose {
-									//log.Println("\napiDecodeInfo:", strApi, strPath, strMethod, strDealPath)
-								}
+								self.ApiDecodeInfo[self.apiFunctionName(strApi, strMethod, strPath)] = &ApiDecode{Api: strApi, Method: strings.ToUpper(strMethod), Path: strPath}
 							}
 						}
 					}
@@ -1020,12 +1270,26 @@ func (self *Exchange) ApiFuncReturnList(function string, params interface{}, hea
 	return self.Child.Request(path, api, method, params.(map[string]interface{}), headers, body).([]interface{})
 }
 
+// Parse8601 returns milliseconds, or 0 for an empty string
 func (self *Exchange) Parse8601(x string) int64 {
+	if x == "" {
+		return 0
+	}
 	t, err := time.Parse(time.RFC3339, x)
 	if err != nil {
 		self.RaiseInternalException("Parse8601 " + x + " err!")
 	}
-	return t.Unix()
+	return t.UnixNano() / int64(time.Millisecond)
+}
+
+// ParseDate returns the milliseconds of a UTC "2006-01-02 15:04:05" date,
+// or of an ISO 8601 one, 0 for an empty string
+func (self *Exchange) ParseDate(x string) int64 {
+	t, err := time.Parse("2006-01-02 15:04:05", x)
+	if err != nil {
+		return self.Parse8601(x)
+	}
+	return t.UnixNano() / int64(time.Millisecond)
 }
 
 func (self *Exchange) Iso8601Okex(milliseconds int64) string {
@@ -1034,13 +1298,20 @@ func (self *Exchange) Iso8601Okex(milliseconds int64) string {
 	return time.Unix(seconds, 0).In(time.UTC).Format("2006-01-02T15:04:05.070Z")
 }
 
+// Iso8601 formats milliseconds as the unified datetime, in UTC with
+// milliseconds, e.g. 2020-09-13T12:26:40.123Z. Unknown (zero or negative)
+// timestamps give an empty string.
 func (self *Exchange) Iso8601(milliseconds int64) string {
-	var seconds int64
-	seconds = milliseconds / 1000
-	return time.Unix(seconds, 0).Format("2006-01-02T15:04:05-0700")
+	if milliseconds <= 0 {
+		return ""
+	}
+	return time.Unix(0, milliseconds*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z")
 }
 
 func (self *Exchange) Milliseconds() int64 {
+	if self.Clock != nil {
+		return self.Clock()
+	}
 	return time.Now().UnixNano() / 1000000
 }
 
@@ -1173,12 +1444,16 @@ func (self *Exchange) Extend(maps ...interface{}) interface{} {
 }
 
 func (self *Exchange) InMap(k interface{}, m interface{}) bool {
-	if strMap, ok := m.(map[string]interface{}); ok {
-		if _, ok := strMap[k.(string)]; ok {
-			return true
-		}
+	key, ok := k.(string)
+	if !ok || m == nil {
+		return false
 	}
-	return false
+	// any map keyed by string, e.g. MarketsById as well as parsed json
+	v := reflect.ValueOf(m)
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return false
+	}
+	return v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())).IsValid()
 }
 
 func (self *Exchange) ToBool(v interface{}) bool {
@@ -1260,7 +1535,7 @@ func (self *Exchange) ParseOrderBook(orderBook interface{}, timeStamp int64, bid
 			}
 		}
 		result.Timestamp = timeStamp
-		// result.Datetime = time.Unix(timeStamp/1000, 0).Format("2006-01-02 15:04:05")
+		result.Datetime = self.Iso8601(timeStamp)
 
 		return &result
 	}
@@ -1291,7 +1566,10 @@ func (self *Exchange) SafeFloat2(d interface{}, key1 string, key2 string, defaul
 }
 
 func (self *Exchange) SafeString2(d interface{}, key1 string, key2 string, defaultVal string) string {
-	return self.SafeEither(d, key1, key2, defaultVal).(string)
+	if val := self.SafeEither(d, key1, key2, nil); val != nil {
+		return stringify(val)
+	}
+	return defaultVal
 }
 
 func (self *Exchange) SafeValue2(d interface{}, key1 string, key2 string, defaultVal interface{}) interface{} {
@@ -1314,6 +1592,15 @@ func (self *Exchange) NumberToString(v interface{}) string {
 	return NumberToString(v.(float64))
 }
 
+// stringify formats JSON numbers, which decode to float64, without an exponent
+// so that large ids and small amounts survive a round trip through a string
+func stringify(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return NumberToString(f)
+	}
+	return fmt.Sprintf("%v", v)
+}
+
 func (self *Exchange) SafeString(d interface{}, key string, defaultVal interface{}) string {
 	if d, ok := d.(map[string]interface{}); ok {
 		val := d[key]
@@ -1324,7 +1611,7 @@ func (self *Exchange) SafeString(d interface{}, key string, defaultVal interface
 			case int64:
 				return strconv.FormatInt(val.(int64), 10)
 			}
-			return fmt.Sprintf("%v", val)
+			return stringify(val)
 		}
 	}
 	if d, ok := d.([]string); ok {
@@ -1375,6 +1662,13 @@ func (self *Exchange) Omit(d map[string]interface{}, args interface{}) (result m
 		return d
 	}
 
+	if argList, ok := args.([]interface{}); ok {
+		for _, arg := range argList {
+			delete(d, fmt.Sprintf("%v", arg))
+		}
+		return d
+	}
+
 	if arg, ok := args.(string); ok {
 		delete(d, arg)
 		return d
@@ -1395,7 +1689,7 @@ func (self *Exchange) ExtractParams(s string) (result []string) {
 func (self *Exchange) ImplodeParams(s string, params interface{}) string {
 	if paramsMap, ok := params.(map[string]interface{}); ok {
 		for k, v := range paramsMap {
-			s = strings.ReplaceAll(s, "{"+k+"}", fmt.Sprintf("%v", v))
+			s = strings.ReplaceAll(s, "{"+k+"}", stringify(v))
 		}
 	}
 	return s
@@ -1469,15 +1763,33 @@ func (self *Exchange) ParseBalance(balances map[string]interface{}) (pAccount *A
 	account.Total = make(map[string]float64)
 
 	account.Account = map[string]*Balance{}
-	for currency, balance := range self.Omit(balances, []string{"info", "free", "used", "total"}) {
+	for currency, balance := range self.Omit(balances, []string{"info", "free", "used", "total", "debt", "interest"}) {
 		if balance, ok := balance.(map[string]interface{}); ok {
 			free := self.SafeFloat(balance, "free", 0)
 			used := self.SafeFloat(balance, "used", 0)
-			total := self.SafeFloat(balance, "used", 0)
+			total := self.SafeFloat(balance, "total", 0)
+			if balance["total"] == nil {
+				total = free + used
+			} else if balance["free"] == nil {
+				free = total - used
+			}
 			account.Free[currency] = free
 			account.Used[currency] = used
 			account.Total[currency] = total
 			account.Account[currency] = &Balance{Free: free, Used: used, Total: total}
+			// margin accounts report what is owed
+			debt := self.SafeFloat(balance, "debt", 0)
+			interest := self.SafeFloat(balance, "interest", 0)
+			if debt != 0 || interest != 0 {
+				if account.Debt == nil {
+					account.Debt = map[string]float64{}
+					account.Interest = map[string]float64{}
+				}
+				account.Debt[currency] = debt
+				account.Interest[currency] = interest
+				account.Account[currency].Debt = debt
+				account.Account[currency].Interest = interest
+			}
 		}
 	}
 
@@ -1552,7 +1864,7 @@ func (self *Exchange) Member(o interface{}, idx interface{}) interface{} {
 	case reflect.Struct:
 		return reflect.ValueOf(o).FieldByName(self.Capitalize(idx.(string))).Interface()
 	case reflect.Ptr:
-		return reflect.Indirect(reflect.ValueOf(o)).FieldByName(self.Capitalize(idx.(string)))
+		return reflect.Indirect(reflect.ValueOf(o)).FieldByName(self.Capitalize(idx.(string))).Interface()
 	}
 
 	return nil
@@ -1623,9 +1935,6 @@ func (self *Exchange) SetValue(x interface{}, k string, v interface{}) {
 	}
 }
 
-func (self *Exchange) CheckRequiredCredentials() {
-}
-
 func (self *Exchange) UrlencodeWithArrayRepeat(i interface{}) string {
 	re := regexp.MustCompile(`%5B\d*%5D`)
 	return re.ReplaceAllString(self.Urlencode(i), "")
@@ -1643,7 +1952,7 @@ func (self *Exchange) Urlencode(i interface{}) string {
 
 		for _, k := range keys {
 			val := m[k]
-			v.Add(k, fmt.Sprintf("%v", val))
+			v.Add(k, stringify(val))
 		}
 		return v.Encode()
 	}
@@ -1687,11 +1996,11 @@ func (self *Exchange) AddTwoInterface(a interface{}, b interface{}) interface{}
 }
 
 func (self *Exchange) FetchBalance(params map[string]interface{}) (*Account, error) {
-	return nil, fmt.Errorf("%s FetchBalance not supported yet", self.Id)
+	return nil, TypedError("NotSupported", self.Id+" FetchBalance not supported yet")
 }
 
 func (self *Exchange) CreateOrder(symbol string, otype string, side string, amount float64, price float64, params map[string]interface{}) (*Order, error) {
-	return nil, fmt.Errorf("%s CreateOrder not supported yet", self.Id)
+	return nil, TypedError("NotSupported", self.Id+" CreateOrder not supported yet")
 }
 
 func (self *Exchange) LimitBuy(symbol string, price, amount float64, params map[string]interface{}) (*Order, error) {
@@ -1707,18 +2016,18 @@ func (self *Exchange) FetchCurrencies(params map[string]interface{}) map[string]
 }
 
 func (self *Exchange) CancelOrder(id string, symbol string, params map[string]interface{}) (interface{}, error) {
-	return nil, fmt.Errorf("%s CancelOrder not supported yet", self.Id)
+	return nil, TypedError("NotSupported", self.Id+" CancelOrder not supported yet")
 }
 
 func (self *Exchange) FetchOrder(id string, symbol string, params map[string]interface{}) (*Order, error) {
-	return nil, fmt.Errorf("%s FetchOrder not supported yet", self.Id)
+	return nil, TypedError("NotSupported", self.Id+" FetchOrder not supported yet")
 }
 
 func (self *Exchange) HandleErrors(code int64, reason string, url string, method string, headers interface{}, body string, response interface{}, requestHeaders interface{}, requestBody interface{}) {
 }
 
 func (self *Exchange) FetchOpenOrders(symbol string, since int64, limit int64, params map[string]interface{}) ([]*Order, error) {
-	return nil, fmt.Errorf("%s FetchOpenOrders not supported yet", self.Id)
+	return nil, TypedError("NotSupported", self.Id+" FetchOpenOrders not supported yet")
 }
 
 func (self *Exchange) SetApiKey(s string) {
@@ -1770,8 +2079,77 @@ func (self *Exchange) Capitalize(s string) string {
 	return string(b)
 }
 
+// Nonce returns the exchange's time in milliseconds, bumped where needed so
+// that concurrent requests never share one
 func (self *Exchange) Nonce() int64 {
-	return self.Milliseconds()
+	self.clockMu.Lock()
+	defer self.clockMu.Unlock()
+	nonce := self.Milliseconds() - self.timeDifference
+	if nonce <= self.lastNonce {
+		nonce = self.lastNonce + 1
+	}
+	self.lastNonce = nonce
+	return nonce
+}
+
+// ServerMilliseconds is the local clock corrected by the time difference,
+// for signing timestamps that the exchange checks against its own clock
+func (self *Exchange) ServerMilliseconds() int64 {
+	return self.Milliseconds() - self.TimeDifference()
+}
+
+// TimeDifference returns how many milliseconds the local clock is ahead of
+// the exchange's, as last loaded
+func (self *Exchange) TimeDifference() int64 {
+	self.clockMu.Lock()
+	defer self.clockMu.Unlock()
+	return self.timeDifference
+}
+
+// FetchTime returns the exchange's current time in milliseconds
+func (self *Exchange) FetchTime(params map[string]interface{}) (int64, error) {
+	return 0, TypedError("NotSupported", self.Id+" FetchTime not supported yet")
+}
+
+// LoadTimeDifference measures the local clock against FetchTime, assuming
+// the exchange read its clock halfway through the round trip, and applies
+// the difference to Nonce and ServerMilliseconds.
+func (self *Exchange) LoadTimeDifference() (difference int64, err error) {
+	before := self.Milliseconds()
+	serverTime, err := self.Child.FetchTime(nil)
+	if err != nil {
+		return
+	}
+	after := self.Milliseconds()
+	difference = (before+after)/2 - serverTime
+	self.clockMu.Lock()
+	self.timeDifference = difference
+	self.clockMu.Unlock()
+	return
+}
+
+// SyncClock reloads the time difference every interval in the background
+// until stop is called. A failed attempt keeps the previous estimate.
+func (self *Exchange) SyncClock(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if _, err := self.LoadTimeDifference(); err != nil {
+				self.Log().Warn("sync clock", "exchange", self.Id, "error", err)
+			}
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
 }
 
 func (self *Exchange) PrecisionFromString(s string) int {
@@ -1805,24 +2183,57 @@ func (self *Exchange) ThrowExactlyMatchedException(exact interface{}, s interfac
 	}
 }
 
+// stringMap accepts the exceptions sections as parsed from json as well as
+// map[string]string
+func stringMap(m interface{}) map[string]string {
+	if result, ok := m.(map[string]string); ok {
+		return result
+	}
+	result := map[string]string{}
+	entries, _ := m.(map[string]interface{})
+	for k, v := range entries {
+		if class, ok := v.(string); ok {
+			result[k] = class
+		}
+	}
+	return result
+}
+
+// FindBroadlyMatchedKey returns the longest key of broad contained in s
 func (self *Exchange) FindBroadlyMatchedKey(broad interface{}, s interface{}) string {
-	for k, _ := range broad.(map[string]interface{}) {
-		if strings.Contains(s.(string), k) {
-			return k
+	str, _ := s.(string)
+	result := ""
+	for k := range stringMap(broad) {
+		if strings.Contains(str, k) && (len(k) > len(result) || len(k) == len(result) && k < result) {
+			result = k
 		}
 	}
-	return ""
+	return result
 }
 
 func (self *Exchange) ThrowBroadlyMatchedException(broad interface{}, s interface{}, message interface{}) {
 	broadKey := self.FindBroadlyMatchedKey(broad, s)
 	if broadKey != "" {
-		self.RaiseException(broad.(map[string]string)[broadKey], message)
+		self.RaiseException(stringMap(broad)[broadKey], message)
+	}
+}
+
+// ThrowMatchedException raises the class ErrorRules give the codes and
+// messages in s, if any, with feedback as the message
+func (self *Exchange) ThrowMatchedException(feedback string, s ...string) {
+	if class := self.ErrorRules.Match(s...); class != "" {
+		self.RaiseException(class, feedback)
 	}
 }
 
 func (self *Exchange) PanicToError(e interface{}) (err error) {
 	switch e.(type) {
+	case runtime.Error:
+		self.Log().Debug("panic", "exchange", self.Id, "panic", e, "stack", string(debug.Stack()))
+		err = TypedError("InternalError", fmt.Sprintf("Catch unknown panic: %v", e))
+	case error:
+		// an error from a nested call that returns one, already typed
+		err = e.(error)
 	case []string:
 		args := e.([]string)
 		if len(args) == 2 {
@@ -1831,33 +2242,19 @@ func (self *Exchange) PanicToError(e interface{}) (err error) {
 			//err = errors.New(errCls + ": " + message)
 			err = TypedError(errCls, message)
 		} else {
-			if self.Verbose {
-				log.Println(string(debug.Stack()))
-			}
-			err = fmt.Errorf("Catch unknown panic: %v", e)
+			self.Log().Debug("panic", "exchange", self.Id, "panic", e, "stack", string(debug.Stack()))
+			err = TypedError("InternalError", fmt.Sprintf("Catch unknown panic: %v", e))
 		}
 	default:
-		if self.Verbose {
-			log.Println(string(debug.Stack()))
-		}
-		err = fmt.Errorf("Catch unknown panic: %v", e)
+		self.Log().Debug("panic", "exchange", self.Id, "panic", e, "stack", string(debug.Stack()))
+		err = TypedError("InternalError", fmt.Sprintf("Catch unknown panic: %v", e))
 	}
 	return
 }
 
 func (self *Exchange) HandleRestErrors(httpStatusCode int, httpStatusText string, body string, url string, method string) {
-	errCls := ""
 	strCode := strconv.Itoa(httpStatusCode)
-	if _, ok := self.httpExceptions[strCode]; ok {
-		errCls = self.httpExceptions[strCode]
-		if errCls == "ExchangeNotAvailable" {
-			matched, err := regexp.MatchString("(?i)(cloudflare|incapsula|overload|ddos)", body)
-			if matched && err == nil {
-				errCls = "DDoSProtection"
-			}
-		}
-	}
-	if errCls != "" {
+	if errCls := self.ErrorRules.MatchHttp(httpStatusCode, body); errCls != "" {
 		self.RaiseException(errCls, strings.Join([]string{method, url, strCode, httpStatusText, body}, " "))
 	}
 }
@@ -1875,7 +2272,8 @@ func (self *Exchange) IsJsonEncodedObject(input interface{}) bool {
 }
 
 func (self *Exchange) HandleRestResponse(response string, jsonResponse interface{}, url string, method string) {
-	if self.IsJsonEncodedObject(response) && self.TestNil(jsonResponse) {
+	// an empty list or object is a valid answer, only text that fails to parse is an error
+	if self.IsJsonEncodedObject(response) && jsonResponse == nil {
 		dDoSProtectionMatched, _ := regexp.MatchString("(?i)(cloudflare|incapsula|overload|ddos)", response)
 		if dDoSProtectionMatched {
 			self.RaiseException("DDoSProtection", strings.Join([]string{method, url, response}, " "))
@@ -1943,10 +2341,20 @@ func (self *Exchange) IndexBy(x interface{}, k string) map[string]interface{} {
 	return out
 }
 
-func (self *Exchange) InArray(a string, list []string) bool {
-	for _, b := range list {
-		if b == a {
-			return true
+// InArray accepts a []string or a decoded JSON array
+func (self *Exchange) InArray(a string, list interface{}) bool {
+	switch list := list.(type) {
+	case []string:
+		for _, b := range list {
+			if b == a {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, b := range list {
+			if b == a {
+				return true
+			}
 		}
 	}
 	return false
@@ -1977,31 +2385,32 @@ func (self *Exchange) ArrayConcat(a interface{}, b interface{}) (result []interf
 func (self *Exchange) FilterByValueSinceLimit(arr []interface{}, field string, value interface{}, since interface{}, limit interface{}, key string, tail bool) (result []interface{}) {
 	defer func() {
 		if e := recover(); e != nil {
-			log.Printf("filter_by_symbol_since_limit err: %v, arr: %v\n", e, arr)
+			self.Log().Error("filter by value since limit", "exchange", self.Id, "error", e, "arr", arr)
 		}
 	}()
 
 	result = self.ToArray(arr)
 
-	if value != nil {
+	// the zero value of each filter means no filter, as elsewhere in the go api
+	if value != nil && value != "" {
 		result = funk.Filter(result, func(x interface{}) bool {
 			return x.(map[string]interface{})[field] == value
 		}).([]interface{})
 	}
 
-	if since != nil {
+	if since != nil && since.(int64) > 0 {
 		result = funk.Filter(result, func(x interface{}) bool {
 			return x.(map[string]interface{})[key].(int64) >= since.(int64)
 		}).([]interface{})
 	}
 
-	if limit != nil {
+	if limit != nil && limit.(int64) > 0 {
 		limitNum := limit.(int64)
 		lenNum := int64(len(result))
 		if limitNum > lenNum {
 			limitNum = lenNum
 		}
-		if tail && since != nil {
+		if tail && since != nil && since.(int64) > 0 {
 			result = result[lenNum-limitNum:]
 		} else {
 			result = result[:limitNum]
@@ -2023,6 +2432,35 @@ func (self *Exchange) DeepExtend(args ...interface{}) (result map[string]interfa
 	return
 }
 
+// ExtendDescribe deep merges the JSON object overrides into describe, for
+// adapters that are another adapter with different defaults
+func ExtendDescribe(describe []byte, overrides string) []byte {
+	var base, extra map[string]interface{}
+	if err := json.Unmarshal(describe, &base); err != nil {
+		panic(err)
+	}
+	if err := json.Unmarshal([]byte(overrides), &extra); err != nil {
+		panic(err)
+	}
+	var merge func(dst, src map[string]interface{})
+	merge = func(dst, src map[string]interface{}) {
+		for k, v := range src {
+			sub, ok := v.(map[string]interface{})
+			if into, isMap := dst[k].(map[string]interface{}); ok && isMap {
+				merge(into, sub)
+			} else {
+				dst[k] = v
+			}
+		}
+	}
+	merge(base, extra)
+	result, err := json.Marshal(base)
+	if err != nil {
+		panic(err)
+	}
+	return result
+}
+
 func (self *Exchange) InitDescribe() (err error) {
 	err = json.Unmarshal(self.Child.Describe(), &self.DescribeMap)
 	if err != nil {
@@ -2040,9 +2478,16 @@ func (self *Exchange) InitDescribe() (err error) {
 		self.Version = self.DescribeMap["version"].(string)
 	}
 	self.Exceptions = self.DescribeMap["exceptions"].(map[string]interface{})
+	if self.ErrorRules, err = NewErrorRules(self.Exceptions, self.DescribeMap["httpExceptions"]); err != nil {
+		return
+	}
 	if hostName, ok := self.DescribeMap["hostname"]; ok {
 		self.Hostname = hostName.(string)
 	}
+	self.Id, _ = self.DescribeMap["id"].(string)
+	if err = self.CheckKeyType(); err != nil {
+		return
+	}
 	if fees, ok := self.DescribeMap["fees"]; ok {
 		self.Fees = fees.(map[string]interface{})
 	}
@@ -2054,11 +2499,65 @@ func (self *Exchange) InitDescribe() (err error) {
 		"BCHSV":  "BSV",
 	}
 
+	if self.Test {
+		err = self.SetSandboxMode(true)
+	}
+	return
+}
+
+// SetSandboxMode switches urls.api to the exchange's testnet urls.test, or
+// back to the live urls when enabled is false. An exchange without a sandbox
+// raises NotSupported rather than silently trading live.
+func (self *Exchange) SetSandboxMode(enabled bool) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	if enabled {
+		test, ok := self.Urls["test"]
+		if !ok || test == nil {
+			self.RaiseException("NotSupported", self.Id+" does not have a sandbox URL")
+		}
+		if _, ok := self.Urls["apiBackup"]; !ok {
+			self.Urls["apiBackup"] = self.Urls["api"]
+		}
+		self.Urls["api"] = test
+	} else if backup, ok := self.Urls["apiBackup"]; ok {
+		self.Urls["api"] = backup
+		delete(self.Urls, "apiBackup")
+	}
+	self.Test = enabled
 	return
 }
 
+// SetBaseUrl points the adapter at another host, e.g. a local fake server
+// or a proxy. If urls.api is a map every entry keeps its path and only the
+// scheme and host are replaced. Entries built from {hostname} move the
+// hostname too, because some exchanges sign it.
 func (self *Exchange) SetBaseUrl(u string) {
-	self.Urls["api"] = u
+	api, ok := self.Urls["api"].(map[string]interface{})
+	if !ok {
+		self.Urls["api"] = u
+		return
+	}
+	u = strings.TrimRight(u, "/")
+	re := regexp.MustCompile(`^[a-z]+://[^/]+`)
+	replaced := map[string]interface{}{}
+	for k, v := range api {
+		s, ok := v.(string)
+		if !ok {
+			replaced[k] = v
+			continue
+		}
+		if strings.Contains(s, "{hostname}") {
+			if parsed, err := url.Parse(u); err == nil {
+				self.Hostname = parsed.Host
+			}
+		}
+		replaced[k] = re.ReplaceAllLiteralString(s, u)
+	}
+	self.Urls["api"] = replaced
 }
 
 func (self *Exchange) BaseUrl() string {
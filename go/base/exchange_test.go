@@ -0,0 +1,126 @@
+package base
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+func TestSetSandboxModeWithoutTestUrls(t *testing.T) {
+	ex := &Exchange{}
+	ex.Urls = map[string]interface{}{"api": "https://api.example.com"}
+	if err := ex.SetSandboxMode(true); !errors.Is(err, NotSupported) {
+		t.Fatal("SetSandboxMode:", err)
+	}
+	if ex.Urls["api"] != "https://api.example.com" || ex.Test {
+		t.Fatal("live urls changed:", ex.Urls)
+	}
+}
+
+func TestNonceIsUniqueUnderConcurrency(t *testing.T) {
+	ex := &Exchange{Clock: func() int64 { return 1000 }}
+	nonces := make(chan int64, 100)
+	var wg sync.WaitGroup
+	for i := 0; i < cap(nonces); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			nonces <- ex.Nonce()
+		}()
+	}
+	wg.Wait()
+	close(nonces)
+	seen := map[int64]bool{}
+	for nonce := range nonces {
+		if seen[nonce] || nonce < 1000 {
+			t.Fatal("nonce reused or behind the clock:", nonce)
+		}
+		seen[nonce] = true
+	}
+}
+
+func TestFetchUsesProxyAndHeaders(t *testing.T) {
+	var got *http.Request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	ex := &Exchange{}
+	err := ex.Init(&ExchangeConfig{
+		ProxyUrl:  proxy.URL,
+		Headers:   map[string]string{"X-Desk": "emea", "X-Override": "default"},
+		UserAgent: "desk-bot/1.0",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.Fetch("http://exchange.example/api/v3/time", "GET", map[string]interface{}{"X-Override": "request"}, nil)
+	if got == nil || got.URL.String() != "http://exchange.example/api/v3/time" {
+		t.Fatal("request did not go through the proxy:", got)
+	}
+	if got.Header.Get("User-Agent") != "desk-bot/1.0" || got.Header.Get("X-Desk") != "emea" || got.Header.Get("X-Override") != "request" {
+		t.Fatal("headers:", got.Header)
+	}
+
+	if err := (&Exchange{}).Init(&ExchangeConfig{ProxyUrl: "::"}); !errors.Is(err, BadRequest) {
+		t.Fatal("invalid proxy:", err)
+	}
+}
+
+func TestFetchDefaultsToExchangeInfo(t *testing.T) {
+	var got *http.Request
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		w.Write([]byte(`{}`))
+	}))
+	defer proxy.Close()
+
+	ex := &Exchange{}
+	if err := ex.Init(&ExchangeConfig{Headers: map[string]string{"X-Override": "config"}}); err != nil {
+		t.Fatal(err)
+	}
+	// set after Init, as a child's description would be
+	ex.Proxy = proxy.URL
+	ex.UserAgents = map[string]string{"chrome": "Mozilla/5.0 Chrome"}
+	ex.Header = http.Header{"X-Desk": {"emea"}, "X-Override": {"default"}}
+	ex.Fetch("http://exchange.example/api/v3/time", "GET", nil, nil)
+	if got == nil || got.URL.String() != "http://exchange.example/api/v3/time" {
+		t.Fatal("request did not go through the proxy:", got)
+	}
+	if got.Header.Get("User-Agent") != "Mozilla/5.0 Chrome" || got.Header.Get("X-Desk") != "emea" || got.Header.Get("X-Override") != "config" {
+		t.Fatal("headers:", got.Header)
+	}
+}
+
+func TestInjectedHttpClientIsShared(t *testing.T) {
+	shared := &http.Client{}
+	a, b := &Exchange{}, &Exchange{}
+	a.Init(&ExchangeConfig{HttpClient: shared})
+	b.Init(&ExchangeConfig{HttpClient: shared})
+	if a.Client != shared || b.Client != shared {
+		t.Fatal("client not shared")
+	}
+	a.SetTransport(http.DefaultTransport)
+	if shared.Transport != nil || b.Client != shared {
+		t.Fatal("SetTransport changed the shared client")
+	}
+
+	var transport http.RoundTripper = &http.Transport{}
+	c := &Exchange{}
+	c.Init(&ExchangeConfig{Transport: transport})
+	if c.Client.Transport != transport {
+		t.Fatal("transport not used")
+	}
+	for _, config := range []*ExchangeConfig{
+		{Transport: transport, ProxyUrl: "http://127.0.0.1:3128"},
+		{Transport: transport, LocalAddr: "127.0.0.1"},
+	} {
+		if err := (&Exchange{}).Init(config); !errors.Is(err, BadRequest) {
+			t.Error("transport with proxy or local address:", err)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package base
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Transaction is a deposit or a withdrawal
+type Transaction struct {
+	Id string `json:"id"`
+	// Txid is the hash of the transaction on chain, empty until it is sent
+	Txid string `json:"txid"`
+	// Type is "deposit" or "withdrawal"
+	Type     string  `json:"type"`
+	Currency string  `json:"currency"`
+	Network  string  `json:"network"`
+	Address  string  `json:"address"`
+	Tag      string  `json:"tag"`
+	Amount   float64 `json:"amount"`
+	Fee      float64 `json:"fee"`
+	// Status is "pending", "ok", "failed" or "canceled"
+	Status    string      `json:"status"`
+	Timestamp int64       `json:"timestamp"`
+	Datetime  string      `json:"datetime"`
+	Updated   int64       `json:"updated"`
+	Info      interface{} `json:"info"`
+}
+
+// CurrencyId returns the exchange's id of a currency code, the code itself
+// for a currency the markets do not list
+func (self *Exchange) CurrencyId(code string) string {
+	if currency := self.Currencies[code]; currency != nil && currency.Id != "" {
+		return currency.Id
+	}
+	return code
+}
+
+// CheckAddress raises InvalidAddress for an empty address or one with
+// whitespace, and returns it otherwise
+func (self *Exchange) CheckAddress(address string) string {
+	if address == "" || strings.IndexFunc(address, unicode.IsSpace) >= 0 {
+		self.RaiseException("InvalidAddress", self.Id+" address is invalid or has less than 1 characters: "+address)
+	}
+	return address
+}
+
+// NetworkMatches reports whether an exchange's chain name is network, given
+// as the chain name itself or as the token standard, e.g. "TRC20" matches
+// okex's "USDT-TRC20" and huobi's "trc20usdt" or "usdterc20". An empty
+// network matches any.
+func (self *Exchange) NetworkMatches(chain string, network string, code string) bool {
+	chain, network, code = strings.ToUpper(chain), strings.ToUpper(network), strings.ToUpper(code)
+	return network == "" || chain == network || chain == code+"-"+network || chain == network+code || chain == code+network
+}
+
+// ToDepositAddress returns the checked deposit address of a currency. An
+// empty address is one the exchange is still generating, it raises
+// AddressPending.
+func (self *Exchange) ToDepositAddress(code string, address string, tag string, network string, info interface{}) *DepositAddress {
+	if address == "" {
+		self.RaiseException("AddressPending", self.Id+" is generating the "+code+" deposit address, try again later")
+	}
+	return &DepositAddress{
+		Currency: code,
+		Address:  self.CheckAddress(address),
+		Tag:      tag,
+		Network:  network,
+		Status:   "ok",
+		Info:     info,
+	}
+}
+
+// FetchDepositAddress returns the address to deposit code to on network,
+// the currency's default network if empty
+func (self *Exchange) FetchDepositAddress(code string, network string, params map[string]interface{}) (*DepositAddress, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchDepositAddress not supported yet")
+}
+
+// CreateDepositAddress asks for a new deposit address, on the exchanges
+// that only hand one out on request
+func (self *Exchange) CreateDepositAddress(code string, network string, params map[string]interface{}) (*DepositAddress, error) {
+	return nil, TypedError("NotSupported", self.Id+" CreateDepositAddress not supported yet")
+}
+
+// Withdraw sends amount of code to address, with tag for the currencies
+// that need one. The transaction returned is pending.
+func (self *Exchange) Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (*Transaction, error) {
+	return nil, TypedError("NotSupported", self.Id+" Withdraw not supported yet")
+}
+
+// FetchDeposits returns the deposits of code, every currency if empty, made
+// from since on, at most limit of them, oldest first
+func (self *Exchange) FetchDeposits(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchDeposits not supported yet")
+}
+
+// FetchWithdrawals returns the withdrawals of code like FetchDeposits
+func (self *Exchange) FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchWithdrawals not supported yet")
+}
+
+// FetchTransactions returns the deposits and withdrawals of code together,
+// for the exchanges that list them separately
+func (self *Exchange) FetchTransactions(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error) {
+	deposits, err := self.Child.FetchDeposits(code, since, limit, self.Extend(params).(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := self.Child.FetchWithdrawals(code, since, limit, self.Extend(params).(map[string]interface{}))
+	if err != nil {
+		return nil, err
+	}
+	return self.FilterTransactions(append(deposits, withdrawals...), code, since, limit), nil
+}
+
+// FilterTransactions sorts transactions oldest first and keeps those of
+// code, unless empty, from since on, at most limit of them
+func (self *Exchange) FilterTransactions(transactions []*Transaction, code string, since int64, limit int64) []*Transaction {
+	ofCode := []*Transaction{}
+	for _, transaction := range transactions {
+		if code == "" || transaction.Currency == code {
+			ofCode = append(ofCode, transaction)
+		}
+	}
+	result := []*Transaction{}
+	for _, i := range FilterSince(len(ofCode), func(i int) int64 { return ofCode[i].Timestamp }, since, limit) {
+		result = append(result, ofCode[i])
+	}
+	return result
+}
+
+// FilterSince is the since and limit of history methods over n entries
+// whose timestamps are given by timestamp: it returns the indices of the
+// entries from since on, oldest first, at most limit of them
+func FilterSince(n int, timestamp func(i int) int64, since int64, limit int64) []int {
+	result := []int{}
+	for i := 0; i < n; i++ {
+		if timestamp(i) >= since {
+			result = append(result, i)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return timestamp(result[i]) < timestamp(result[j])
+	})
+	if limit > 0 && int64(len(result)) > limit {
+		result = result[:limit]
+	}
+	return result
+}
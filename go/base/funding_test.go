@@ -0,0 +1,42 @@
+package base
+
+import "testing"
+
+func TestNetworkMatches(t *testing.T) {
+	ex := &Exchange{}
+	for _, chain := range []string{"TRC20", "USDT-TRC20", "trc20usdt"} {
+		if !ex.NetworkMatches(chain, "trc20", "USDT") {
+			t.Error(chain)
+		}
+	}
+	if ex.NetworkMatches("USDT-ERC20", "TRC20", "USDT") || !ex.NetworkMatches("USDT-ERC20", "", "USDT") {
+		t.Error("USDT-ERC20")
+	}
+}
+
+func TestFilterTransactions(t *testing.T) {
+	ex := &Exchange{}
+	transactions := []*Transaction{
+		{Id: "3", Currency: "BTC", Timestamp: 3000},
+		{Id: "1", Currency: "BTC", Timestamp: 1000},
+		{Id: "2", Currency: "ETH", Timestamp: 2000},
+	}
+	ids := func(transactions []*Transaction) (result string) {
+		for _, transaction := range transactions {
+			result += transaction.Id
+		}
+		return
+	}
+	if got := ids(ex.FilterTransactions(transactions, "", 0, 0)); got != "123" {
+		t.Error("sorted:", got)
+	}
+	if got := ids(ex.FilterTransactions(transactions, "BTC", 0, 0)); got != "13" {
+		t.Error("code:", got)
+	}
+	if got := ids(ex.FilterTransactions(transactions, "", 1500, 1)); got != "2" {
+		t.Error("since and limit:", got)
+	}
+	if got := ids(ex.FilterTransactions(transactions, "ETH", 0, 1)); got != "2" {
+		t.Error("limit after code:", got)
+	}
+}
@@ -0,0 +1,76 @@
+package base
+
+import "testing"
+
+func TestParse8601Milliseconds(t *testing.T) {
+	ex := &Exchange{}
+	if ms := ex.Parse8601("2020-09-13T12:26:40.123Z"); ms != 1600000000123 {
+		t.Error("Parse8601:", ms)
+	}
+	if ms := ex.Parse8601(""); ms != 0 {
+		t.Error("empty:", ms)
+	}
+}
+
+func TestParseBalanceFillsTotalAndFree(t *testing.T) {
+	balance := (&Exchange{}).ParseBalance(map[string]interface{}{
+		"BTC":  map[string]interface{}{"free": 1.5, "used": 0.5},
+		"USDT": map[string]interface{}{"used": 10.0, "total": 100.0},
+	})
+	if balance.Total["BTC"] != 2 || balance.Free["USDT"] != 90 || balance.Total["USDT"] != 100 {
+		t.Error("balance:", balance.Free, balance.Used, balance.Total)
+	}
+}
+
+func TestHelpersAcceptDecodedJson(t *testing.T) {
+	ex := &Exchange{}
+	if symbol := ex.Member(&Market{Symbol: "BTC/USDT"}, "symbol"); symbol != "BTC/USDT" {
+		t.Errorf("Member on a pointer: %#v", symbol)
+	}
+	if !ex.InArray("LIMIT", []interface{}{"MARKET", "LIMIT"}) || ex.InArray("STOP", []interface{}{"LIMIT"}) {
+		t.Error("InArray on a decoded array")
+	}
+	params := ex.Omit(map[string]interface{}{"a": 1, "b": 2, "c": 3}, []interface{}{"a", "b"})
+	if len(params) != 1 || params["c"] != 3 {
+		t.Error("Omit:", params)
+	}
+}
+
+func TestNumbersStringifyWithoutExponent(t *testing.T) {
+	ex := &Exchange{}
+	if id := ex.SafeString(map[string]interface{}{"id": 123456789012.0}, "id", ""); id != "123456789012" {
+		t.Error("SafeString:", id)
+	}
+	if path := ex.ImplodeParams("orders/{id}", map[string]interface{}{"id": 123456789012.0}); path != "orders/123456789012" {
+		t.Error("ImplodeParams:", path)
+	}
+	if query := ex.Urlencode(map[string]interface{}{"amount": 0.00001}); query != "amount=0.00001" {
+		t.Error("Urlencode:", query)
+	}
+}
+
+func TestMarketFromMapKeepsExchangeInfo(t *testing.T) {
+	info := map[string]interface{}{"symbol": "BTCUSDT", "orderTypes": []interface{}{"LIMIT"}}
+	market := MarketFromMap(map[string]interface{}{
+		"id": "BTCUSDT", "symbol": "BTC/USDT", "base": "BTC", "quote": "USDT", "baseId": "BTC", "info": info,
+	})
+	if got, ok := market.Info.(map[string]interface{}); !ok || got["symbol"] != "BTCUSDT" {
+		t.Errorf("Info: %#v", market.Info)
+	}
+}
+
+func TestSetMarketsIndexesCurrencies(t *testing.T) {
+	ex := &Exchange{}
+	ex.SetMarkets([]interface{}{map[string]interface{}{
+		"id": "btcusdt", "symbol": "BTC/USDT", "base": "BTC", "quote": "USDT", "baseId": "btc",
+	}}, nil)
+	if btc := ex.Currencies["BTC"]; btc == nil || btc.Id != "btc" {
+		t.Errorf("BTC: %+v", btc)
+	}
+	if usdt := ex.Currencies["USDT"]; usdt == nil || usdt.Id != "USDT" {
+		t.Errorf("USDT: %+v", usdt)
+	}
+	if _, ok := ex.CurrenciesById[""]; ok || len(ex.CurrenciesById) != 2 {
+		t.Error("CurrenciesById:", ex.CurrenciesById)
+	}
+}
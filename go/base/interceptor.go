@@ -0,0 +1,50 @@
+package base
+
+// Call is one request as signed by the adapter, on its way to be sent
+type Call struct {
+	// Id correlates the log records and the error of the request
+	Id string
+	// Api, Path and Params identify the endpoint as passed to Request
+	Api    string
+	Path   string
+	Params map[string]interface{}
+	// Url, Method, Headers and Body are the signed request that is sent
+	Url     string
+	Method  string
+	Headers map[string]interface{}
+	Body    interface{}
+}
+
+// Next passes a call on to the next interceptor, the last one sends it
+type Next func(call *Call) (response interface{}, err error)
+
+// Interceptor wraps every request of an exchange, whether it comes from a
+// unified method or a raw ApiFunc call. It may change the call before
+// passing it to next, change the decoded response or the error next returns,
+// or answer without calling next at all. Retries go through the chain again.
+type Interceptor func(call *Call, next Next) (response interface{}, err error)
+
+// Use appends interceptors to the chain, the first one registered sees a
+// call first and its response last. Register them before making requests.
+func (self *Exchange) Use(interceptors ...Interceptor) {
+	self.interceptors = append(self.interceptors, interceptors...)
+}
+
+// intercept runs call through the chain and fetch
+func (self *Exchange) intercept(call *Call) (interface{}, error) {
+	next := func(call *Call) (response interface{}, err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = self.PanicToError(e)
+			}
+		}()
+		return self.fetch(call), nil
+	}
+	for i := len(self.interceptors) - 1; i >= 0; i-- {
+		interceptor, inner := self.interceptors[i], next
+		next = func(call *Call) (interface{}, error) {
+			return interceptor(call, inner)
+		}
+	}
+	return next(call)
+}
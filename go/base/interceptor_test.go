@@ -0,0 +1,73 @@
+package base
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInterceptorsSeeSignedCallsInOrder(t *testing.T) {
+	ex, calls := newRetryExchange(t, RetryPolicy{}, nil)
+	ex.ApiDecodeInfo = map[string]*ApiDecode{"publicGetPing": {Api: "public", Method: "GET", Path: "ping"}}
+	var order []string
+	ex.Use(func(call *Call, next Next) (interface{}, error) {
+		order = append(order, "outer "+call.Method+" "+call.Url)
+		call.Headers["X-Trace"] = "1"
+		response, err := next(call)
+		order = append(order, "outer response")
+		return response, err
+	}, func(call *Call, next Next) (interface{}, error) {
+		order = append(order, "inner "+call.Headers["X-Trace"].(string))
+		response, err := next(call)
+		response.(map[string]interface{})["seen"] = true
+		return response, err
+	})
+
+	response := ex.ApiFunc("publicGetPing", map[string]interface{}{}, nil, nil)
+	if response["ok"] != true || response["seen"] != true || *calls != 1 {
+		t.Fatal("response:", response, *calls)
+	}
+	want := []string{"outer GET " + ex.url + "/ping", "inner 1", "outer response"}
+	if len(order) != len(want) || order[0] != want[0] || order[1] != want[1] || order[2] != want[2] {
+		t.Fatal("order:", order)
+	}
+}
+
+func TestInterceptorShortCircuitsAndInjectsFaults(t *testing.T) {
+	ex, calls := newRetryExchange(t, RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}, nil)
+	ex.Use(func(call *Call, next Next) (interface{}, error) {
+		if call.Path == "cached" {
+			return map[string]interface{}{"cached": true}, nil
+		}
+		return next(call)
+	})
+	if err := ex.call("GET", nil); err != nil {
+		t.Fatal(err)
+	}
+	if *calls != 1 {
+		t.Fatal("calls:", *calls)
+	}
+	response := ex.Request("cached", "public", "GET", nil, nil, nil)
+	if response.(map[string]interface{})["cached"] != true || *calls != 1 {
+		t.Fatal("short circuit:", response, *calls)
+	}
+
+	faults := 0
+	ex.Use(func(call *Call, next Next) (interface{}, error) {
+		if faults < 2 {
+			faults++
+			return nil, TypedError("RequestTimeout", "injected")
+		}
+		return next(call)
+	})
+	if err := ex.call("GET", nil); err != nil || faults != 2 || *calls != 2 {
+		t.Fatal("retried through injected faults:", err, faults, *calls)
+	}
+	if err := ex.call("POST", nil); err != nil {
+		t.Fatal(err)
+	}
+	faults = 0
+	if err := ex.call("POST", nil); !errors.Is(err, RequestTimeout) {
+		t.Fatal("injected fault:", err)
+	}
+}
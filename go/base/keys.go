@@ -0,0 +1,127 @@
+package base
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+)
+
+// Key types of ExchangeConfig.KeyType
+const (
+	KeyTypeHmac    = "hmac"
+	KeyTypeRsa     = "rsa"
+	KeyTypeEd25519 = "ed25519"
+)
+
+var hashIds = map[string]crypto.Hash{
+	"sha1":   crypto.SHA1,
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+// ParsePrivateKey reads a PEM encoded PKCS#8 RSA or Ed25519 key, or a
+// PKCS#1 RSA key
+func ParsePrivateKey(key string) (crypto.Signer, error) {
+	block, _ := pem.Decode([]byte(key))
+	if block == nil {
+		return nil, fmt.Errorf("private key is not PEM encoded")
+	}
+	if block.Type == "RSA PRIVATE KEY" {
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	signer, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("unsupported private key %T", parsed)
+	}
+	return signer, nil
+}
+
+// privateKey returns the parsed PEM key, it is parsed again only when the
+// key changes, e.g. after the credentials are rotated
+func (self *Exchange) privateKey(key string) (crypto.Signer, error) {
+	self.signerMu.Lock()
+	defer self.signerMu.Unlock()
+	if self.signer == nil || self.signerSecret != key {
+		signer, err := ParsePrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		self.signer, self.signerSecret = signer, key
+	}
+	return self.signer, nil
+}
+
+// Rsa signs the payload with RSASSA-PKCS1-v1_5 under the PEM private key
+func (self *Exchange) Rsa(payload, key, algo, encoding string) string {
+	hashId, ok := hashIds[algo]
+	if !ok {
+		self.RaiseException("InternalError", fmt.Sprintf("RSA: unsupported hashing algo \"%s\"", algo))
+	}
+	signer, err := self.privateKey(key)
+	if err != nil {
+		self.RaiseException("AuthenticationError", fmt.Sprintf("RSA private key: %v", err))
+	}
+	private, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		self.RaiseException("AuthenticationError", fmt.Sprintf("RSA private key: got %T", signer))
+	}
+	h := hashId.New()
+	h.Write([]byte(payload))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, private, hashId, h.Sum(nil))
+	if err != nil {
+		self.RaiseException("InternalError", fmt.Sprintf("RSA: %s", err))
+	}
+	return string(encode(signature, encoding))
+}
+
+// Eddsa signs the payload with the PEM Ed25519 private key
+func (self *Exchange) Eddsa(payload, key, encoding string) string {
+	signer, err := self.privateKey(key)
+	if err != nil {
+		self.RaiseException("AuthenticationError", fmt.Sprintf("Ed25519 private key: %v", err))
+	}
+	private, ok := signer.(ed25519.PrivateKey)
+	if !ok {
+		self.RaiseException("AuthenticationError", fmt.Sprintf("Ed25519 private key: got %T", signer))
+	}
+	return string(encode(ed25519.Sign(private, []byte(payload)), encoding))
+}
+
+// Signature signs the payload with the secret as KeyType says: an HMAC with
+// algo, the default, an RSA signature with algo, or an Ed25519 signature.
+// Exchanges expect asymmetric signatures base64 encoded.
+func (self *Exchange) Signature(payload, secret, algo, encoding string) string {
+	switch self.KeyType {
+	case KeyTypeRsa:
+		return self.Rsa(payload, secret, algo, encoding)
+	case KeyTypeEd25519:
+		return self.Eddsa(payload, secret, encoding)
+	}
+	return self.Hmac(payload, secret, algo, encoding)
+}
+
+// Asymmetric reports whether KeyType is a public key algorithm
+func (self *Exchange) Asymmetric() bool {
+	return self.KeyType == KeyTypeRsa || self.KeyType == KeyTypeEd25519
+}
+
+// CheckKeyType returns NotSupported if the exchange does not accept KeyType,
+// the describe's options.keyTypes lists the ones it does besides "hmac"
+func (self *Exchange) CheckKeyType() error {
+	if self.KeyType == "" || self.KeyType == KeyTypeHmac {
+		return nil
+	}
+	if keyTypes, ok := self.Options["keyTypes"].([]interface{}); ok && self.InArray(self.KeyType, keyTypes) {
+		return nil
+	}
+	return TypedError("NotSupported", fmt.Sprintf("%s does not support %s keys", self.Id, self.KeyType))
+}
@@ -0,0 +1,100 @@
+package base
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestAsymmetricSignatures(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex := &Exchange{}
+	pkcs1 := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))
+	ex.KeyType = KeyTypeRsa
+	signature, _ := base64.StdEncoding.DecodeString(ex.Signature("payload", pkcs1, "sha256", "base64"))
+	digest := sha256.Sum256([]byte("payload"))
+	if err := rsa.VerifyPKCS1v15(&rsaKey.PublicKey, crypto.SHA256, digest[:], signature); err != nil {
+		t.Error("rsa:", err)
+	}
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkcs8 := string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	ex.KeyType = KeyTypeEd25519
+	signature, _ = base64.StdEncoding.DecodeString(ex.Signature("payload", pkcs8, "sha256", "base64"))
+	if !ed25519.Verify(public, []byte("payload"), signature) {
+		t.Error("ed25519 signature does not verify")
+	}
+
+	err = func() (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = ex.PanicToError(e)
+			}
+		}()
+		ex.Signature("payload", "not a key", "sha256", "base64")
+		return
+	}()
+	if !errors.Is(err, AuthenticationError) {
+		t.Error("invalid key:", err)
+	}
+}
+
+func TestKeyTypeConfig(t *testing.T) {
+	ex := &Exchange{}
+	if err := ex.Init(&ExchangeConfig{KeyType: "dsa"}); !errors.Is(err, BadRequest) {
+		t.Fatal("unknown key type:", err)
+	}
+
+	ex = &Exchange{}
+	ex.KeyType = KeyTypeEd25519
+	if err := ex.CheckKeyType(); !errors.Is(err, NotSupported) {
+		t.Fatal("exchange without keyTypes:", err)
+	}
+	ex.Options = map[string]interface{}{"keyTypes": []interface{}{"rsa", "ed25519"}}
+	if err := ex.CheckKeyType(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPrivateKeyIsParsedOncePerSecret(t *testing.T) {
+	pemKey := func() string {
+		_, private, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatal(err)
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}))
+	}
+	ex := &Exchange{}
+	first, rotated := pemKey(), pemKey()
+	a, _ := ex.privateKey(first)
+	b, _ := ex.privateKey(first)
+	c, _ := ex.privateKey(rotated)
+	if a == nil || reflect.ValueOf(a).Pointer() != reflect.ValueOf(b).Pointer() {
+		t.Error("same secret parsed again")
+	}
+	if reflect.ValueOf(c).Pointer() == reflect.ValueOf(a).Pointer() {
+		t.Error("rotated secret not parsed")
+	}
+}
@@ -0,0 +1,171 @@
+package base
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Logger receives the log records of an exchange. The methods mirror
+// log/slog, a message followed by alternating keys and values, so a
+// *slog.Logger can be used as is.
+type Logger interface {
+	Debug(msg string, args ...interface{})
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+	Error(msg string, args ...interface{})
+}
+
+// Level orders log records by severity
+type Level int
+
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+// stdLogger writes logfmt style lines through the standard log package
+type stdLogger struct {
+	out   *log.Logger
+	level Level
+}
+
+// NewLogger returns a Logger that writes records at level and above to w,
+// one "LEVEL message key=value ..." line each. A nil w writes through the
+// standard log package.
+func NewLogger(w io.Writer, level Level) Logger {
+	l := &stdLogger{level: level}
+	if w != nil {
+		l.out = log.New(w, "", log.LstdFlags)
+	}
+	return l
+}
+
+func (l *stdLogger) log(level Level, msg string, args []interface{}) {
+	if level < l.level {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteString(" ")
+	b.WriteString(msg)
+	for i := 0; i < len(args); i += 2 {
+		key, value := fmt.Sprint(args[i]), interface{}("")
+		if i+1 < len(args) {
+			value = args[i+1]
+		}
+		s := fmt.Sprint(value)
+		if s == "" || strings.ContainsAny(s, " =\"\n") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(" " + key + "=" + s)
+	}
+	if l.out != nil {
+		l.out.Println(b.String())
+	} else {
+		log.Println(b.String())
+	}
+}
+
+func (l *stdLogger) Debug(msg string, args ...interface{}) { l.log(LevelDebug, msg, args) }
+func (l *stdLogger) Info(msg string, args ...interface{})  { l.log(LevelInfo, msg, args) }
+func (l *stdLogger) Warn(msg string, args ...interface{})  { l.log(LevelWarn, msg, args) }
+func (l *stdLogger) Error(msg string, args ...interface{}) { l.log(LevelError, msg, args) }
+
+// Log returns the configured Logger. Without one, warnings and errors go to
+// the standard log package, and debug records too when Verbose is set.
+func (self *Exchange) Log() Logger {
+	if self.Logger != nil {
+		return self.Logger
+	}
+	if self.Verbose {
+		return &stdLogger{level: LevelDebug}
+	}
+	return &stdLogger{level: LevelWarn}
+}
+
+// newRequestId returns a random id that correlates the records of a request
+func newRequestId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+const redacted = "[REDACTED]"
+
+// sensitiveName matches the names of headers and parameters that carry
+// credentials or signatures, e.g. X-MBX-APIKEY, OK-ACCESS-SIGN,
+// KC-API-PASSPHRASE, AccessKeyId or signature
+var sensitiveName = regexp.MustCompile(`(?i)(key|sign|secret|passphrase|password|token|authorization|cookie)`)
+
+var (
+	sensitiveParam = regexp.MustCompile(`(^|[?&])([^=&?]+)=([^&]*)`)
+	sensitiveJson  = regexp.MustCompile(`"([^"]+)"(\s*:\s*)"(?:[^"\\]|\\.)*"`)
+)
+
+// redact removes the credentials of the exchange and the values of
+// sensitive parameters from a url, query string or body
+func (self *Exchange) redact(s string) string {
+	credentials, _ := self.currentCredentials()
+	for _, credential := range []string{credentials.ApiKey, credentials.Secret, credentials.Password, self.ApiKey, self.Secret, self.Password} {
+		if len(credential) >= 4 {
+			s = strings.Replace(s, credential, redacted, -1)
+		}
+	}
+	s = sensitiveParam.ReplaceAllStringFunc(s, func(m string) string {
+		parts := sensitiveParam.FindStringSubmatch(m)
+		if !sensitiveName.MatchString(parts[2]) {
+			return m
+		}
+		return parts[1] + parts[2] + "=" + redacted
+	})
+	return sensitiveJson.ReplaceAllStringFunc(s, func(m string) string {
+		parts := sensitiveJson.FindStringSubmatch(m)
+		if !sensitiveName.MatchString(parts[1]) {
+			return m
+		}
+		return `"` + parts[1] + `"` + parts[2] + `"` + redacted + `"`
+	})
+}
+
+// redactHeaders formats headers for a log record, sorted and with the
+// values of sensitive headers removed
+func (self *Exchange) redactHeaders(header http.Header) string {
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]string, len(names))
+	for i, name := range names {
+		value := strings.Join(header[name], ",")
+		if sensitiveName.MatchString(name) {
+			value = redacted
+		} else {
+			value = self.redact(value)
+		}
+		fields[i] = name + ":" + value
+	}
+	return "{" + strings.Join(fields, " ") + "}"
+}
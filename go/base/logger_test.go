@@ -0,0 +1,117 @@
+package base
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// captureLogger keeps every record as one line
+type captureLogger struct {
+	lines []string
+}
+
+func (l *captureLogger) add(level, msg string, args []interface{}) {
+	l.lines = append(l.lines, level+" "+msg+" "+fmt.Sprint(args...))
+}
+
+func (l *captureLogger) Debug(msg string, args ...interface{}) { l.add("DEBUG", msg, args) }
+func (l *captureLogger) Info(msg string, args ...interface{})  { l.add("INFO", msg, args) }
+func (l *captureLogger) Warn(msg string, args ...interface{})  { l.add("WARN", msg, args) }
+func (l *captureLogger) Error(msg string, args ...interface{}) { l.add("ERROR", msg, args) }
+
+// signedExchange signs like okex and kucoin, with the credentials in headers
+// and a signature in the query
+type signedExchange struct {
+	Exchange
+	url string
+}
+
+func (self *signedExchange) Sign(path string, api string, method string, params map[string]interface{}, headers interface{}, body interface{}) interface{} {
+	return map[string]interface{}{
+		"url":    self.url + "/" + path + "?symbol=BTCUSDT&signature=c2lnbmF0dXJl",
+		"method": method,
+		"headers": map[string]interface{}{
+			"OK-ACCESS-KEY":     self.ApiKey,
+			"KC-API-PASSPHRASE": self.Password,
+			"Content-Type":      "application/json",
+		},
+		"body": `{"clientOid":"x1","secret":"` + self.Secret + `"}`,
+	}
+}
+
+func TestLoggerRedactsCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(400)
+		w.Write([]byte(`{"code":"-1","msg":"bad request"}`))
+	}))
+	defer server.Close()
+	logger := &captureLogger{}
+	ex := &signedExchange{url: server.URL}
+	err := ex.Init(&ExchangeConfig{ApiKey: "apikey-1234", Secret: "secret-5678", Password: "pass-9012", Logger: logger})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.Child = ex
+
+	err = func() (err error) {
+		defer func() {
+			if e := recover(); e != nil {
+				err = ex.PanicToError(e)
+			}
+		}()
+		ex.Request("order", "private", "POST", nil, nil, nil)
+		return
+	}()
+	var e *Error
+	if !errors.As(err, &e) || e.RequestId == "" {
+		t.Fatal("request id:", err)
+	}
+
+	log := strings.Join(logger.lines, "\n")
+	for _, s := range []string{"DEBUG request", "DEBUG response", e.RequestId, "symbol=BTCUSDT", "clientOid", "bad request"} {
+		if !strings.Contains(log, s) {
+			t.Errorf("log misses %q:\n%s", s, log)
+		}
+	}
+	for _, s := range []string{"apikey-1234", "secret-5678", "pass-9012", "c2lnbmF0dXJl"} {
+		if strings.Contains(log, s) {
+			t.Errorf("log leaks %q:\n%s", s, log)
+		}
+	}
+}
+
+func TestRedact(t *testing.T) {
+	ex := &Exchange{}
+	ex.Secret = "s3cr3t"
+	cases := map[string]string{
+		"https://api.example.com/order?symbol=BTCUSDT&signature=abc": "https://api.example.com/order?symbol=BTCUSDT&signature=[REDACTED]",
+		"AccessKeyId=k1&Timestamp=1&Signature=x":                     "AccessKeyId=[REDACTED]&Timestamp=1&Signature=[REDACTED]",
+		`{"apiKey": "k1", "amount":"1"}`:                             `{"apiKey": "[REDACTED]", "amount":"1"}`,
+		"key s3cr3t leaked":                                          "key [REDACTED] leaked",
+	}
+	for in, want := range cases {
+		if got := ex.redact(in); got != want {
+			t.Errorf("redact(%q) = %q, want %q", in, got, want)
+		}
+	}
+
+	header := http.Header{"X-Mbx-Apikey": {"k1"}, "Content-Type": {"application/json"}}
+	if got := ex.redactHeaders(header); got != "{Content-Type:application/json X-Mbx-Apikey:[REDACTED]}" {
+		t.Error("headers:", got)
+	}
+}
+
+func TestNewLoggerLevel(t *testing.T) {
+	var b strings.Builder
+	logger := NewLogger(&b, LevelInfo)
+	logger.Debug("hidden")
+	logger.Info("retry", "attempt", 1, "error", "ExchangeNotAvailable: 503")
+	out := b.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, `INFO retry attempt=1 error="ExchangeNotAvailable: 503"`) {
+		t.Fatal(out)
+	}
+}
@@ -0,0 +1,76 @@
+package base
+
+// MarginLoan is a borrow or a repayment on a margin account
+type MarginLoan struct {
+	Id       string  `json:"id"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	// Symbol is the market of an isolated margin account, empty for cross
+	// margin
+	Symbol    string      `json:"symbol"`
+	Timestamp int64       `json:"timestamp"`
+	Datetime  string      `json:"datetime"`
+	Info      interface{} `json:"info"`
+}
+
+// BorrowRate is the interest charged for borrowing a currency on margin
+type BorrowRate struct {
+	Currency string `json:"currency"`
+	// Rate is charged once every Period milliseconds, 86400000 for a daily
+	// rate
+	Rate      float64     `json:"rate"`
+	Period    int64       `json:"period"`
+	Timestamp int64       `json:"timestamp"`
+	Datetime  string      `json:"datetime"`
+	Info      interface{} `json:"info"`
+}
+
+// BorrowInterest is interest charged on a margin loan
+type BorrowInterest struct {
+	Currency string  `json:"currency"`
+	Symbol   string  `json:"symbol"`
+	Interest float64 `json:"interest"`
+	Rate     float64 `json:"rate"`
+	// Amount is the principal the interest was charged on
+	Amount    float64     `json:"amount"`
+	Timestamp int64       `json:"timestamp"`
+	Datetime  string      `json:"datetime"`
+	Info      interface{} `json:"info"`
+}
+
+// Borrow takes a margin loan of amount of code, on the isolated margin
+// account of symbol or on the cross margin account if symbol is empty
+func (self *Exchange) Borrow(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error) {
+	return nil, TypedError("NotSupported", self.Id+" Borrow not supported yet")
+}
+
+// Repay pays back amount of code borrowed with Borrow, interest first
+func (self *Exchange) Repay(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error) {
+	return nil, TypedError("NotSupported", self.Id+" Repay not supported yet")
+}
+
+// FetchBorrowRate returns the current interest rate of borrowing code
+func (self *Exchange) FetchBorrowRate(code string, params map[string]interface{}) (*BorrowRate, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchBorrowRate not supported yet")
+}
+
+// FetchBorrowInterest returns the interest charged on loans of code, every
+// code if empty, from since on, at most limit entries, oldest first
+func (self *Exchange) FetchBorrowInterest(code string, symbol string, since int64, limit int64, params map[string]interface{}) ([]*BorrowInterest, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchBorrowInterest not supported yet")
+}
+
+// FetchMaxBorrowable returns how much more of code can be borrowed
+func (self *Exchange) FetchMaxBorrowable(code string, symbol string, params map[string]interface{}) (float64, error) {
+	return 0, TypedError("NotSupported", self.Id+" FetchMaxBorrowable not supported yet")
+}
+
+// FilterBorrowInterest sorts entries oldest first and keeps those from
+// since on, at most limit of them
+func (self *Exchange) FilterBorrowInterest(entries []*BorrowInterest, since int64, limit int64) []*BorrowInterest {
+	result := []*BorrowInterest{}
+	for _, i := range FilterSince(len(entries), func(i int) int64 { return entries[i].Timestamp }, since, limit) {
+		result = append(result, entries[i])
+	}
+	return result
+}
@@ -0,0 +1,17 @@
+package base
+
+import "testing"
+
+func TestParseBalanceDebt(t *testing.T) {
+	ex := &Exchange{}
+	balance := ex.ParseBalance(map[string]interface{}{
+		"BTC":  map[string]interface{}{"free": 1.5, "used": 0.0, "debt": 1.0, "interest": 0.001},
+		"USDT": map[string]interface{}{"free": 100.0, "used": 0.0},
+	})
+	if balance.Debt["BTC"] != 1 || balance.Interest["BTC"] != 0.001 || balance.Account["BTC"].Debt != 1 {
+		t.Error("debt:", balance.Debt, balance.Interest)
+	}
+	if _, ok := balance.Debt["USDT"]; ok {
+		t.Error("USDT owes nothing:", balance.Debt)
+	}
+}
@@ -0,0 +1,110 @@
+package base
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Metrics receives measurements of the requests of an exchange. The methods
+// are called from the goroutine making the request, implementations must be
+// safe for concurrent use.
+type Metrics interface {
+	// ObserveRequest is called once for every attempt of a request
+	ObserveRequest(m RequestMetric)
+	// ObserveRetryDelay is called before a failed request waits to be
+	// retried, see RetryPolicy. Requests are not throttled otherwise, so
+	// this is the only wait reported.
+	ObserveRetryDelay(exchange string, function string, delay time.Duration)
+	// ObserveRateLimit reports the request weight left in the exchange's
+	// current window, for the exchanges that send it with every response
+	ObserveRateLimit(exchange string, remaining float64, limit float64)
+}
+
+// RequestMetric describes one attempt of a request
+type RequestMetric struct {
+	Exchange string
+	// Function is the ApiDecodeInfo name of the endpoint, e.g.
+	// "publicGetDepth", "" for a raw Fetch
+	Function string
+	Method   string
+	// Status is the HTTP status, 0 if no response was received
+	Status int
+	// ErrorClass is the class of the error raised, e.g. "InsufficientFunds",
+	// "" on success
+	ErrorClass string
+	Duration   time.Duration
+}
+
+// apiFunctionName returns the name DefineRestApi gives the endpoint
+func (self *Exchange) apiFunctionName(api string, method string, path string) string {
+	if api == "" {
+		return ""
+	}
+	name := api + strings.Title(strings.ToLower(method))
+	for _, part := range self.RegSplit(path, "[^a-zA-Z0-9]") {
+		name += strings.Title(part)
+	}
+	return name
+}
+
+// observeRequest reports an attempt to Metrics, if set
+func (self *Exchange) observeRequest(call *Call, resp *http.Response, err error, duration time.Duration) {
+	if self.Metrics == nil {
+		return
+	}
+	m := RequestMetric{
+		Exchange: self.Id,
+		Function: self.apiFunctionName(call.Api, call.Method, call.Path),
+		Method:   call.Method,
+		Duration: duration,
+	}
+	if resp != nil {
+		m.Status = resp.StatusCode
+		if remaining, limit, ok := self.rateLimitUsage(resp.Header); ok {
+			self.Metrics.ObserveRateLimit(self.Id, remaining, limit)
+		}
+	}
+	if err != nil {
+		var e *Error
+		if errors.As(err, &e) {
+			m.ErrorClass = e.Class
+		} else {
+			m.ErrorClass = "InternalError"
+		}
+	}
+	self.Metrics.ObserveRequest(m)
+}
+
+// rateLimitUsage reads the weight left from the response headers named by
+// options.rateLimitHeaders, which has a "remaining" or a "used" header and
+// a "limit" header or number
+func (self *Exchange) rateLimitUsage(header http.Header) (remaining float64, limit float64, ok bool) {
+	headers, _ := self.Options["rateLimitHeaders"].(map[string]interface{})
+	if headers == nil {
+		return
+	}
+	value := func(key string) (float64, bool) {
+		switch v := headers[key].(type) {
+		case float64:
+			return v, true
+		case string:
+			f, err := strconv.ParseFloat(header.Get(v), 64)
+			return f, err == nil
+		}
+		return 0, false
+	}
+	if limit, ok = value("limit"); !ok {
+		return
+	}
+	if remaining, ok = value("remaining"); ok {
+		return
+	}
+	var used float64
+	if used, ok = value("used"); ok {
+		remaining = limit - used
+	}
+	return
+}
@@ -0,0 +1,74 @@
+package base
+
+import (
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+// captureMetrics keeps every observation
+type captureMetrics struct {
+	sync.Mutex
+	requests  []RequestMetric
+	delays    []time.Duration
+	remaining float64
+	limit     float64
+}
+
+func (m *captureMetrics) ObserveRequest(r RequestMetric) {
+	m.Lock()
+	defer m.Unlock()
+	m.requests = append(m.requests, r)
+}
+
+func (m *captureMetrics) ObserveRetryDelay(exchange string, function string, delay time.Duration) {
+	m.Lock()
+	defer m.Unlock()
+	m.delays = append(m.delays, delay)
+}
+
+func (m *captureMetrics) ObserveRateLimit(exchange string, remaining float64, limit float64) {
+	m.Lock()
+	defer m.Unlock()
+	m.remaining, m.limit = remaining, limit
+}
+
+func TestMetricsObserveEveryAttempt(t *testing.T) {
+	ex, _ := newRetryExchange(t, RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond},
+		http.Header{"X-Mbx-Used-Weight-1m": {"20"}}, 503)
+	metrics := &captureMetrics{}
+	ex.Id = "test"
+	ex.Metrics = metrics
+	ex.Options = map[string]interface{}{
+		"rateLimitHeaders": map[string]interface{}{"used": "X-MBX-USED-WEIGHT-1M", "limit": 1200.0},
+	}
+	if err := ex.call("GET", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(metrics.requests) != 2 || len(metrics.delays) != 1 {
+		t.Fatal("observations:", metrics.requests, metrics.delays)
+	}
+	failed, ok := metrics.requests[0], metrics.requests[1]
+	if failed.Exchange != "test" || failed.Function != "publicGetPing" || failed.Method != "GET" ||
+		failed.Status != 503 || failed.ErrorClass != "ExchangeNotAvailable" {
+		t.Error("failed attempt:", failed)
+	}
+	if ok.Status != 200 || ok.ErrorClass != "" || ok.Duration <= 0 {
+		t.Error("successful attempt:", ok)
+	}
+	if metrics.remaining != 1180 || metrics.limit != 1200 {
+		t.Error("rate limit:", metrics.remaining, metrics.limit)
+	}
+}
+
+func TestApiFunctionName(t *testing.T) {
+	ex := &Exchange{}
+	if name := ex.apiFunctionName("sapi", "POST", "margin/loan"); name != "sapiPostMarginLoan" {
+		t.Fatal(name)
+	}
+	if name := ex.apiFunctionName("", "GET", "https://example.com"); name != "" {
+		t.Fatal("raw fetch:", name)
+	}
+}
@@ -0,0 +1,174 @@
+package base
+
+import (
+	"math"
+)
+
+// bids are sorted by price descending and asks ascending (see ParseOrderBook),
+// so the first level of each side is the best price.
+
+// BestBid returns the highest bid price, 0 if there are no bids
+func (o *OrderBook) BestBid() float64 {
+	if len(o.Bids) == 0 {
+		return 0
+	}
+	return o.Bids[0][0]
+}
+
+// BestAsk returns the lowest ask price, 0 if there are no asks
+func (o *OrderBook) BestAsk() float64 {
+	if len(o.Asks) == 0 {
+		return 0
+	}
+	return o.Asks[0][0]
+}
+
+// MidPrice returns (bestBid + bestAsk) / 2, 0 if either side is empty
+func (o *OrderBook) MidPrice() float64 {
+	bid, ask := o.BestBid(), o.BestAsk()
+	if bid == 0 || ask == 0 {
+		return 0
+	}
+	return (bid + ask) / 2
+}
+
+// Spread returns bestAsk - bestBid, 0 if either side is empty
+func (o *OrderBook) Spread() float64 {
+	bid, ask := o.BestBid(), o.BestAsk()
+	if bid == 0 || ask == 0 {
+		return 0
+	}
+	return ask - bid
+}
+
+// SpreadBps returns the spread relative to the mid price in basis points
+func (o *OrderBook) SpreadBps() float64 {
+	mid := o.MidPrice()
+	if mid == 0 {
+		return 0
+	}
+	return o.Spread() / mid * 10000
+}
+
+// levels returns the side of the book an order of the given side executes against:
+// a buy takes the asks, a sell hits the bids
+func (o *OrderBook) levels(side string) [][2]float64 {
+	if side == "buy" {
+		return o.Asks
+	}
+	return o.Bids
+}
+
+// VwapForAmount walks the book for an order of side ("buy" or "sell") and
+// amount in base currency. It returns the volume-weighted average fill price
+// and the amount that could actually be filled, which is less than amount
+// if the book is not deep enough.
+func (o *OrderBook) VwapForAmount(side string, amount float64) (price float64, filled float64) {
+	var cost float64
+	for _, level := range o.levels(side) {
+		if filled >= amount {
+			break
+		}
+		take := math.Min(level[1], amount-filled)
+		filled += take
+		cost += take * level[0]
+	}
+	if filled > 0 {
+		price = cost / filled
+	}
+	return
+}
+
+// VwapForCost is like VwapForAmount but the order size is given in quote
+// currency. It returns the average fill price and the base amount received
+// for spending (or receiving) up to cost.
+func (o *OrderBook) VwapForCost(side string, cost float64) (price float64, filled float64) {
+	var spent float64
+	for _, level := range o.levels(side) {
+		if spent >= cost {
+			break
+		}
+		if level[0] <= 0 {
+			continue
+		}
+		take := math.Min(level[1], (cost-spent)/level[0])
+		filled += take
+		spent += take * level[0]
+	}
+	if filled > 0 {
+		price = spent / filled
+	}
+	return
+}
+
+// Slippage returns the expected slippage of a market order of side and amount
+// as a fraction of the mid price, e.g. 0.001 means the average fill is 10 bps
+// worse than mid. It returns 0 when the book is empty.
+func (o *OrderBook) Slippage(side string, amount float64) float64 {
+	mid := o.MidPrice()
+	price, filled := o.VwapForAmount(side, amount)
+	if mid == 0 || filled == 0 {
+		return 0
+	}
+	if side == "buy" {
+		return (price - mid) / mid
+	}
+	return (mid - price) / mid
+}
+
+// DepthWithinBps sums the levels an order of side ("buy" or "sell") would
+// execute against that are priced within bps basis points of the mid price,
+// and returns the cumulative amount in base currency and cost in quote
+// currency.
+func (o *OrderBook) DepthWithinBps(side string, bps float64) (amount float64, cost float64) {
+	mid := o.MidPrice()
+	if mid == 0 {
+		return
+	}
+	limit := mid * (1 - bps/10000)
+	if side == "buy" {
+		limit = mid * (1 + bps/10000)
+	}
+	for _, level := range o.levels(side) {
+		if side == "buy" && level[0] > limit || side != "buy" && level[0] < limit {
+			break
+		}
+		amount += level[1]
+		cost += level[0] * level[1]
+	}
+	return
+}
+
+// Group aggregates the book into price buckets of width step. Bids are
+// rounded down and asks up to the bucket boundary so that grouping never
+// makes the book look better than it is. The receiver is not modified.
+func (o *OrderBook) Group(step float64) *OrderBook {
+	result := &OrderBook{
+		Timestamp: o.Timestamp,
+		Datetime:  o.Datetime,
+		Nonce:     o.Nonce,
+	}
+	if step <= 0 {
+		result.Bids = append(result.Bids, o.Bids...)
+		result.Asks = append(result.Asks, o.Asks...)
+		return result
+	}
+	result.Bids = groupLevels(o.Bids, step, math.Floor)
+	result.Asks = groupLevels(o.Asks, step, math.Ceil)
+	return result
+}
+
+// groupLevels merges consecutive levels falling into the same bucket; the
+// input is already sorted so buckets come out in the same order
+func groupLevels(levels [][2]float64, step float64, round func(float64) float64) (out [][2]float64) {
+	for _, level := range levels {
+		// round to 1e-9 first so that e.g. 0.3/0.1 does not land in the wrong bucket
+		bucket := round(math.Round(level[0]/step*1e9)/1e9) * step
+		if n := len(out); n > 0 && out[n-1][0] == bucket {
+			out[n-1][1] += level[1]
+		} else {
+			out = append(out, [2]float64{bucket, level[1]})
+		}
+	}
+	return
+}
@@ -0,0 +1,91 @@
+package base
+
+import (
+	"math"
+	"testing"
+)
+
+func testOrderBook() *OrderBook {
+	return &OrderBook{
+		Bids: [][2]float64{{99, 1}, {98, 2}, {97, 3}},
+		Asks: [][2]float64{{101, 1}, {102, 2}, {103, 3}},
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestOrderBookMidAndSpread(t *testing.T) {
+	ob := testOrderBook()
+	if ob.MidPrice() != 100 {
+		t.Fatal("MidPrice:", ob.MidPrice())
+	}
+	if ob.Spread() != 2 {
+		t.Fatal("Spread:", ob.Spread())
+	}
+	if ob.SpreadBps() != 200 {
+		t.Fatal("SpreadBps:", ob.SpreadBps())
+	}
+	if (&OrderBook{}).MidPrice() != 0 {
+		t.Fatal("MidPrice of empty book should be 0")
+	}
+}
+
+func TestOrderBookVwap(t *testing.T) {
+	ob := testOrderBook()
+
+	price, filled := ob.VwapForAmount("buy", 2)
+	if !almostEqual(price, 101.5) || filled != 2 {
+		t.Fatal("VwapForAmount buy:", price, filled)
+	}
+	price, filled = ob.VwapForAmount("sell", 3)
+	if !almostEqual(price, (99+98*2)/3.) || filled != 3 {
+		t.Fatal("VwapForAmount sell:", price, filled)
+	}
+	_, filled = ob.VwapForAmount("buy", 100)
+	if filled != 6 {
+		t.Fatal("VwapForAmount should stop at book depth:", filled)
+	}
+
+	price, filled = ob.VwapForCost("buy", 101+102)
+	if !almostEqual(filled, 2) || !almostEqual(price, 101.5) {
+		t.Fatal("VwapForCost:", price, filled)
+	}
+}
+
+func TestOrderBookSlippage(t *testing.T) {
+	ob := testOrderBook()
+	if !almostEqual(ob.Slippage("buy", 1), 0.01) {
+		t.Fatal("Slippage buy:", ob.Slippage("buy", 1))
+	}
+	if !almostEqual(ob.Slippage("sell", 1), 0.01) {
+		t.Fatal("Slippage sell:", ob.Slippage("sell", 1))
+	}
+}
+
+func TestOrderBookDepthWithinBps(t *testing.T) {
+	ob := testOrderBook()
+	amount, cost := ob.DepthWithinBps("buy", 200)
+	if amount != 3 || cost != 101+204 {
+		t.Fatal("DepthWithinBps buy:", amount, cost)
+	}
+	amount, _ = ob.DepthWithinBps("sell", 150)
+	if amount != 1 {
+		t.Fatal("DepthWithinBps sell:", amount)
+	}
+}
+
+func TestOrderBookGroup(t *testing.T) {
+	ob := testOrderBook()
+	grouped := ob.Group(5)
+	if len(grouped.Bids) != 1 || grouped.Bids[0] != [2]float64{95, 6} {
+		t.Fatal("Group bids:", grouped.Bids)
+	}
+	if len(grouped.Asks) != 1 || grouped.Asks[0] != [2]float64{105, 6} {
+		t.Fatal("Group asks:", grouped.Asks)
+	}
+	if len(ob.Bids) != 3 {
+		t.Fatal("Group must not modify the receiver")
+	}
+}
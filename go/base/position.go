@@ -0,0 +1,71 @@
+package base
+
+// Position is an open futures or perpetual swap position
+type Position struct {
+	Symbol string `json:"symbol"`
+	// Side is "long" or "short"
+	Side string `json:"side"`
+	// Contracts is the size of the position in contracts, always positive
+	Contracts  float64 `json:"contracts"`
+	EntryPrice float64 `json:"entryPrice"`
+	// MarkPrice is the price liquidation is computed from, not the last
+	// traded price
+	MarkPrice        float64 `json:"markPrice"`
+	UnrealizedPnl    float64 `json:"unrealizedPnl"`
+	LiquidationPrice float64 `json:"liquidationPrice"`
+	// MarginMode is "cross" or "isolated"
+	MarginMode string      `json:"marginMode"`
+	Leverage   float64     `json:"leverage"`
+	Timestamp  int64       `json:"timestamp"`
+	Datetime   string      `json:"datetime"`
+	Info       interface{} `json:"info"`
+}
+
+// FetchPositions returns the open positions on symbols, on every market if
+// symbols is empty
+func (self *Exchange) FetchPositions(symbols []string, params map[string]interface{}) ([]*Position, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchPositions not supported yet")
+}
+
+// FilterPositions keeps the open positions on symbols, every open position
+// if symbols is empty
+func (self *Exchange) FilterPositions(positions []*Position, symbols []string) []*Position {
+	result := []*Position{}
+	for _, position := range positions {
+		if position.Contracts == 0 {
+			continue
+		}
+		if len(symbols) == 0 || self.InArray(position.Symbol, symbols) {
+			result = append(result, position)
+		}
+	}
+	return result
+}
+
+// Leverage is how a futures or swap market is traded
+type Leverage struct {
+	Symbol string `json:"symbol"`
+	// MarginMode is "cross" or "isolated"
+	MarginMode string `json:"marginMode"`
+	// LongLeverage and ShortLeverage only differ on exchanges that set
+	// each side on its own
+	LongLeverage  float64     `json:"longLeverage"`
+	ShortLeverage float64     `json:"shortLeverage"`
+	Info          interface{} `json:"info"`
+}
+
+// FetchLeverage returns the leverage and margin mode orders on symbol use
+func (self *Exchange) FetchLeverage(symbol string, params map[string]interface{}) (*Leverage, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchLeverage not supported yet")
+}
+
+// SetLeverage sets the leverage of both sides of symbol in the account,
+// later orders use it unless they ask for another one
+func (self *Exchange) SetLeverage(symbol string, leverage float64, params map[string]interface{}) error {
+	return TypedError("NotSupported", self.Id+" SetLeverage not supported yet")
+}
+
+// SetMarginMode switches symbol to "cross" or "isolated" margin
+func (self *Exchange) SetMarginMode(symbol string, marginMode string, params map[string]interface{}) error {
+	return TypedError("NotSupported", self.Id+" SetMarginMode not supported yet")
+}
@@ -0,0 +1,18 @@
+package base
+
+import "testing"
+
+func TestFilterPositions(t *testing.T) {
+	ex := &Exchange{}
+	positions := []*Position{
+		{Symbol: "BTC-USD-SWAP", Side: "long", Contracts: 2},
+		{Symbol: "BTC-USD-SWAP", Side: "short", Contracts: 0},
+		{Symbol: "ETH-USD-SWAP", Side: "short", Contracts: 1},
+	}
+	if all := ex.FilterPositions(positions, nil); len(all) != 2 {
+		t.Error("closed positions kept:", all)
+	}
+	if btc := ex.FilterPositions(positions, []string{"BTC-USD-SWAP"}); len(btc) != 1 || btc[0].Side != "long" {
+		t.Error("by symbol:", btc)
+	}
+}
@@ -0,0 +1,190 @@
+// Package prometheus collects the base.Metrics of any number of exchanges
+// and serves them in the Prometheus text exposition format, without a
+// dependency on the Prometheus client library:
+//
+//	metrics := prometheus.New("ccxt")
+//	exchange, _ := binance.New(&base.ExchangeConfig{Metrics: metrics})
+//	http.Handle("/metrics", metrics)
+//
+// It exports
+//
+//	ccxt_request_duration_seconds{exchange,function,method,status}  histogram
+//	ccxt_request_errors_total{exchange,function,class}              counter
+//	ccxt_retry_delay_seconds{exchange,function}                     histogram
+//	ccxt_rate_limit_remaining{exchange}                             gauge
+//	ccxt_rate_limit_limit{exchange}                                 gauge
+package prometheus
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/georgexdz/ccxt/go/base"
+)
+
+// DefaultBuckets are the upper bounds of the histograms, in seconds
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func (h *histogram) observe(buckets []float64, v float64) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	for i, bound := range buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.count++
+	h.sum += v
+}
+
+// Metrics implements base.Metrics and http.Handler
+type Metrics struct {
+	namespace string
+	buckets   []float64
+
+	mu        sync.Mutex
+	durations map[string]*histogram
+	errors    map[string]float64
+	delays    map[string]*histogram
+	remaining map[string]float64
+	limit     map[string]float64
+}
+
+// New returns empty Metrics whose names start with namespace, "ccxt" if empty
+func New(namespace string) *Metrics {
+	if namespace == "" {
+		namespace = "ccxt"
+	}
+	return &Metrics{
+		namespace: namespace,
+		buckets:   DefaultBuckets,
+		durations: map[string]*histogram{},
+		errors:    map[string]float64{},
+		delays:    map[string]*histogram{},
+		remaining: map[string]float64{},
+		limit:     map[string]float64{},
+	}
+}
+
+// labels formats label pairs, the key of a series
+func labels(pairs ...string) string {
+	fields := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		value := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(pairs[i+1])
+		fields = append(fields, pairs[i]+`="`+value+`"`)
+	}
+	return strings.Join(fields, ",")
+}
+
+func (m *Metrics) ObserveRequest(r base.RequestMetric) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := labels("exchange", r.Exchange, "function", r.Function, "method", r.Method, "status", strconv.Itoa(r.Status))
+	h := m.durations[key]
+	if h == nil {
+		h = &histogram{}
+		m.durations[key] = h
+	}
+	h.observe(m.buckets, r.Duration.Seconds())
+	if r.ErrorClass != "" {
+		m.errors[labels("exchange", r.Exchange, "function", r.Function, "class", r.ErrorClass)]++
+	}
+}
+
+func (m *Metrics) ObserveRetryDelay(exchange string, function string, delay time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := labels("exchange", exchange, "function", function)
+	h := m.delays[key]
+	if h == nil {
+		h = &histogram{}
+		m.delays[key] = h
+	}
+	h.observe(m.buckets, delay.Seconds())
+}
+
+func (m *Metrics) ObserveRateLimit(exchange string, remaining float64, limit float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := labels("exchange", exchange)
+	m.remaining[key] = remaining
+	m.limit[key] = limit
+}
+
+func sortedKeys(series interface{}) (keys []string) {
+	switch s := series.(type) {
+	case map[string]*histogram:
+		for key := range s {
+			keys = append(keys, key)
+		}
+	case map[string]float64:
+		for key := range s {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// WriteTo writes every series in the text exposition format
+func (m *Metrics) WriteTo(w io.Writer) (n int64, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b := bufio.NewWriter(w)
+	line := func(format string, args ...interface{}) {
+		written, _ := fmt.Fprintf(b, format+"\n", args...)
+		n += int64(written)
+	}
+	histograms := func(name, help string, series map[string]*histogram) {
+		name = m.namespace + "_" + name
+		line("# HELP %s %s", name, help)
+		line("# TYPE %s histogram", name)
+		for _, key := range sortedKeys(series) {
+			h := series[key]
+			for i, bound := range m.buckets {
+				line(`%s_bucket{%s,le="%s"} %d`, name, key, formatFloat(bound), h.counts[i])
+			}
+			line(`%s_bucket{%s,le="+Inf"} %d`, name, key, h.count)
+			line("%s_sum{%s} %s", name, key, formatFloat(h.sum))
+			line("%s_count{%s} %d", name, key, h.count)
+		}
+	}
+	values := func(name, kind, help string, series map[string]float64) {
+		name = m.namespace + "_" + name
+		line("# HELP %s %s", name, help)
+		line("# TYPE %s %s", name, kind)
+		for _, key := range sortedKeys(series) {
+			line("%s{%s} %s", name, key, formatFloat(series[key]))
+		}
+	}
+	histograms("request_duration_seconds", "Duration of the requests to the exchange.", m.durations)
+	values("request_errors_total", "counter", "Requests that raised an error, by unified error class.", m.errors)
+	histograms("retry_delay_seconds", "Time waited before retrying a failed request.", m.delays)
+	values("rate_limit_remaining", "gauge", "Request weight left in the exchange's current window.", m.remaining)
+	values("rate_limit_limit", "gauge", "Request weight allowed in the exchange's window.", m.limit)
+	return n, b.Flush()
+}
+
+// ServeHTTP serves the metrics to a Prometheus scrape
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
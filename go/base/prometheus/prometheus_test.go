@@ -0,0 +1,45 @@
+package prometheus
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgexdz/ccxt/go/base"
+)
+
+func TestExposition(t *testing.T) {
+	m := New("")
+	m.ObserveRequest(base.RequestMetric{Exchange: "binance", Function: "publicGetDepth", Method: "GET", Status: 200, Duration: 30 * time.Millisecond})
+	m.ObserveRequest(base.RequestMetric{Exchange: "binance", Function: "privatePostOrder", Method: "POST", Status: 400, ErrorClass: "InsufficientFunds", Duration: time.Second})
+	m.ObserveRetryDelay("binance", "publicGetDepth", 2*time.Second)
+	m.ObserveRateLimit("binance", 1180, 1200)
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest("GET", "/metrics", nil))
+	out := w.Body.String()
+	for _, want := range []string{
+		"# TYPE ccxt_request_duration_seconds histogram",
+		`ccxt_request_duration_seconds_bucket{exchange="binance",function="publicGetDepth",method="GET",status="200",le="0.025"} 0`,
+		`ccxt_request_duration_seconds_bucket{exchange="binance",function="publicGetDepth",method="GET",status="200",le="0.05"} 1`,
+		`ccxt_request_duration_seconds_count{exchange="binance",function="privatePostOrder",method="POST",status="400"} 1`,
+		`ccxt_request_errors_total{exchange="binance",function="privatePostOrder",class="InsufficientFunds"} 1`,
+		`ccxt_retry_delay_seconds_sum{exchange="binance",function="publicGetDepth"} 2`,
+		`ccxt_rate_limit_remaining{exchange="binance"} 1180`,
+		`ccxt_rate_limit_limit{exchange="binance"} 1200`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %s in\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain; version=0.0.4") {
+		t.Error("content type:", w.Header().Get("Content-Type"))
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	if got := labels("path", `a"b\c`+"\n"); got != `path="a\"b\\c\n"` {
+		t.Fatal(got)
+	}
+}
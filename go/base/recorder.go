@@ -0,0 +1,309 @@
+package base
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// RecorderMode selects whether a Recorder talks to the network
+type RecorderMode int
+
+const (
+	// ReplayMode answers every request from the cassette and never touches the network
+	ReplayMode RecorderMode = iota
+	// RecordMode sends requests to the real exchange and records them
+	RecordMode
+)
+
+// RecorderModeFromEnv returns RecordMode if CCXT_RECORD is set, ReplayMode otherwise
+func RecorderModeFromEnv() RecorderMode {
+	if os.Getenv("CCXT_RECORD") != "" {
+		return RecordMode
+	}
+	return ReplayMode
+}
+
+// DefaultVolatileParams are query and body keys that change on every call,
+// they are dropped before requests are matched or written to a cassette
+var DefaultVolatileParams = []string{
+	"timestamp", "recvWindow", "signature", // binance
+	"Timestamp", "Signature", "AccessKeyId", "SignatureMethod", "SignatureVersion", // huobipro
+	"time",      // bitmax
+	"clientOid", // kucoin generates a uuid per order
+}
+
+// DefaultSensitiveHeaders are substrings of header names whose values are
+// redacted in cassettes, the match is case insensitive
+var DefaultSensitiveHeaders = []string{"key", "sign", "passphrase", "timestamp", "authorization", "cookie"}
+
+// RecordedRequest is the scrubbed form of a request
+type RecordedRequest struct {
+	Method  string            `json:"method"`
+	Url     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// RecordedResponse stores a JSON body as is so cassettes stay readable,
+// any other body goes to Body
+type RecordedResponse struct {
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Json    json.RawMessage   `json:"json,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Interaction is one request/response pair
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// Cassette is the file format of a Recorder. Comment describes where the
+// interactions come from, e.g. that a cassette was written by hand rather
+// than recorded; recording a cassette again drops it.
+type Cassette struct {
+	Comment      string         `json:"comment,omitempty"`
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// Recorder is an http.RoundTripper that records request/response pairs to a
+// cassette file or replays them offline. Install it with Exchange.SetTransport.
+//
+// Requests are matched on method, url and body after the volatile params
+// are removed. Identical requests replay their recordings in order and the
+// last one repeats once they run out.
+type Recorder struct {
+	sync.Mutex
+
+	Path string
+	Mode RecorderMode
+	// Transport performs real requests in RecordMode, defaults to http.DefaultTransport
+	Transport        http.RoundTripper
+	VolatileParams   []string
+	SensitiveHeaders []string
+
+	cassette Cassette
+	used     map[string]int
+}
+
+// NewRecorder creates a Recorder for the cassette at path. In ReplayMode
+// the cassette must exist, in RecordMode it is rewritten by Save.
+func NewRecorder(path string, mode RecorderMode) (*Recorder, error) {
+	r := &Recorder{
+		Path:             path,
+		Mode:             mode,
+		VolatileParams:   DefaultVolatileParams,
+		SensitiveHeaders: DefaultSensitiveHeaders,
+		used:             map[string]int{},
+	}
+	if mode == ReplayMode {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err = json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("cassette %s: %v", path, err)
+		}
+	}
+	return r, nil
+}
+
+// Interactions returns the recorded pairs
+func (r *Recorder) Interactions() []*Interaction {
+	r.Lock()
+	defer r.Unlock()
+	return append([]*Interaction(nil), r.cassette.Interactions...)
+}
+
+// Save writes the cassette, it is a no-op in ReplayMode
+func (r *Recorder) Save() error {
+	if r.Mode != RecordMode {
+		return nil
+	}
+	r.Lock()
+	defer r.Unlock()
+	data, err := json.MarshalIndent(&r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(r.Path), 0755); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(r.Path, append(data, '\n'), 0644)
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	recorded := RecordedRequest{
+		Method:  req.Method,
+		Url:     r.ScrubUrl(req.URL.String()),
+		Headers: r.scrubHeaders(req.Header),
+		Body:    r.ScrubBody(string(body)),
+	}
+
+	if r.Mode == RecordMode {
+		return r.record(req, recorded)
+	}
+	return r.replay(req, recorded)
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	transport := r.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	response := RecordedResponse{
+		Status:  resp.StatusCode,
+		Headers: map[string]string{},
+	}
+	for k := range resp.Header {
+		response.Headers[k] = resp.Header.Get(k)
+	}
+	if len(respBody) > 0 && json.Valid(respBody) {
+		response.Json = json.RawMessage(respBody)
+	} else {
+		response.Body = string(respBody)
+	}
+	r.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, &Interaction{Request: recorded, Response: response})
+	r.Unlock()
+	return r.response(req, &response), nil
+}
+
+func (r *Recorder) replay(req *http.Request, recorded RecordedRequest) (*http.Response, error) {
+	r.Lock()
+	defer r.Unlock()
+	key := recordedKey(&recorded)
+	var matches []*Interaction
+	for _, one := range r.cassette.Interactions {
+		if recordedKey(&one.Request) == key {
+			matches = append(matches, one)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("cassette %s has no interaction for %s", r.Path, key)
+	}
+	idx := r.used[key]
+	if idx >= len(matches) {
+		idx = len(matches) - 1
+	}
+	r.used[key]++
+	return r.response(req, &matches[idx].Response), nil
+}
+
+func (r *Recorder) response(req *http.Request, recorded *RecordedResponse) *http.Response {
+	body := []byte(recorded.Body)
+	if len(recorded.Json) > 0 {
+		body = recorded.Json
+	}
+	header := http.Header{}
+	for k, v := range recorded.Headers {
+		header.Set(k, v)
+	}
+	return &http.Response{
+		StatusCode:    recorded.Status,
+		Status:        fmt.Sprintf("%d %s", recorded.Status, http.StatusText(recorded.Status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          ioutil.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func recordedKey(r *RecordedRequest) string {
+	return strings.TrimSpace(r.Method + " " + r.Url + " " + r.Body)
+}
+
+func (r *Recorder) volatile(key string) bool {
+	for _, k := range r.VolatileParams {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Recorder) scrubValues(values url.Values) url.Values {
+	for k := range values {
+		if r.volatile(k) {
+			delete(values, k)
+		}
+	}
+	return values
+}
+
+// ScrubUrl drops the volatile query params and sorts the rest
+func (r *Recorder) ScrubUrl(rawUrl string) string {
+	u, err := url.Parse(rawUrl)
+	if err != nil {
+		return rawUrl
+	}
+	u.RawQuery = r.scrubValues(u.Query()).Encode()
+	return u.String()
+}
+
+// ScrubBody drops the volatile keys of a JSON object or urlencoded body
+func (r *Recorder) ScrubBody(body string) string {
+	if body == "" {
+		return body
+	}
+	var obj map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &obj); err == nil {
+		for k := range obj {
+			if r.volatile(k) {
+				delete(obj, k)
+			}
+		}
+		out, _ := json.Marshal(obj)
+		return string(out)
+	}
+	if values, err := url.ParseQuery(body); err == nil && strings.Contains(body, "=") {
+		return r.scrubValues(values).Encode()
+	}
+	return body
+}
+
+func (r *Recorder) scrubHeaders(header http.Header) map[string]string {
+	result := map[string]string{}
+	for k := range header {
+		v := header.Get(k)
+		lower := strings.ToLower(k)
+		for _, sensitive := range r.SensitiveHeaders {
+			if strings.Contains(lower, sensitive) {
+				v = "REDACTED"
+				break
+			}
+		}
+		result[k] = v
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package base
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy makes Request retry transient failures, i.e. any NetworkError
+// such as RequestTimeout, DDoSProtection or ExchangeNotAvailable. Every
+// attempt is signed again, so timestamps and nonces stay fresh.
+//
+// GETs are always retried. Other methods are only retried when they carry a
+// client order id, which lets the exchange reject the repeat instead of
+// placing the order twice, so pass clientOrderId to CreateOrder to make it
+// safe to retry.
+type RetryPolicy struct {
+	// MaxAttempts counts the first try, 0 or 1 disables retries
+	MaxAttempts int `json:"maxAttempts"`
+	// Backoff is the delay before the first retry, doubled for each one after
+	Backoff time.Duration `json:"backoff"`
+	// MaxBackoff caps the delay, 0 means no cap
+	MaxBackoff time.Duration `json:"maxBackoff"`
+	// Jitter spreads each delay by up to this fraction either way, e.g. 0.2
+	Jitter float64 `json:"jitter"`
+}
+
+// clientOrderIdKeys are the request parameters the exchanges deduplicate
+// orders by, overridden per exchange with options.clientOrderIdKeys
+var clientOrderIdKeys = []interface{}{"clientOrderId", "newClientOrderId", "origClientOrderId", "client_oid", "client-order-id", "clientOid"}
+
+// parseRetryAfter reads a Retry-After header in seconds or as an HTTP date
+func parseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return 0
+}
+
+// backoff returns the delay before retry number attempt, counting from 1
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	delay := float64(p.Backoff) * math.Pow(2, float64(attempt-1))
+	if p.MaxBackoff > 0 {
+		delay = math.Min(delay, float64(p.MaxBackoff))
+	}
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// idempotent reports whether a request can be sent again without acting twice
+func (self *Exchange) idempotent(method string, params map[string]interface{}) bool {
+	if method == "GET" {
+		return true
+	}
+	keys := self.SafeValue(self.Options, "clientOrderIdKeys", clientOrderIdKeys)
+	for _, key := range keys.([]interface{}) {
+		if id, ok := params[key.(string)]; ok && id != nil && id != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// retryDelay decides whether the failed attempt should be retried and after
+// how long
+func (self *Exchange) retryDelay(err error, attempt int, method string, params map[string]interface{}) (time.Duration, bool) {
+	policy := self.Retry
+	if attempt >= policy.MaxAttempts || !errors.Is(err, NetworkError) || !self.idempotent(method, params) {
+		return 0, false
+	}
+	delay := policy.backoff(attempt)
+	var e *Error
+	if errors.As(err, &e) && e.RetryAfter > delay {
+		delay = e.RetryAfter
+	}
+	return delay, true
+}
@@ -0,0 +1,95 @@
+package base
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// retryExchange sends every request unsigned to a test server
+type retryExchange struct {
+	Exchange
+	url string
+}
+
+func (self *retryExchange) Sign(path string, api string, method string, params map[string]interface{}, headers interface{}, body interface{}) interface{} {
+	return map[string]interface{}{"url": self.url + "/" + path, "method": method, "headers": map[string]interface{}{}, "body": nil}
+}
+
+// newRetryExchange answers with the given statuses in turn, then 200
+func newRetryExchange(t *testing.T, policy RetryPolicy, header http.Header, statuses ...int) (*retryExchange, *int) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		status := 200
+		if calls <= len(statuses) {
+			status = statuses[calls-1]
+			for k, v := range header {
+				w.Header()[k] = v
+			}
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{"ok":true}`))
+	}))
+	t.Cleanup(server.Close)
+	ex := &retryExchange{url: server.URL}
+	if err := ex.Init(&ExchangeConfig{Retry: policy}); err != nil {
+		t.Fatal(err)
+	}
+	ex.Child = ex
+	return ex, &calls
+}
+
+func (self *retryExchange) call(method string, params map[string]interface{}) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.Request("ping", "public", method, params, nil, nil)
+	return
+}
+
+func TestRetryGet(t *testing.T) {
+	ex, calls := newRetryExchange(t, RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond, Jitter: 0.5}, nil, 503, 503)
+	if err := ex.call("GET", nil); err != nil || *calls != 3 {
+		t.Fatal("calls:", *calls, err)
+	}
+
+	ex, calls = newRetryExchange(t, RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}, nil, 503, 503)
+	if err := ex.call("GET", nil); !errors.Is(err, ExchangeNotAvailable) || *calls != 2 {
+		t.Fatal("attempts exhausted:", *calls, err)
+	}
+}
+
+func TestRetryMutatingOnlyWithClientOrderId(t *testing.T) {
+	policy := RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond}
+	ex, calls := newRetryExchange(t, policy, nil, 503)
+	if err := ex.call("POST", map[string]interface{}{"symbol": "BTCUSDT"}); !errors.Is(err, ExchangeNotAvailable) || *calls != 1 {
+		t.Fatal("POST without client order id:", *calls, err)
+	}
+
+	ex, calls = newRetryExchange(t, policy, nil, 503)
+	if err := ex.call("POST", map[string]interface{}{"symbol": "BTCUSDT", "newClientOrderId": "x1"}); err != nil || *calls != 2 {
+		t.Fatal("POST with client order id:", *calls, err)
+	}
+}
+
+func TestRetryHonorsRetryAfter(t *testing.T) {
+	ex, calls := newRetryExchange(t, RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}, http.Header{"Retry-After": {"1"}}, 429)
+	start := time.Now()
+	if err := ex.call("GET", nil); err != nil || *calls != 2 {
+		t.Fatal("calls:", *calls, err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatal("retried after", elapsed)
+	}
+
+	now := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	header := http.Header{"Retry-After": {now.Add(30 * time.Second).Format(http.TimeFormat)}}
+	if delay := parseRetryAfter(header, now); delay != 30*time.Second {
+		t.Fatal("http date:", delay)
+	}
+}
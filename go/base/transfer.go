@@ -0,0 +1,72 @@
+package base
+
+// TransferEntry is a move of funds between two accounts of the same user,
+// e.g. from spot to margin
+type TransferEntry struct {
+	Id       string  `json:"id"`
+	Currency string  `json:"currency"`
+	Amount   float64 `json:"amount"`
+	// FromAccount and ToAccount are unified account types, see AccountId
+	FromAccount string `json:"fromAccount"`
+	ToAccount   string `json:"toAccount"`
+	// Status is "pending", "ok" or "failed"
+	Status    string      `json:"status"`
+	Timestamp int64       `json:"timestamp"`
+	Datetime  string      `json:"datetime"`
+	Info      interface{} `json:"info"`
+}
+
+// AccountId returns the exchange's id of a unified account type such as
+// "spot", "margin", "futures", "swap" or "funding", from
+// options.accountsByType. Other names are passed through as exchange ids.
+func (self *Exchange) AccountId(account string) string {
+	return self.SafeString(self.SafeValue(self.Options, "accountsByType", nil), account, account)
+}
+
+// AccountType is the reverse of AccountId
+func (self *Exchange) AccountType(id string) string {
+	accountsByType, _ := self.SafeValue(self.Options, "accountsByType", nil).(map[string]interface{})
+	for account := range accountsByType {
+		if self.SafeString(accountsByType, account, "") == id {
+			return account
+		}
+	}
+	return id
+}
+
+// Transfer moves amount of code from one account type to another, e.g.
+// Transfer("USDT", 100, "spot", "futures", nil)
+func (self *Exchange) Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (*TransferEntry, error) {
+	return nil, TypedError("NotSupported", self.Id+" Transfer not supported yet")
+}
+
+// FetchTransfers returns the transfers of code between account types made
+// from since on, at most limit of them, oldest first
+func (self *Exchange) FetchTransfers(code string, since int64, limit int64, params map[string]interface{}) ([]*TransferEntry, error) {
+	return nil, TypedError("NotSupported", self.Id+" FetchTransfers not supported yet")
+}
+
+// SelectAccountType returns the unified account type of a call, the
+// "accountType" param, else ExchangeConfig.AccountType, else defaultType,
+// and removes the param
+func (self *Exchange) SelectAccountType(params map[string]interface{}, defaultType string) string {
+	accountType := self.SafeString(params, "accountType", "")
+	self.Omit(params, "accountType")
+	if accountType == "" {
+		accountType = self.ExchangeConfig.AccountType
+	}
+	if accountType == "" {
+		accountType = defaultType
+	}
+	return accountType
+}
+
+// FilterTransfers sorts transfers oldest first and keeps those from since
+// on, at most limit of them
+func (self *Exchange) FilterTransfers(transfers []*TransferEntry, since int64, limit int64) []*TransferEntry {
+	result := []*TransferEntry{}
+	for _, i := range FilterSince(len(transfers), func(i int) int64 { return transfers[i].Timestamp }, since, limit) {
+		result = append(result, transfers[i])
+	}
+	return result
+}
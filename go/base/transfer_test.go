@@ -0,0 +1,14 @@
+package base
+
+import "testing"
+
+func TestAccountId(t *testing.T) {
+	ex := &Exchange{}
+	ex.Options = map[string]interface{}{"accountsByType": map[string]interface{}{"spot": "1", "swap": "9"}}
+	if ex.AccountId("swap") != "9" || ex.AccountId("7") != "7" {
+		t.Error("AccountId:", ex.AccountId("swap"), ex.AccountId("7"))
+	}
+	if ex.AccountType("1") != "spot" || ex.AccountType("7") != "7" {
+		t.Error("AccountType:", ex.AccountType("1"), ex.AccountType("7"))
+	}
+}
@@ -15,7 +15,10 @@ type Binance struct {
 
 func New(config *ExchangeConfig) (ex *Binance, err error) {
 	ex = new(Binance)
-	err = ex.Init(config)
+	if err = ex.Init(config); err != nil {
+		ex = nil
+		return
+	}
 	ex.Child = ex
 
 	err = ex.InitDescribe()
@@ -54,7 +57,18 @@ func (self *Binance) Describe() []byte {
         "fetchFundingFees": true,
         "fetchDeposits": true,
         "fetchWithdrawals": true,
-        "fetchTransactions": false,
+        "fetchTransactions": true,
+        "transfer": true,
+        "fetchTransfers": true,
+        "borrow": true,
+        "repay": true,
+        "fetchBorrowRate": true,
+        "fetchBorrowInterest": true,
+        "fetchMaxBorrowable": true,
+        "fetchPositions": true,
+        "fetchLeverage": true,
+        "setLeverage": true,
+        "setMarginMode": true,
         "fetchTradingFee": true,
         "fetchTradingFees": true,
         "cancelAllOrders": true
@@ -119,6 +133,7 @@ func (self *Binance) Describe() []byte {
                 "margin/account",
                 "margin/transfer",
                 "margin/interestHistory",
+                "margin/interestRateHistory",
                 "margin/forceLiquidationRec",
                 "margin/order",
                 "margin/openOrders",
@@ -126,6 +141,7 @@ func (self *Binance) Describe() []byte {
                 "margin/myTrades",
                 "margin/maxBorrowable",
                 "margin/maxTransferable",
+                "margin/isolated/account",
                 "futures/transfer",
                 "capital/config/getall",
                 "capital/deposit/address",
@@ -339,34 +355,64 @@ func (self *Binance) Describe() []byte {
             "market": "FULL",
             "limit": "RESULT"
         },
-        "quoteOrderQty": true
+        "quoteOrderQty": true,
+        "keyTypes": ["rsa", "ed25519"],
+        "accountsByType": {
+            "spot": "spot",
+            "margin": "margin",
+            "futures": "futures",
+            "delivery": "delivery"
+        },
+        "rateLimitHeaders": {
+            "used": "X-MBX-USED-WEIGHT-1M",
+            "limit": 1200
+        }
     },
     "exceptions": {
-        "API key does not exist": "AuthenticationError",
-        "Order would trigger immediately.": "InvalidOrder",
-        "Account has insufficient balance for requested action.": "InsufficientFunds",
-        "Rest API trading is not enabled.": "ExchangeNotAvailable",
-        "You don't have permission.": "PermissionDenied",
-        "Market is closed.": "ExchangeNotAvailable",
-        "-1000": "ExchangeNotAvailable",
-        "-1003": "RateLimitExceeded",
-        "-1013": "InvalidOrder",
-        "-1021": "InvalidNonce",
-        "-1022": "AuthenticationError",
-        "-1100": "InvalidOrder",
-        "-1104": "ExchangeError",
-        "-1128": "ExchangeError",
-        "-2010": "ExchangeError",
-        "-2011": "OrderNotFound",
-        "-2013": "OrderNotFound",
-        "-2014": "AuthenticationError",
-        "-2015": "AuthenticationError",
-        "-3008": "InsufficientFunds",
-        "-3010": "ExchangeError"
+        "exact": {
+            "API key does not exist": "AuthenticationError",
+            "Order would trigger immediately.": "InvalidOrder",
+            "Account has insufficient balance for requested action.": "InsufficientFunds",
+            "Rest API trading is not enabled.": "ExchangeNotAvailable",
+            "You don't have permission.": "PermissionDenied",
+            "Market is closed.": "ExchangeNotAvailable",
+            "-1000": "ExchangeNotAvailable",
+            "-1003": "RateLimitExceeded",
+            "-1013": "InvalidOrder",
+            "-1021": "InvalidNonce",
+            "-1022": "AuthenticationError",
+            "-1100": "InvalidOrder",
+            "-1104": "ExchangeError",
+            "-1128": "ExchangeError",
+            "-2010": "ExchangeError",
+            "-2011": "OrderNotFound",
+            "-2013": "OrderNotFound",
+            "-2014": "AuthenticationError",
+            "-2015": "AuthenticationError",
+            "-3006": "InsufficientFunds",
+            "-3008": "InsufficientFunds",
+            "-3010": "ExchangeError"
+        },
+        "broad": {
+            "address is invalid": "InvalidAddress",
+            "Price * QTY is zero or less": "InvalidOrder",
+            "LOT_SIZE": "InvalidOrder",
+            "PRICE_FILTER": "InvalidOrder"
+        }
     }
 }`)
 }
 
+func (self *Binance) FetchTime(params map[string]interface{}) (timestamp int64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	response := self.ApiFunc("publicGetTime", params, nil, nil)
+	return self.SafeInteger(response, "serverTime", 0), nil
+}
+
 func (self *Binance) FetchMarkets(params map[string]interface{}) []interface{} {
 	defaultType := self.SafeString2(self.Options, "fetchMarkets", "defaultType", "spot")
 	typ := self.SafeString(params, "type", defaultType)
@@ -376,10 +422,6 @@ func (self *Binance) FetchMarkets(params map[string]interface{}) []interface{} {
 	}
 	method := self.IfThenElse(self.ToBool(typ == "future"), "fapiPublicGetExchangeInfo", "publicGetExchangeInfo").(string)
 	response := self.ApiFunc(method, query, nil, nil)
-	if self.ToBool(self.Member(self.Options, "adjustForTimeDifference")) {
-		// TODO, false
-		//self.LoadTimeDifference()
-	}
 	markets := self.SafeValue(response, "symbols", nil)
 	result := []interface{}{}
 	for i := 0; i < self.Length(markets); i++ {
@@ -397,10 +439,10 @@ func (self *Binance) FetchMarkets(params map[string]interface{}) []interface{} {
 		filters := self.SafeValue(market, "filters", []interface{}{})
 		filtersByType := self.IndexBy(filters, "filterType")
 		precision := map[string]interface{}{
-			"base":   self.SafeInteger(market, "baseAssetPrecision", 0),
-			"quote":  self.SafeInteger(market, "quotePrecision", 0),
-			"amount": self.SafeInteger(market, "baseAssetPrecision", 0),
-			"price":  self.SafeInteger(market, "quotePrecision", 0),
+			"base":   int(self.SafeInteger(market, "baseAssetPrecision", 0)),
+			"quote":  int(self.SafeInteger(market, "quotePrecision", 0)),
+			"amount": int(self.SafeInteger(market, "baseAssetPrecision", 0)),
+			"price":  int(self.SafeInteger(market, "quotePrecision", 0)),
 		}
 		status := self.SafeString(market, "status", "")
 		active := status == "TRADING"
@@ -479,15 +521,16 @@ func (self *Binance) FetchBalance(params map[string]interface{}) (balanceResult
 		}
 	}()
 	self.LoadMarkets()
-	defaultType := self.SafeString2(self.Options, "fetchBalance", "defaultType", "spot")
-	typ := self.SafeString(params, "type", defaultType)
+	typ, query := self.ApiType("fetchBalance", "spot", params)
 	method := "privateGetAccount"
 	if self.ToBool(typ == "future") {
 		method = "fapiPrivateGetAccount"
 	} else if self.ToBool(typ == "margin") {
 		method = "sapiGetMarginAccount"
 	}
-	query := self.Omit(params, "type")
+	if self.SafeString(query, "isIsolated", "") == "TRUE" {
+		return self.ParseIsolatedBalance(self.ApiFunc("sapiGetMarginIsolatedAccount", self.Omit(query, "isIsolated"), nil, nil)), nil
+	}
 	response := self.ApiFunc(method, query, nil, nil)
 	result := map[string]interface{}{
 		"info": response,
@@ -501,6 +544,10 @@ func (self *Binance) FetchBalance(params map[string]interface{}) (balanceResult
 			account := self.Account()
 			self.SetValue(account, "free", self.SafeFloat(balance, "free", 0))
 			self.SetValue(account, "used", self.SafeFloat(balance, "locked", 0))
+			if self.ToBool(typ == "margin") {
+				self.SetValue(account, "debt", self.SafeFloat(balance, "borrowed", 0))
+				self.SetValue(account, "interest", self.SafeFloat(balance, "interest", 0))
+			}
 			self.SetValue(result, code, account)
 		}
 	} else {
@@ -518,6 +565,54 @@ func (self *Binance) FetchBalance(params map[string]interface{}) (balanceResult
 	return self.ParseBalance(result), nil
 }
 
+// ApiType returns the api a call goes to, spot, margin or future: the type
+// param, else the unified account type, else the defaultType option of
+// method. The returned params ask for isIsolated on an isolated margin
+// account
+func (self *Binance) ApiType(method string, defaultType string, params map[string]interface{}) (string, map[string]interface{}) {
+	typ := self.SafeString2(self.Options, method, "defaultType", defaultType)
+	query := self.Extend(params).(map[string]interface{})
+	switch accountType := self.SelectAccountType(query, ""); accountType {
+	case "":
+	case "spot", "margin":
+		typ = accountType
+	case "isolated-margin":
+		typ = "margin"
+		self.SetValue(query, "isIsolated", "TRUE")
+	case "futures":
+		typ = "future"
+	default:
+		self.RaiseException("NotSupported", self.Id+" has no "+accountType+" account")
+	}
+	typ = self.SafeString(query, "type", typ)
+	return typ, self.Omit(query, "type")
+}
+
+// ParseIsolatedBalance sums the base and quote assets of every isolated
+// margin pair
+func (self *Binance) ParseIsolatedBalance(response interface{}) *Account {
+	result := map[string]interface{}{
+		"info": response,
+	}
+	pairs := self.SafeValue(response, "assets", []interface{}{})
+	for i := 0; i < self.Length(pairs); i++ {
+		pair := self.Member(pairs, i)
+		for _, key := range []string{"baseAsset", "quoteAsset"} {
+			balance := self.SafeValue(pair, key, nil)
+			code := self.SafeCurrencyCode(self.SafeString(balance, "asset", ""))
+			account := self.SafeValue(result, code, nil)
+			if account == nil {
+				account = self.Account()
+				self.SetValue(result, code, account)
+			}
+			for field, value := range map[string]string{"free": "free", "used": "locked", "debt": "borrowed", "interest": "interest"} {
+				self.SetValue(account, field, self.SafeFloat(account, field, 0)+self.SafeFloat(balance, value, 0))
+			}
+		}
+	}
+	return self.ParseBalance(result)
+}
+
 func (self *Binance) FetchOrderBook(symbol string, limit int64, params map[string]interface{}) (orderBook *OrderBook, err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -668,10 +763,9 @@ func (self *Binance) CreateOrder(symbol string, typ string, side string, amount
 	}()
 	self.LoadMarkets()
 	market := self.Market(symbol)
-	defaultType := self.SafeString2(self.Options, "createOrder", "defaultType", market.Type)
-	orderType := self.SafeString(params, "type", defaultType)
+	orderType, params := self.ApiType("createOrder", market.Type, params)
 	clientOrderId := self.SafeString2(params, "newClientOrderId", "clientOrderId", "")
-	params = self.Omit(params, []interface{}{"type", "newClientOrderId", "clientOrderId"})
+	params = self.Omit(params, []interface{}{"newClientOrderId", "clientOrderId"})
 	method := "privatePostOrder"
 	if self.ToBool(orderType == "future") {
 		method = "fapiPrivatePostOrder"
@@ -687,7 +781,7 @@ func (self *Binance) CreateOrder(symbol string, typ string, side string, amount
 	}
 	uppercaseType := strings.ToUpper(typ)
 	validOrderTypes := self.SafeValue(self.Member(market, "info"), "orderTypes", nil)
-	if self.ToBool(!self.ToBool(self.InArray(uppercaseType, validOrderTypes.([]string)))) {
+	if self.ToBool(!self.ToBool(self.InArray(uppercaseType, validOrderTypes))) {
 		self.RaiseException("InvalidOrder", self.Id+" "+typ+" is not a valid order type in "+market.Type+" market "+symbol)
 	}
 	request := map[string]interface{}{
@@ -788,8 +882,7 @@ func (self *Binance) FetchOrder(id string, symbol string, params map[string]inte
 	}
 	self.LoadMarkets()
 	market := self.Market(symbol)
-	defaultType := self.SafeString2(self.Options, "fetchOrder", "defaultType", market.Type)
-	typ := self.SafeString(params, "type", defaultType)
+	typ, params := self.ApiType("fetchOrder", market.Type, params)
 	method := "privateGetOrder"
 	if self.ToBool(typ == "future") {
 		method = "fapiPrivateGetOrder"
@@ -805,7 +898,7 @@ func (self *Binance) FetchOrder(id string, symbol string, params map[string]inte
 	} else {
 		self.SetValue(request, "orderId", ToInteger(id))
 	}
-	query := self.Omit(params, []interface{}{"type", "clientOrderId", "origClientOrderId"})
+	query := self.Omit(params, []interface{}{"clientOrderId", "origClientOrderId"})
 	response := self.ApiFunc(method, self.Extend(request, query), nil, nil)
 	return self.ToOrder(self.ParseOrder(response, market)), nil
 }
@@ -818,24 +911,20 @@ func (self *Binance) FetchOpenOrders(symbol string, since int64, limit int64, pa
 	}()
 	self.LoadMarkets()
 	var market *Market
-	var query interface{}
-	var typ interface{}
+	var query map[string]interface{}
+	var typ string
 	request := map[string]interface{}{}
 	if self.ToBool(!self.TestNil(symbol)) {
 		market = self.Market(symbol)
 		self.SetValue(request, "symbol", self.Member(market, "id"))
-		defaultType := self.SafeString2(self.Options, "fetchOpenOrders", "defaultType", market.Type)
-		typ = self.SafeString(params, "type", defaultType)
-		query = self.Omit(params, "type")
+		typ, query = self.ApiType("fetchOpenOrders", market.Type, params)
 	} else if self.ToBool(self.Member(self.Options, "warnOnFetchOpenOrdersWithoutSymbol")) {
 		symbols := self.Symbols
 		numSymbols := self.Length(symbols)
 		fetchOpenOrdersRateLimit := ToInteger(numSymbols / 2)
 		self.RaiseException("ExchangeError", self.Id+" fetchOpenOrders WARNING: fetching open orders without specifying a symbol is rate-limited to one call per "+fmt.Sprintf("%v", fetchOpenOrdersRateLimit)+" seconds. Do not call this method frequently to avoid ban. Set "+self.Id+".options[warnOnFetchOpenOrdersWithoutSymbol] = false to suppress this warning message.")
 	} else {
-		defaultType := self.SafeString2(self.Options, "fetchOpenOrders", "defaultType", "spot")
-		typ = self.SafeString(params, "type", defaultType)
-		query = self.Omit(params, "type")
+		typ, query = self.ApiType("fetchOpenOrders", "spot", params)
 	}
 	method := "privateGetOpenOrders"
 	if self.ToBool(typ == "future") {
@@ -843,7 +932,7 @@ func (self *Binance) FetchOpenOrders(symbol string, since int64, limit int64, pa
 	} else if self.ToBool(typ == "margin") {
 		method = "sapiGetMarginOpenOrders"
 	}
-	response := self.ApiFunc(method, self.Extend(request, query), nil, nil)
+	response := self.ApiFuncReturnList(method, self.Extend(request, query), nil, nil)
 	return self.ToOrders(self.ParseOrders(response, market, since, limit)), nil
 }
 
@@ -858,8 +947,7 @@ func (self *Binance) CancelOrder(id string, symbol string, params map[string]int
 	}
 	self.LoadMarkets()
 	market := self.Market(symbol)
-	defaultType := self.SafeString2(self.Options, "fetchOpenOrders", "defaultType", market.Type)
-	typ := self.SafeString(params, "type", defaultType)
+	typ, params := self.ApiType("cancelOrder", market.Type, params)
 	origClientOrderId := self.SafeValue2(params, "origClientOrderId", "clientOrderId", "")
 	request := map[string]interface{}{
 		"symbol": self.Member(market, "id"),
@@ -875,11 +963,390 @@ func (self *Binance) CancelOrder(id string, symbol string, params map[string]int
 	} else if self.ToBool(typ == "margin") {
 		method = "sapiDeleteMarginOrder"
 	}
-	query := self.Omit(params, []interface{}{"type", "origClientOrderId", "clientOrderId"})
+	query := self.Omit(params, []interface{}{"origClientOrderId", "clientOrderId"})
 	response = self.ApiFunc(method, self.Extend(request, query), nil, nil)
 	return self.ToOrder(self.ParseOrder(response, market)), nil
 }
 
+func (self *Binance) FetchDepositAddress(code string, network string, params map[string]interface{}) (result *DepositAddress, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"coin": self.CurrencyId(code),
+	}
+	if network != "" {
+		self.SetValue(request, "network", network)
+	}
+	response := self.ApiFunc("sapiGetCapitalDepositAddress", self.Extend(request, params), nil, nil)
+	address := self.SafeString(response, "address", "")
+	tag := self.SafeString(response, "tag", "")
+	return self.ToDepositAddress(code, address, tag, network, response), nil
+}
+
+func (self *Binance) Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (result *Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.CheckAddress(address)
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"coin":    self.CurrencyId(code),
+		"address": address,
+		"amount":  self.NumberToString(amount),
+	}
+	if tag != "" {
+		self.SetValue(request, "addressTag", tag)
+	}
+	if network != "" {
+		self.SetValue(request, "network", network)
+	}
+	response := self.ApiFunc("sapiPostCapitalWithdrawApply", self.Extend(request, params), nil, nil)
+	return &Transaction{
+		Id:       self.SafeString(response, "id", ""),
+		Type:     "withdrawal",
+		Currency: code,
+		Network:  network,
+		Address:  address,
+		Tag:      tag,
+		Amount:   amount,
+		Status:   "pending",
+		Info:     response,
+	}, nil
+}
+
+func (self *Binance) FetchDeposits(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("deposit", code, since, limit, params), nil
+}
+
+func (self *Binance) FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("withdrawal", code, since, limit, params), nil
+}
+
+func (self *Binance) FetchTransactionsByType(typ string, code string, since int64, limit int64, params map[string]interface{}) []*Transaction {
+	self.LoadMarkets()
+	request := map[string]interface{}{}
+	if code != "" {
+		self.SetValue(request, "coin", self.CurrencyId(code))
+	}
+	if since > 0 {
+		self.SetValue(request, "startTime", since)
+	}
+	if limit > 0 {
+		self.SetValue(request, "limit", limit)
+	}
+	method := "sapiGetCapitalDepositHisrec"
+	if typ == "withdrawal" {
+		method = "sapiGetCapitalWithdrawHistory"
+	}
+	response := self.ApiFuncReturnList(method, self.Extend(request, params), nil, nil)
+	result := []*Transaction{}
+	for _, transaction := range response {
+		result = append(result, self.ParseTransaction(transaction, typ))
+	}
+	return self.FilterTransactions(result, code, since, limit)
+}
+
+func (self *Binance) ParseTransactionStatus(typ string, status string) string {
+	statuses := map[string]interface{}{
+		"deposit": map[string]interface{}{
+			"0": "pending",
+			"1": "ok",
+			"6": "ok", // credited, not withdrawable yet
+		},
+		"withdrawal": map[string]interface{}{
+			"0": "pending", // email sent
+			"1": "canceled",
+			"2": "pending", // awaiting approval
+			"3": "failed",  // rejected
+			"4": "pending", // processing
+			"5": "failed",
+			"6": "ok",
+		},
+	}
+	return self.SafeString(self.SafeValue(statuses, typ, nil), status, status)
+}
+
+func (self *Binance) ParseTransaction(transaction interface{}, typ string) *Transaction {
+	timestamp := self.SafeInteger(transaction, "insertTime", 0)
+	if typ == "withdrawal" {
+		timestamp = self.ParseDate(self.SafeString(transaction, "applyTime", ""))
+	}
+	return &Transaction{
+		Id:        self.SafeString(transaction, "id", ""),
+		Txid:      self.SafeString(transaction, "txId", ""),
+		Type:      typ,
+		Currency:  self.SafeCurrencyCode(self.SafeString(transaction, "coin", "")),
+		Network:   self.SafeString(transaction, "network", ""),
+		Address:   self.SafeString(transaction, "address", ""),
+		Tag:       self.SafeString(transaction, "addressTag", ""),
+		Amount:    self.SafeFloat(transaction, "amount", 0),
+		Fee:       self.SafeFloat(transaction, "transactionFee", 0),
+		Status:    self.ParseTransactionStatus(typ, self.SafeString(transaction, "status", "")),
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Info:      transaction,
+	}
+}
+
+func (self *Binance) Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (result *TransferEntry, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	// spot is one side of every transfer, futures are usdt margined and
+	// delivery coin margined
+	method := ""
+	var typ int
+	switch self.AccountId(fromAccount) + " " + self.AccountId(toAccount) {
+	case "spot margin":
+		method, typ = "sapiPostMarginTransfer", 1
+	case "margin spot":
+		method, typ = "sapiPostMarginTransfer", 2
+	case "spot futures":
+		method, typ = "sapiPostFuturesTransfer", 1
+	case "futures spot":
+		method, typ = "sapiPostFuturesTransfer", 2
+	case "spot delivery":
+		method, typ = "sapiPostFuturesTransfer", 3
+	case "delivery spot":
+		method, typ = "sapiPostFuturesTransfer", 4
+	default:
+		self.RaiseException("NotSupported", self.Id+" transfer from "+fromAccount+" to "+toAccount+" is not supported")
+	}
+	request := map[string]interface{}{
+		"asset":  self.CurrencyId(code),
+		"amount": self.NumberToString(amount),
+		"type":   typ,
+	}
+	response := self.ApiFunc(method, self.Extend(request, params), nil, nil)
+	timestamp := self.Milliseconds()
+	return &TransferEntry{
+		Id:          self.SafeString(response, "tranId", ""),
+		Currency:    code,
+		Amount:      amount,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Status:      "pending",
+		Timestamp:   timestamp,
+		Datetime:    self.Iso8601(timestamp),
+		Info:        response,
+	}, nil
+}
+
+// FetchTransfers merges the margin and the futures transfer histories,
+// binance requires a code and keeps 30 days of them by default
+func (self *Binance) FetchTransfers(code string, since int64, limit int64, params map[string]interface{}) (result []*TransferEntry, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	if code == "" {
+		self.RaiseException("ArgumentsRequired", self.Id+" fetchTransfers requires a code argument")
+	}
+	self.LoadMarkets()
+	if since <= 0 {
+		since = self.Milliseconds() - 30*24*60*60*1000
+	}
+	request := map[string]interface{}{
+		"asset":     self.CurrencyId(code),
+		"startTime": since,
+	}
+	if limit > 0 {
+		self.SetValue(request, "size", limit)
+	}
+	result = []*TransferEntry{}
+	for _, method := range []string{"sapiGetMarginTransfer", "sapiGetFuturesTransfer"} {
+		response := self.ApiFunc(method, self.Extend(request, params), nil, nil)
+		rows := self.SafeValue(response, "rows", []interface{}{})
+		for i := 0; i < self.Length(rows); i++ {
+			result = append(result, self.ParseTransfer(self.Member(rows, i)))
+		}
+	}
+	return self.FilterTransfers(result, since, limit), nil
+}
+
+func (self *Binance) ParseTransfer(transfer interface{}) *TransferEntry {
+	// margin transfers roll in or out, futures ones are numbered as in Transfer
+	accounts := map[string]interface{}{
+		"ROLL_IN":  []interface{}{"spot", "margin"},
+		"ROLL_OUT": []interface{}{"margin", "spot"},
+		"1":        []interface{}{"spot", "futures"},
+		"2":        []interface{}{"futures", "spot"},
+		"3":        []interface{}{"spot", "delivery"},
+		"4":        []interface{}{"delivery", "spot"},
+	}
+	statuses := map[string]interface{}{
+		"PENDING":   "pending",
+		"CONFIRMED": "ok",
+		"FAILED":    "failed",
+	}
+	typ := self.SafeString(transfer, "type", "")
+	fromTo := self.SafeValue(accounts, typ, []interface{}{typ, typ})
+	status := self.SafeString(transfer, "status", "")
+	timestamp := self.SafeInteger(transfer, "timestamp", 0)
+	return &TransferEntry{
+		Id:          self.SafeString(transfer, "tranId", ""),
+		Currency:    self.SafeCurrencyCode(self.SafeString(transfer, "asset", "")),
+		Amount:      self.SafeFloat(transfer, "amount", 0),
+		FromAccount: self.Member(fromTo, 0).(string),
+		ToAccount:   self.Member(fromTo, 1).(string),
+		Status:      self.SafeString(statuses, status, status),
+		Timestamp:   timestamp,
+		Datetime:    self.Iso8601(timestamp),
+		Info:        transfer,
+	}
+}
+
+// Borrow takes a cross margin loan, or an isolated one on symbol
+func (self *Binance) Borrow(code string, amount float64, symbol string, params map[string]interface{}) (result *MarginLoan, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.MarginLoan("sapiPostMarginLoan", code, amount, symbol, params), nil
+}
+
+func (self *Binance) Repay(code string, amount float64, symbol string, params map[string]interface{}) (result *MarginLoan, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.MarginLoan("sapiPostMarginRepay", code, amount, symbol, params), nil
+}
+
+func (self *Binance) MarginLoan(method string, code string, amount float64, symbol string, params map[string]interface{}) *MarginLoan {
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"asset":  self.CurrencyId(code),
+		"amount": self.NumberToString(amount),
+	}
+	if symbol != "" {
+		self.SetValue(request, "isIsolated", "TRUE")
+		self.SetValue(request, "symbol", self.MarketId(symbol))
+	}
+	response := self.ApiFunc(method, self.Extend(request, params), nil, nil)
+	timestamp := self.Milliseconds()
+	return &MarginLoan{
+		Id:        self.SafeString(response, "tranId", ""),
+		Currency:  code,
+		Amount:    amount,
+		Symbol:    symbol,
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Info:      response,
+	}
+}
+
+// FetchBorrowRate returns the latest daily cross margin rate
+func (self *Binance) FetchBorrowRate(code string, params map[string]interface{}) (result *BorrowRate, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"asset": self.CurrencyId(code),
+		"limit": 1,
+	}
+	response := self.ApiFuncReturnList("sapiGetMarginInterestRateHistory", self.Extend(request, params), nil, nil)
+	if self.Length(response) == 0 {
+		self.RaiseException("ExchangeError", self.Id+" fetchBorrowRate has no rate for "+code)
+	}
+	// newest first
+	rate := self.Member(response, 0)
+	timestamp := self.SafeInteger(rate, "timestamp", 0)
+	return &BorrowRate{
+		Currency:  code,
+		Rate:      self.SafeFloat(rate, "dailyInterestRate", 0),
+		Period:    86400000,
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Info:      rate,
+	}, nil
+}
+
+func (self *Binance) FetchBorrowInterest(code string, symbol string, since int64, limit int64, params map[string]interface{}) (result []*BorrowInterest, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{}
+	if code != "" {
+		self.SetValue(request, "asset", self.CurrencyId(code))
+	}
+	if symbol != "" {
+		self.SetValue(request, "isolatedSymbol", self.MarketId(symbol))
+	}
+	if since > 0 {
+		self.SetValue(request, "startTime", since)
+	}
+	if limit > 0 {
+		self.SetValue(request, "size", limit)
+	}
+	response := self.ApiFunc("sapiGetMarginInterestHistory", self.Extend(request, params), nil, nil)
+	rows := self.SafeValue(response, "rows", []interface{}{})
+	result = []*BorrowInterest{}
+	for i := 0; i < self.Length(rows); i++ {
+		row := self.Member(rows, i)
+		timestamp := self.SafeInteger(row, "interestAccuredTime", 0)
+		interest := &BorrowInterest{
+			Currency:  self.SafeCurrencyCode(self.SafeString(row, "asset", "")),
+			Interest:  self.SafeFloat(row, "interest", 0),
+			Rate:      self.SafeFloat(row, "interestRate", 0),
+			Amount:    self.SafeFloat(row, "principal", 0),
+			Timestamp: timestamp,
+			Datetime:  self.Iso8601(timestamp),
+			Info:      row,
+		}
+		if market, ok := self.MarketsById[self.SafeString(row, "isolatedSymbol", "")]; ok {
+			interest.Symbol = market.Symbol
+		}
+		result = append(result, interest)
+	}
+	return self.FilterBorrowInterest(result, since, limit), nil
+}
+
+func (self *Binance) FetchMaxBorrowable(code string, symbol string, params map[string]interface{}) (amount float64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"asset": self.CurrencyId(code),
+	}
+	if symbol != "" {
+		self.SetValue(request, "isolatedSymbol", self.MarketId(symbol))
+	}
+	response := self.ApiFunc("sapiGetMarginMaxBorrowable", self.Extend(request, params), nil, nil)
+	return self.SafeFloat(response, "amount", 0), nil
+}
+
 func (self *Binance) Sign(path string, api string, method string, params map[string]interface{}, headers interface{}, body interface{}) (ret interface{}) {
 	if self.ToBool(!self.ToBool(self.InMap(api, self.Member(self.Urls, "api")))) {
 		self.RaiseException("NotSupported", self.Id+" does not have a testnet/sandbox URL for "+api+" endpoints")
@@ -891,18 +1358,18 @@ func (self *Binance) Sign(path string, api string, method string, params map[str
 	}
 	userDataStream := path == "userDataStream" || path == "listenKey"
 	if self.ToBool(path == "historicalTrades") {
-		if self.ToBool(self.ApiKey) {
+		if apiKey := self.Credentials().ApiKey; self.ToBool(apiKey) {
 			headers = map[string]interface{}{
-				"X-MBX-APIKEY": self.ApiKey,
+				"X-MBX-APIKEY": apiKey,
 			}
 		} else {
 			self.RaiseException("AuthenticationError", self.Id+" historicalTrades endpoint requires `apiKey` credential")
 		}
 	} else if self.ToBool(userDataStream) {
-		if self.ToBool(self.ApiKey) {
+		if apiKey := self.Credentials().ApiKey; self.ToBool(apiKey) {
 			body = self.Urlencode(params)
 			headers = map[string]interface{}{
-				"X-MBX-APIKEY": self.ApiKey,
+				"X-MBX-APIKEY": apiKey,
 				"Content-Type": "application/x-www-form-urlencoded",
 			}
 		} else {
@@ -911,29 +1378,35 @@ func (self *Binance) Sign(path string, api string, method string, params map[str
 	}
 	if self.ToBool(api == "private" || api == "sapi" || api == "wapi" && path != "systemStatus" || api == "fapiPrivate") {
 		self.CheckRequiredCredentials()
+		credentials := self.Credentials()
 		var query string
 		if self.ToBool(api == "sapi" && path == "asset/dust") {
 			query = self.UrlencodeWithArrayRepeat(self.Extend(map[string]interface{}{
-				"timestamp":  self.Nonce(),
+				"timestamp":  self.ServerMilliseconds(),
 				"recvWindow": self.Member(self.Options, "recvWindow"),
 			}, params))
 		} else {
 		/*else if self.ToBool(path == "batchOrders") {
 			query = self.Rawencode(self.Extend(map[string]interface{}{
-				"timestamp":  self.Nonce(),
+				"timestamp":  self.ServerMilliseconds(),
 				"recvWindow": self.Member(self.Options, "recvWindow"),
 			}, params))
 		}
 		*/
 			query = self.Urlencode(self.Extend(map[string]interface{}{
-				"timestamp":  self.Nonce(),
+				"timestamp":  self.ServerMilliseconds(),
 				"recvWindow": self.Member(self.Options, "recvWindow"),
 			}, params))
 		}
-		signature := self.Hmac(self.Encode(query), self.Encode(self.Secret), "sha256", "hex")
-		query += "&" + "signature=" + signature
+		if self.Asymmetric() {
+			signature := self.Signature(self.Encode(query), credentials.Secret, "sha256", "base64")
+			query += "&" + self.Urlencode(map[string]interface{}{"signature": signature})
+		} else {
+			signature := self.Hmac(self.Encode(query), self.Encode(credentials.Secret), "sha256", "hex")
+			query += "&" + "signature=" + signature
+		}
 		headers = map[string]interface{}{
-			"X-MBX-APIKEY": self.ApiKey,
+			"X-MBX-APIKEY": credentials.ApiKey,
 		}
 		if self.ToBool(method == "GET" || method == "DELETE" || api == "wapi") {
 			url += "?" + query
@@ -957,20 +1430,6 @@ func (self *Binance) Sign(path string, api string, method string, params map[str
 }
 
 func (self *Binance) HandleErrors(httpCode int64, reason string, url string, method string, headers interface{}, body string, response interface{}, requestHeaders interface{}, requestBody interface{}) {
-	if self.ToBool(httpCode == 418 || httpCode == 429) {
-		self.RaiseException("DDoSProtection", self.Id+" "+fmt.Sprintf("%v", httpCode)+" "+reason+" "+body)
-	}
-	if self.ToBool(httpCode >= 400) {
-		if strings.Contains(body, "Price * QTY is zero or less") {
-			self.RaiseException("InvalidOrder", self.Id+" order cost = amount * price is zero or less "+body)
-		}
-		if strings.Contains(body, "LOT_SIZE") {
-			self.RaiseException("InvalidOrder", self.Id+" order amount should be evenly divisible by lot size "+body)
-		}
-		if strings.Contains(body, "PRICE_FILTER") {
-			self.RaiseException("InvalidOrder", self.Id+" order price is invalid, i.e. exceeds allowed price precision, exceeds min price or max price limits or is invalid float value in general, use this.priceToPrecision (symbol, amount) "+body)
-		}
-	}
 	if self.ToBool(self.TestNil(response)) {
 		return
 	}
@@ -986,7 +1445,7 @@ func (self *Binance) HandleErrors(httpCode int64, reason string, url string, met
 	}
 	message := self.SafeString(response, "msg", "")
 	if self.ToBool(!self.TestNil(message)) {
-		self.ThrowExactlyMatchedException(self.Exceptions, message, self.Id+" "+message)
+		self.ThrowMatchedException(self.Id+" "+message, message)
 	}
 	errorStr := self.SafeString(response, "code", "")
 	if errorStr != "" {
@@ -997,10 +1456,123 @@ func (self *Binance) HandleErrors(httpCode int64, reason string, url string, met
 			self.RaiseException("DDoSProtection", self.Id+" temporary banned: "+body)
 		}
 		feedback := self.Id + " " + body
-		self.ThrowExactlyMatchedException(self.Exceptions, errorStr, feedback)
+		self.ThrowMatchedException(feedback, errorStr)
 		self.RaiseException("ExchangeError", feedback)
 	}
 	if self.ToBool(!self.ToBool(success)) {
 		self.RaiseException("ExchangeError", self.Id+" "+body)
 	}
-}
\ No newline at end of file
+}
+
+// FetchPositions returns the USDⓈ-M futures positions
+func (self *Binance) FetchPositions(symbols []string, params map[string]interface{}) (result []*Position, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	response := self.ApiFuncReturnList("fapiPrivateGetPositionRisk", params, nil, nil)
+	result = []*Position{}
+	for i := 0; i < self.Length(response); i++ {
+		result = append(result, self.ParsePosition(self.Member(response, i)))
+	}
+	return self.FilterPositions(result, symbols), nil
+}
+
+// FetchLeverage reads the usdt futures leverage of symbol from its
+// positionRisk, which lists it even without an open position
+func (self *Binance) FetchLeverage(symbol string, params map[string]interface{}) (result *Leverage, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	response := self.ApiFuncReturnList("fapiPrivateGetPositionRisk", self.Extend(map[string]interface{}{
+		"symbol": self.MarketId(symbol),
+	}, params), nil, nil)
+	if self.Length(response) == 0 {
+		self.RaiseException("BadSymbol", self.Id+" has no usdt futures on "+symbol)
+	}
+	position := self.Member(response, 0)
+	return &Leverage{
+		Symbol:        symbol,
+		MarginMode:    self.SafeStringLower(position, "marginType", ""),
+		LongLeverage:  self.SafeFloat(position, "leverage", 0),
+		ShortLeverage: self.SafeFloat(position, "leverage", 0),
+		Info:          response,
+	}, nil
+}
+
+// SetLeverage sets the usdt futures leverage of symbol, binance has one for
+// both sides and only takes whole numbers
+func (self *Binance) SetLeverage(symbol string, leverage float64, params map[string]interface{}) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	if leverage != math.Trunc(leverage) {
+		self.RaiseException("BadRequest", self.Id+" leverage must be a whole number, not "+self.NumberToString(leverage))
+	}
+	self.LoadMarkets()
+	self.ApiFunc("fapiPrivatePostLeverage", self.Extend(map[string]interface{}{
+		"symbol":   self.MarketId(symbol),
+		"leverage": int64(leverage),
+	}, params), nil, nil)
+	return nil
+}
+
+// SetMarginMode sets the usdt futures margin type of symbol
+func (self *Binance) SetMarginMode(symbol string, marginMode string, params map[string]interface{}) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	types := map[string]interface{}{
+		"cross":    "CROSSED",
+		"isolated": "ISOLATED",
+	}
+	marginType := self.SafeString(types, marginMode, "")
+	if marginType == "" {
+		self.RaiseException("BadRequest", self.Id+" margin mode must be cross or isolated, not "+marginMode)
+	}
+	self.ApiFunc("fapiPrivatePostMarginType", self.Extend(map[string]interface{}{
+		"symbol":     self.MarketId(symbol),
+		"marginType": marginType,
+	}, params), nil, nil)
+	return nil
+}
+
+// ParsePosition parses a positionRisk entry, whose side is its
+// positionSide in hedge mode and the sign of positionAmt in one-way mode
+func (self *Binance) ParsePosition(position interface{}) *Position {
+	marketId := self.SafeString(position, "symbol", "")
+	symbol := marketId
+	if market, ok := self.MarketsById[marketId]; ok {
+		symbol = market.Symbol
+	}
+	amount := self.SafeFloat(position, "positionAmt", 0)
+	side := self.SafeStringLower(position, "positionSide", "")
+	if side != "long" && side != "short" {
+		side = self.IfThenElse(amount < 0, "short", "long").(string)
+	}
+	timestamp := self.SafeInteger(position, "updateTime", 0)
+	return &Position{
+		Symbol:           symbol,
+		Side:             side,
+		Contracts:        math.Abs(amount),
+		EntryPrice:       self.SafeFloat(position, "entryPrice", 0),
+		MarkPrice:        self.SafeFloat(position, "markPrice", 0),
+		UnrealizedPnl:    self.SafeFloat(position, "unRealizedProfit", 0),
+		LiquidationPrice: self.SafeFloat(position, "liquidationPrice", 0),
+		MarginMode:       self.SafeStringLower(position, "marginType", ""),
+		Leverage:         self.SafeFloat(position, "leverage", 0),
+		Timestamp:        timestamp,
+		Datetime:         self.Iso8601(timestamp),
+		Info:             position,
+	}
+}
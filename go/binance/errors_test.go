@@ -0,0 +1,25 @@
+package binance
+
+import (
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base/conformance"
+)
+
+func TestHandleErrors(t *testing.T) {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.RunErrors(t, ex, []conformance.ErrorCase{
+		{Status: 200, Body: `{"symbol":"BTCUSDT"}`},
+		{Status: 400, Body: `{"code":-2010,"msg":"Account has insufficient balance for requested action."}`, Class: "InsufficientFunds"},
+		{Status: 400, Body: `{"code":-1013,"msg":"Filter failure: LOT_SIZE"}`, Class: "InvalidOrder"},
+		{Status: 400, Body: `{"code":-2013,"msg":"Order does not exist."}`, Class: "OrderNotFound"},
+		{Status: 400, Body: `{"code":-1021,"msg":"Timestamp for this request is outside of the recvWindow."}`, Class: "InvalidNonce"},
+		{Status: 429, Body: `{"code":-1003,"msg":"Too many requests."}`, Class: "RateLimitExceeded"},
+		{Status: 400, Body: `{"code":-9999,"msg":"Something new."}`, Class: "ExchangeError"},
+		{Status: 403, Body: `<html>Forbidden</html>`, Class: "PermissionDenied"},
+		{Status: 503, Body: `<html>cloudflare</html>`, Class: "DDoSProtection"},
+	})
+}
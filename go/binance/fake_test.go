@@ -0,0 +1,493 @@
+package binance
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/base/conformance"
+	"github.com/georgexdz/ccxt/go/fake"
+)
+
+func newFakeServer() *fake.Server {
+	return fake.NewBinance(&fake.Config{
+		ApiKey:       "fake-key",
+		Secret:       "fake-secret",
+		Balances:     map[string]float64{"USDT": 1000},
+		BorrowRate:   0.0002,
+		BorrowLimits: map[string]float64{"BTC": 2},
+		Books: map[string]*base.OrderBook{
+			"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}},
+		},
+	})
+}
+
+func newFakeExchange(t *testing.T, server *fake.Server, secret string) *Binance {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.ApiKey = "fake-key"
+	ex.Secret = secret
+	ex.SetBaseUrl(server.URL)
+	return ex
+}
+
+func TestFakeOrderFillsWhenMarketMoves(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Used["USDT"] != 90 || balance.Free["USDT"] != 910 {
+		t.Fatal("locked balance:", ex.Json(balance))
+	}
+
+	server.SetBook("BTC/USDT", &base.OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Id != order.Id || o.Status != "closed" || o.Filled != 0.01 {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	balance, err = ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Total["BTC"] != 0.01 || balance.Total["USDT"] != 910 || balance.Used["USDT"] != 0 {
+		t.Fatal("filled balance:", ex.Json(balance))
+	}
+}
+
+func TestFakeCancelOrder(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", ex.Json(openOrders))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "canceled" {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, base.OrderNotFound) {
+		t.Fatal("second cancel:", err)
+	}
+}
+
+func TestFakeRejectsBadSignature(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "wrong-secret")
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+		t.Fatal("FetchBalance:", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	conformance.Run(t, newFakeExchange(t, server, "fake-secret"), server)
+}
+
+func TestFakeClockSkew(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	// the exchange's clock runs a minute behind ours
+	server.Engine.Now = func() int64 { return time.Now().UnixNano()/int64(time.Millisecond) - 60000 }
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.InvalidNonce) {
+		t.Fatal("FetchBalance before sync:", err)
+	}
+	difference, err := ex.LoadTimeDifference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difference < 59000 || difference > 61000 {
+		t.Fatal("time difference:", difference)
+	}
+	if _, err := ex.FetchBalance(nil); err != nil {
+		t.Fatal("FetchBalance after sync:", err)
+	}
+}
+
+func TestFakeErrorDetails(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	_, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 1, 9000, nil)
+	var e *base.Error
+	if !errors.As(err, &e) || !errors.Is(err, base.InsufficientFunds) {
+		t.Fatal("CreateOrder:", err)
+	}
+	if e.Exchange != "binance" || e.Code != "-2010" || e.Status != 400 || e.Method != "POST" ||
+		!strings.HasPrefix(e.Url, server.URL+"/api/v3/order") || !strings.Contains(e.Body, "-2010") || e.Retryable {
+		t.Fatalf("details: %#v", e)
+	}
+}
+
+func TestFakeAsymmetricKeys(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edPublic, edPrivate, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []struct {
+		keyType string
+		public  crypto.PublicKey
+		private crypto.PrivateKey
+	}{
+		{base.KeyTypeRsa, &rsaKey.PublicKey, rsaKey},
+		{base.KeyTypeEd25519, edPublic, edPrivate},
+	} {
+		der, err := x509.MarshalPKCS8PrivateKey(key.private)
+		if err != nil {
+			t.Fatal(err)
+		}
+		server := fake.NewBinance(&fake.Config{ApiKey: "fake-key", PublicKey: key.public, Balances: map[string]float64{"USDT": 1000}})
+		ex, err := New(&base.ExchangeConfig{
+			ApiKey:  "fake-key",
+			Secret:  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+			KeyType: key.keyType,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ex.SetBaseUrl(server.URL)
+		if balance, err := ex.FetchBalance(nil); err != nil || balance.Free["USDT"] != 1000 {
+			t.Error(key.keyType, balance, err)
+		}
+		ex.KeyType = base.KeyTypeHmac
+		if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+			t.Error(key.keyType, "hmac with a private key:", err)
+		}
+		server.Close()
+	}
+}
+
+func TestFakeDepositAndWithdraw(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	address, err := ex.FetchDepositAddress("BTC", "", nil)
+	if err != nil || address.Currency != "BTC" || address.Address == "" || address.Tag != "" || address.Status != "ok" {
+		t.Fatal("BTC address:", address, err)
+	}
+	if address, err = ex.FetchDepositAddress("XRP", "", nil); err != nil || address.Tag == "" {
+		t.Fatal("XRP tag:", address, err)
+	}
+	server.SetDepositAddress("ETH", "", "", "")
+	if _, err := ex.FetchDepositAddress("ETH", "", nil); !errors.Is(err, base.AddressPending) {
+		t.Fatal("pending address:", err)
+	}
+
+	withdrawal, err := ex.Withdraw("USDT", 100, "0xabc", "", "ETH", nil)
+	if err != nil || withdrawal.Id == "" || withdrawal.Status != "pending" || withdrawal.Type != "withdrawal" {
+		t.Fatal("Withdraw:", withdrawal, err)
+	}
+	if sent := server.Withdrawals(); len(sent) != 1 || sent[0].Network != "ETH" || sent[0].Amount != 100 {
+		t.Fatal("withdrawals:", sent)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 900 {
+		t.Fatal("balance after withdrawal:", balance, err)
+	}
+
+	for _, address := range []string{"", "0x abc", "invalid-address"} {
+		if _, err := ex.Withdraw("USDT", 1, address, "", "", nil); !errors.Is(err, base.InvalidAddress) {
+			t.Error(address, err)
+		}
+	}
+	if _, err := ex.Withdraw("USDT", 5000, "0xabc", "", "", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Error("insufficient funds:", err)
+	}
+}
+
+func TestFakeTransactionHistory(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	now := server.Engine.Now
+	server.Engine.Now = func() int64 { return 1600000000000 }
+	server.Deposit("BTC", "BTC", 0.5)
+	server.Engine.Now = func() int64 { return 1600000100000 }
+	server.Deposit("USDT", "TRC20", 200)
+	server.Engine.Now = now
+	if _, err := ex.Withdraw("USDT", 100, "0xabc", "", "ERC20", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	deposits, err := ex.FetchDeposits("", 0, 0, nil)
+	if err != nil || len(deposits) != 2 {
+		t.Fatal("FetchDeposits:", deposits, err)
+	}
+	if d := deposits[0]; d.Currency != "BTC" || d.Amount != 0.5 || d.Status != "ok" || d.Txid == "" || d.Timestamp != 1600000000000 || d.Datetime != "2020-09-13T12:26:40.000Z" {
+		t.Fatal("deposit:", d)
+	}
+	if deposits, err := ex.FetchDeposits("", 1600000000001, 0, nil); err != nil || len(deposits) != 1 || deposits[0].Network != "TRC20" {
+		t.Fatal("deposits since:", deposits, err)
+	}
+	withdrawals, err := ex.FetchWithdrawals("USDT", 0, 0, nil)
+	if err != nil || len(withdrawals) != 1 {
+		t.Fatal("FetchWithdrawals:", withdrawals, err)
+	}
+	if w := withdrawals[0]; w.Type != "withdrawal" || w.Status != "pending" || w.Address != "0xabc" || w.Network != "ERC20" || w.Timestamp == 0 {
+		t.Fatal("withdrawal:", w)
+	}
+
+	transactions, err := ex.FetchTransactions("USDT", 0, 0, nil)
+	if err != nil || len(transactions) != 2 || transactions[0].Type != "deposit" || transactions[1].Type != "withdrawal" {
+		t.Fatal("FetchTransactions:", transactions, err)
+	}
+	if transactions, err := ex.FetchTransactions("", 0, 1, nil); err != nil || len(transactions) != 1 || transactions[0].Currency != "BTC" {
+		t.Fatal("limit:", transactions, err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 1100 || balance.Total["BTC"] != 0.5 {
+		t.Fatal("balance:", balance, err)
+	}
+}
+
+func TestFakeTransfer(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	transfer, err := ex.Transfer("USDT", 300, "spot", "futures", nil)
+	if err != nil || transfer.Id == "" || transfer.FromAccount != "spot" || transfer.ToAccount != "futures" {
+		t.Fatal("Transfer:", transfer, err)
+	}
+	if _, err := ex.Transfer("USDT", 100, "futures", "spot", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ex.Transfer("USDT", 50, "spot", "margin", nil); err != nil {
+		t.Fatal(err)
+	}
+	if server.AccountBalance("futures", "USDT") != 200 || server.AccountBalance("margin", "USDT") != 50 {
+		t.Fatal("account balances:", server.AccountBalance("futures", "USDT"), server.AccountBalance("margin", "USDT"))
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 750 {
+		t.Fatal("spot balance:", balance, err)
+	}
+
+	if _, err := ex.Transfer("USDT", 500, "futures", "spot", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Error("overdrawn futures:", err)
+	}
+	if _, err := ex.Transfer("USDT", 1, "margin", "futures", nil); !errors.Is(err, base.NotSupported) {
+		t.Error("margin to futures:", err)
+	}
+
+	transfers, err := ex.FetchTransfers("USDT", 0, 0, nil)
+	if err != nil || len(transfers) != 3 {
+		t.Fatal("FetchTransfers:", transfers, err)
+	}
+	moves := map[string]float64{}
+	for _, transfer := range transfers {
+		if transfer.Status != "ok" || transfer.Currency != "USDT" || transfer.Timestamp == 0 {
+			t.Error("transfer:", transfer)
+		}
+		moves[transfer.FromAccount+" "+transfer.ToAccount] = transfer.Amount
+	}
+	if moves["spot futures"] != 300 || moves["futures spot"] != 100 || moves["spot margin"] != 50 {
+		t.Error("transfers:", moves)
+	}
+	if _, err := ex.FetchTransfers("", 0, 0, nil); !errors.Is(err, base.ArgumentsRequired) {
+		t.Error("without a code:", err)
+	}
+}
+
+func TestFakeMarginLoan(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+	near := func(a, b float64) bool { return math.Abs(a-b) < 1e-12 }
+
+	loan, err := ex.Borrow("BTC", 1.5, "", nil)
+	if err != nil || loan.Id == "" || loan.Currency != "BTC" || loan.Amount != 1.5 {
+		t.Fatal("Borrow:", loan, err)
+	}
+	if _, err := ex.Borrow("BTC", 1, "", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Error("over the limit:", err)
+	}
+	if max, err := ex.FetchMaxBorrowable("BTC", "", nil); err != nil || max != 0.5 {
+		t.Error("FetchMaxBorrowable:", max, err)
+	}
+	if rate, err := ex.FetchBorrowRate("BTC", nil); err != nil || rate.Rate != 0.0002 || rate.Period != 86400000 {
+		t.Error("FetchBorrowRate:", rate, err)
+	}
+
+	server.AccrueInterest()
+	interest, err := ex.FetchBorrowInterest("BTC", "", 0, 0, nil)
+	if err != nil || len(interest) != 1 || interest[0].Amount != 1.5 || !near(interest[0].Interest, 0.0003) || interest[0].Currency != "BTC" {
+		t.Fatal("FetchBorrowInterest:", interest, err)
+	}
+	balance, err := ex.FetchBalance(map[string]interface{}{"type": "margin"})
+	if err != nil || balance.Free["BTC"] != 1.5 || balance.Debt["BTC"] != 1.5 || !near(balance.Interest["BTC"], 0.0003) {
+		t.Fatal("margin balance:", balance, err)
+	}
+	if balance.Account["BTC"].Debt != 1.5 {
+		t.Error("margin account:", balance.Account["BTC"])
+	}
+
+	// interest is paid off first
+	if _, err := ex.Repay("BTC", 0.5003, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if owed := server.Loan("margin", "BTC"); !near(owed.Principal, 1) || !near(owed.Interest, 0) {
+		t.Error("after repaying:", owed)
+	}
+	if _, err := ex.Repay("BTC", 5, "", nil); err == nil {
+		t.Error("repaid more than owed")
+	}
+}
+
+func TestFakeAccountType(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+	if _, err := ex.Transfer("USDT", 400, "spot", "margin", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, map[string]interface{}{"accountType": "margin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Engine.OpenOrders("")) != 0 || len(server.AccountEngine("margin").OpenOrders("")) != 1 {
+		t.Fatal("order not placed on the margin account")
+	}
+	if _, err := ex.FetchOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, base.OrderNotFound) {
+		t.Error("margin order on spot:", err)
+	}
+
+	ex.ExchangeConfig.AccountType = "margin"
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 400 || balance.Used["USDT"] != 90 {
+		t.Fatal("margin balance:", balance, err)
+	}
+	if openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil); err != nil || len(openOrders) != 1 {
+		t.Fatal("FetchOpenOrders:", openOrders, err)
+	}
+	if _, err := ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	// the param overrides the config, and the type param overrides both
+	if spot, err := ex.FetchBalance(map[string]interface{}{"accountType": "spot"}); err != nil || spot.Total["USDT"] != 600 {
+		t.Fatal("spot balance:", spot, err)
+	}
+	if spot, err := ex.FetchBalance(map[string]interface{}{"type": "spot"}); err != nil || spot.Total["USDT"] != 600 {
+		t.Fatal("spot balance:", spot, err)
+	}
+	if _, err := ex.FetchBalance(map[string]interface{}{"accountType": "isolated-margin"}); err == nil {
+		t.Error("isolated margin on a fake without it")
+	}
+	if _, err := ex.FetchBalance(map[string]interface{}{"accountType": "savings"}); !errors.Is(err, base.NotSupported) {
+		t.Error("unknown account:", err)
+	}
+}
+
+func TestFakeFetchPositions(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	server.SetPosition(base.Position{Symbol: "BTC/USDT", Side: "long", Contracts: 0.5, EntryPrice: 10000, MarkPrice: 10100,
+		UnrealizedPnl: 50, LiquidationPrice: 8000, MarginMode: "isolated", Leverage: 5})
+	server.SetPosition(base.Position{Symbol: "BTC/USDT", Side: "short", Contracts: 0.2, EntryPrice: 10300, MarkPrice: 10100,
+		UnrealizedPnl: 40, LiquidationPrice: 30000, MarginMode: "isolated", Leverage: 5})
+
+	positions, err := ex.FetchPositions([]string{"BTC/USDT"}, nil)
+	if err != nil || len(positions) != 2 {
+		t.Fatal("FetchPositions:", positions, err)
+	}
+	long, short := positions[0], positions[1]
+	if long.Symbol != "BTC/USDT" || long.Side != "long" || long.Contracts != 0.5 || long.EntryPrice != 10000 || long.MarkPrice != 10100 ||
+		long.UnrealizedPnl != 50 || long.LiquidationPrice != 8000 || long.MarginMode != "isolated" || long.Leverage != 5 || long.Timestamp == 0 {
+		t.Error("long:", long)
+	}
+	if short.Side != "short" || short.Contracts != 0.2 || short.UnrealizedPnl != 40 {
+		t.Error("short:", short)
+	}
+	if positions, err := ex.FetchPositions([]string{"ETH/USDT"}, nil); err != nil || len(positions) != 0 {
+		t.Error("other symbol:", positions, err)
+	}
+}
+
+func TestFakeLeverage(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	leverage, err := ex.FetchLeverage("BTC/USDT", nil)
+	if err != nil || leverage.MarginMode != "cross" || leverage.LongLeverage != 10 || leverage.ShortLeverage != 10 {
+		t.Fatal("FetchLeverage:", leverage, err)
+	}
+	if err := ex.SetLeverage("BTC/USDT", 25, nil); err != nil {
+		t.Fatal("SetLeverage:", err)
+	}
+	if err := ex.SetMarginMode("BTC/USDT", "isolated", nil); err != nil {
+		t.Fatal("SetMarginMode:", err)
+	}
+	leverage, err = ex.FetchLeverage("BTC/USDT", nil)
+	if err != nil || leverage.Symbol != "BTC/USDT" || leverage.MarginMode != "isolated" || leverage.LongLeverage != 25 || leverage.ShortLeverage != 25 {
+		t.Error("after setting:", leverage, err)
+	}
+	// open positions report the symbol's setting
+	server.SetPosition(base.Position{Symbol: "BTC/USDT", Side: "long", Contracts: 1, EntryPrice: 10000, MarkPrice: 10000})
+	if positions, err := ex.FetchPositions([]string{"BTC/USDT"}, nil); err != nil || len(positions) != 1 || positions[0].Leverage != 25 {
+		t.Error("position leverage:", positions, err)
+	}
+
+	// binance maps its illegal parameter code to InvalidOrder
+	if err := ex.SetLeverage("BTC/USDT", 200, nil); !errors.Is(err, base.InvalidOrder) {
+		t.Error("leverage over the limit:", err)
+	}
+	if err := ex.SetLeverage("BTC/USDT", 2.5, nil); !errors.Is(err, base.BadRequest) {
+		t.Error("fractional leverage:", err)
+	}
+	if err := ex.SetMarginMode("BTC/USDT", "portfolio", nil); !errors.Is(err, base.BadRequest) {
+		t.Error("unknown margin mode:", err)
+	}
+}
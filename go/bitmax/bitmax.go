@@ -16,7 +16,10 @@ type Bitmax struct {
 
 func New(config *ExchangeConfig) (ex *Bitmax, err error) {
 	ex = new(Bitmax)
-	err = ex.Init(config)
+	if err = ex.Init(config); err != nil {
+		ex = nil
+		return
+	}
 	ex.Child = ex
 
 	err = ex.InitDescribe()
@@ -40,6 +43,7 @@ func (self *Bitmax) Describe() []byte {
         "CORS": false,
         "fetchMarkets": true,
         "fetchCurrencies": true,
+        "fetchTime": true,
         "fetchOrderBook": true,
         "fetchTicker": true,
         "fetchTickers": true,
@@ -54,6 +58,7 @@ func (self *Bitmax) Describe() []byte {
         "fetchTransactions": true,
         "fetchDeposits": true,
         "fetchWithdrawals": true,
+        "transfer": true,
         "fetchOrder": true,
         "fetchOrders": true,
         "fetchOpenOrders": true,
@@ -89,6 +94,7 @@ func (self *Bitmax) Describe() []byte {
         "public": {
             "get": [
                 "assets",
+                "exchange-info",
                 "products",
                 "ticker",
                 "barhist/info",
@@ -112,7 +118,6 @@ func (self *Bitmax) Describe() []byte {
                 "cash/balance",
                 "margin/balance",
                 "margin/risk",
-                "transfer",
                 "futures/collateral-balance",
                 "futures/position",
                 "futures/risk",
@@ -123,6 +128,7 @@ func (self *Bitmax) Describe() []byte {
                 "order/hist"
             ],
             "post": [
+                "transfer",
                 "futures/transfer/deposit",
                 "futures/transfer/withdraw",
                 "{account-category}/order",
@@ -154,6 +160,14 @@ func (self *Bitmax) Describe() []byte {
     "options": {
         "account-category": "cash",
         "account-group": null,
+        "accountsByType": {
+            "spot": "cash",
+            "margin": "margin",
+            "futures": "futures"
+        },
+        "clientOrderIdKeys": [
+            "id"
+        ],
         "fetchClosedOrders": {
             "method": "accountGroupGetOrderHist"
         }
@@ -247,6 +261,21 @@ func (self *Bitmax) ParseOrderStatus(status string) string {
 	return status
 }
 
+// FetchTime is when the exchange received the request, as echoed by its
+// latency endpoint
+func (self *Bitmax) FetchTime(params map[string]interface{}) (timestamp int64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	response := self.ApiFunc("publicGetExchangeInfo", self.Extend(map[string]interface{}{
+		"requestTime": self.Milliseconds(),
+	}, params), nil, nil)
+	data := self.SafeValue(response, "data", map[string]interface{}{})
+	return self.SafeInteger(data, "requestReceiveAt", 0), nil
+}
+
 func (self *Bitmax) FetchCurrencies(params map[string]interface{}) map[string]interface{} {
 	assets := self.ApiFunc("publicGetAssets", params, nil, nil)
 	margin := self.ApiFunc("publicGetMarginAssets", params, nil, nil)
@@ -385,6 +414,21 @@ func (self *Bitmax) FetchAccounts(params map[string]interface{}) []interface{} {
 	}}
 }
 
+// AccountCategory returns the cash, margin or futures category of a call:
+// the account-category param, else the unified account type, else the
+// account-category option of method or of the exchange
+func (self *Bitmax) AccountCategory(method string, params map[string]interface{}) string {
+	accountCategory := self.SafeString(self.Options, "account-category", "cash")
+	options := self.SafeValue(self.Options, method, map[string]interface{}{})
+	accountCategory = self.SafeString(options, "account-category", accountCategory)
+	if accountType := self.SelectAccountType(params, ""); accountType != "" {
+		accountCategory = self.AccountId(accountType)
+	}
+	accountCategory = self.SafeString(params, "account-category", accountCategory)
+	self.Omit(params, "account-category")
+	return accountCategory
+}
+
 func (self *Bitmax) FetchBalance(params map[string]interface{}) (balanceResult *Account, err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -393,11 +437,7 @@ func (self *Bitmax) FetchBalance(params map[string]interface{}) (balanceResult *
 	}()
 	self.LoadMarkets()
 	self.LoadAccounts()
-	defaultAccountCategory := self.SafeString(self.Options, "account-category", "cash")
-	options := self.SafeValue(self.Options, "fetchBalance", map[string]interface{}{})
-	accountCategory := self.SafeString(options, "account-category", defaultAccountCategory)
-	accountCategory = self.SafeString(params, "account-category", accountCategory)
-	params = self.Omit(params, "account-category")
+	accountCategory := self.AccountCategory("fetchBalance", params)
 	account := self.SafeValue(self.Accounts, 0, map[string]interface{}{})
 	accountGroup := self.SafeString(account, "id", "")
 	request := map[string]interface{}{
@@ -424,6 +464,8 @@ func (self *Bitmax) FetchBalance(params map[string]interface{}) (balanceResult *
 			borrowed := self.SafeFloat(balance, "borrowed", 0)
 			free -= borrowed
 			total -= borrowed
+			account["debt"] = borrowed
+			account["interest"] = self.SafeFloat(balance, "interest", 0)
 		}
 		account["free"] = free
 		account["total"] = total
@@ -477,11 +519,15 @@ func (self *Bitmax) ParseOrder(order interface{}, market interface{}) (result ma
 	average := self.SafeFloat(order, "avgPx", 0)
 	filled := self.SafeFloat2(order, "cumFilledQty", "cumQty", 0.0)
 	var remaining interface{}
+	// order status only carries lastExecTime: for an unfilled order that is
+	// its creation time, otherwise it is the best estimate there is
+	if timestamp == 0 {
+		timestamp = lastTradeTimestamp
+	}
+	if filled == 0 {
+		lastTradeTimestamp = 0
+	}
 	if self.ToBool(!self.TestNil(filled)) {
-		if self.ToBool(filled == 0) {
-			timestamp = lastTradeTimestamp
-			lastTradeTimestamp = 0
-		}
 		if self.ToBool(!self.TestNil(amount)) {
 			remaining = math.Max(0, amount-filled)
 		}
@@ -540,11 +586,7 @@ func (self *Bitmax) CreateOrder(symbol string, typ string, side string, amount f
 	self.LoadMarkets()
 	self.LoadAccounts()
 	market := self.Market(symbol)
-	defaultAccountCategory := self.SafeString(self.Options, "account-category", "cash")
-	options := self.SafeValue(self.Options, "createOrder", map[string]interface{}{})
-	accountCategory := self.SafeString(options, "account-category", defaultAccountCategory)
-	accountCategory = self.SafeString(params, "account-category", accountCategory)
-	params = self.Omit(params, "account-category")
+	accountCategory := self.AccountCategory("createOrder", params)
 	account := self.SafeValue(self.Accounts, 0, map[string]interface{}{})
 	accountGroup := self.SafeValue(account, "id", nil)
 	clientOrderId := self.SafeString2(params, "clientOrderId", "id", "")
@@ -552,7 +594,7 @@ func (self *Bitmax) CreateOrder(symbol string, typ string, side string, amount f
 		"account-group":    accountGroup,
 		"account-category": accountCategory,
 		"symbol":           market.Id,
-		"time":             self.Milliseconds(),
+		"time":             self.ServerMilliseconds(),
 		"orderQty":         self.AmountToPrecision(symbol, amount),
 		"orderType":        typ,
 		"side":             side,
@@ -587,11 +629,7 @@ func (self *Bitmax) FetchOrder(id string, symbol string, params map[string]inter
 	}()
 	self.LoadMarkets()
 	self.LoadAccounts()
-	defaultAccountCategory := self.SafeString(self.Options, "account-category", "cash")
-	options := self.SafeValue(self.Options, "fetchOrder", map[string]interface{}{})
-	accountCategory := self.SafeString(options, "account-category", defaultAccountCategory)
-	accountCategory = self.SafeString(params, "account-category", accountCategory)
-	params = self.Omit(params, "account-category")
+	accountCategory := self.AccountCategory("fetchOrder", params)
 	account := self.SafeValue(self.Accounts, 0, map[string]interface{}{})
 	accountGroup := self.SafeValue(account, "id", nil)
 	request := map[string]interface{}{
@@ -616,11 +654,7 @@ func (self *Bitmax) FetchOpenOrders(symbol string, since int64, limit int64, par
 	if self.ToBool(!self.TestNil(symbol)) {
 		market = self.Market(symbol)
 	}
-	defaultAccountCategory := self.SafeString(self.Options, "account-category", "cash")
-	options := self.SafeValue(self.Options, "fetchOpenOrders", map[string]interface{}{})
-	accountCategory := self.SafeString(options, "account-category", defaultAccountCategory)
-	accountCategory = self.SafeString(params, "account-category", accountCategory)
-	params = self.Omit(params, "account-category")
+	accountCategory := self.AccountCategory("fetchOpenOrders", params)
 	account := self.SafeValue(self.Accounts, 0, map[string]interface{}{})
 	accountGroup := self.SafeValue(account, "id", nil)
 	request := map[string]interface{}{
@@ -652,11 +686,7 @@ func (self *Bitmax) CancelOrder(id string, symbol string, params map[string]inte
 	self.LoadMarkets()
 	self.LoadAccounts()
 	market := self.Market(symbol)
-	defaultAccountCategory := self.SafeString(self.Options, "account-category", "cash")
-	options := self.SafeValue(self.Options, "cancelOrder", map[string]interface{}{})
-	accountCategory := self.SafeString(options, "account-category", defaultAccountCategory)
-	accountCategory = self.SafeString(params, "account-category", accountCategory)
-	params = self.Omit(params, "account-category")
+	accountCategory := self.AccountCategory("cancelOrder", params)
 	account := self.SafeValue(self.Accounts, 0, map[string]interface{}{})
 	accountGroup := self.SafeValue(account, "id", nil)
 	clientOrderId := self.SafeString2(params, "clientOrderId", "id", "")
@@ -664,7 +694,7 @@ func (self *Bitmax) CancelOrder(id string, symbol string, params map[string]inte
 		"account-group":    accountGroup,
 		"account-category": accountCategory,
 		"symbol":           market.Id,
-		"time":             self.Milliseconds(),
+		"time":             self.ServerMilliseconds(),
 		"id":               "foobar",
 	}
 	if self.ToBool(self.TestNil(clientOrderId)) {
@@ -679,6 +709,40 @@ func (self *Bitmax) CancelOrder(id string, symbol string, params map[string]inte
 	return self.ParseOrder(info, market), nil
 }
 
+// Transfer moves funds between the cash, margin and futures accounts.
+// Bitmax keeps no history of them, wallet/transactions only lists deposits
+// and withdrawals, so FetchTransfers is not supported.
+func (self *Bitmax) Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (result *TransferEntry, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	self.LoadAccounts()
+	account := self.SafeValue(self.Accounts, 0, map[string]interface{}{})
+	request := map[string]interface{}{
+		"account-group": self.SafeString(account, "id", ""),
+		"amount":        self.NumberToString(amount),
+		"asset":         self.CurrencyId(code),
+		"fromAccount":   self.AccountId(fromAccount),
+		"toAccount":     self.AccountId(toAccount),
+	}
+	response := self.ApiFunc("accountGroupPostTransfer", self.Extend(request, params), nil, nil)
+	// bitmax acknowledges a transfer without an id
+	timestamp := self.Milliseconds()
+	return &TransferEntry{
+		Currency:    code,
+		Amount:      amount,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Status:      "ok",
+		Timestamp:   timestamp,
+		Datetime:    self.Iso8601(timestamp),
+		Info:        response,
+	}, nil
+}
+
 func (self *Bitmax) Sign(path string, api string, method string, params map[string]interface{}, headers interface{}, body interface{}) (ret interface{}) {
 	url := ""
 	query := params
@@ -695,11 +759,12 @@ func (self *Bitmax) Sign(path string, api string, method string, params map[stri
 		}
 	} else {
 		self.CheckRequiredCredentials()
-		timestamp := fmt.Sprintf("%v", self.Milliseconds())
+		credentials := self.Credentials()
+		timestamp := fmt.Sprintf("%v", self.ServerMilliseconds())
 		auth := timestamp + "+" + request
-		signature := self.Hmac(self.Encode(auth), self.Encode(self.Secret), "sha256", "base64")
+		signature := self.Hmac(self.Encode(auth), self.Encode(credentials.Secret), "sha256", "base64")
 		headers = map[string]interface{}{
-			"x-auth-key":       self.ApiKey,
+			"x-auth-key":       credentials.ApiKey,
 			"x-auth-timestamp": timestamp,
 			"x-auth-signature": self.Decode(signature),
 		}
@@ -730,9 +795,7 @@ func (self *Bitmax) HandleErrors(httpCode int64, reason string, url string, meth
 	error := !self.TestNil(code) && code != "0"
 	if self.ToBool(error || !self.TestNil(message)) {
 		feedback := self.Id + " " + body
-		self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), code, feedback)
-		self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), message, feedback)
-		self.ThrowBroadlyMatchedException(self.Member(self.Exceptions, "broad"), message, feedback)
+		self.ThrowMatchedException(feedback, code, message)
 		self.RaiseException("ExchangeError", feedback)
 	}
 }
@@ -0,0 +1,22 @@
+package bitmax
+
+import (
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base/conformance"
+)
+
+func TestHandleErrors(t *testing.T) {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.RunErrors(t, ex, []conformance.ErrorCase{
+		{Status: 200, Body: `{"code":0,"data":[]}`},
+		{Status: 200, Body: `{"code":6010,"message":"Not enough balance."}`, Class: "InsufficientFunds"},
+		{Status: 200, Body: `{"code":2100,"message":"ApiKeyFailure"}`, Class: "AuthenticationError"},
+		{Status: 200, Body: `{"code":5002,"message":"Invalid symbol"}`, Class: "BadSymbol"},
+		{Status: 200, Body: `{"code":999999,"message":"Something new"}`, Class: "ExchangeError"},
+		{Status: 429, Body: ``, Class: "RateLimitExceeded"},
+	})
+}
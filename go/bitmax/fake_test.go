@@ -0,0 +1,179 @@
+package bitmax
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/base/conformance"
+	"github.com/georgexdz/ccxt/go/fake"
+)
+
+func newFakeServer() *fake.Server {
+	return fake.NewBitmax(&fake.Config{
+		ApiKey:   "fake-key",
+		Secret:   "fake-secret",
+		Balances: map[string]float64{"USDT": 1000},
+		Books: map[string]*base.OrderBook{
+			"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}},
+		},
+	})
+}
+
+func newFakeExchange(t *testing.T, server *fake.Server, secret string) *Bitmax {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.ApiKey = "fake-key"
+	ex.Secret = secret
+	ex.SetBaseUrl(server.URL)
+	return ex
+}
+
+func TestFakeOrderFillsWhenMarketMoves(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Used["USDT"] != 90 || balance.Free["USDT"] != 910 {
+		t.Fatal("locked balance:", ex.Json(balance))
+	}
+
+	server.SetBook("BTC/USDT", &base.OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Id != order.Id || o.Status != "closed" || o.Filled != 0.01 {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	balance, err = ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Total["BTC"] != 0.01 || balance.Total["USDT"] != 910 || balance.Used["USDT"] != 0 {
+		t.Fatal("filled balance:", ex.Json(balance))
+	}
+}
+
+func TestFakeCancelOrder(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", ex.Json(openOrders))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "canceled" {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	// bitmax has no dedicated code for unknown orders, only the broader class
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, base.InvalidOrder) {
+		t.Fatal("second cancel:", err)
+	}
+}
+
+func TestFakeRejectsBadSignature(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "wrong-secret")
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+		t.Fatal("FetchBalance:", err)
+	}
+}
+
+func TestFakeFetchTime(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	// the exchange's clock runs an hour ahead of ours
+	server.Engine.Now = func() int64 { return time.Now().UnixNano()/int64(time.Millisecond) + 3600000 }
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	difference, err := ex.LoadTimeDifference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difference > -3599000 || difference < -3601000 {
+		t.Fatal("time difference:", difference)
+	}
+	if _, err := ex.FetchBalance(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	conformance.Run(t, newFakeExchange(t, server, "fake-secret"), server)
+}
+
+func TestFakeTransfer(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	if _, err := ex.Transfer("USDT", 300, "spot", "futures", nil); err != nil {
+		t.Fatal(err)
+	}
+	if sent := server.Transfers(); len(sent) != 1 || sent[0].From != "cash" || sent[0].To != "futures" {
+		t.Fatal("transfers:", sent)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 700 || server.AccountBalance("futures", "USDT") != 300 {
+		t.Fatal("balances:", balance, err)
+	}
+	if _, err := ex.Transfer("USDT", 1000, "spot", "margin", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Error("overdrawn cash:", err)
+	}
+}
+
+func TestAccountCategory(t *testing.T) {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if category := ex.AccountCategory("createOrder", nil); category != "cash" {
+		t.Error("default:", category)
+	}
+	params := map[string]interface{}{"accountType": "margin"}
+	if category := ex.AccountCategory("createOrder", params); category != "margin" || len(params) != 0 {
+		t.Error("accountType param:", category, params)
+	}
+	ex.ExchangeConfig.AccountType = "margin"
+	if category := ex.AccountCategory("fetchBalance", nil); category != "margin" {
+		t.Error("config:", category)
+	}
+	if category := ex.AccountCategory("fetchBalance", map[string]interface{}{"accountType": "spot"}); category != "cash" {
+		t.Error("param over config:", category)
+	}
+	if category := ex.AccountCategory("fetchBalance", map[string]interface{}{"account-category": "futures"}); category != "futures" {
+		t.Error("account-category param:", category)
+	}
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/georgexdz/ccxt/go/kucoin"
 	"github.com/georgexdz/ccxt/go/bitmax"
 	"github.com/georgexdz/ccxt/go/margin_bitmax"
+	"github.com/georgexdz/ccxt/go/margin_binance"
+	"github.com/georgexdz/ccxt/go/paper"
 )
 
 type IExchange = base.ExchangeInterface
@@ -20,8 +22,20 @@ func New(exchange string, config *base.ExchangeConfig) (ex IExchange, err error)
 		ex, err = bitmax.New(config)
 	case "margin_bitmax":
 		ex, err = margin_bitmax.New(config)
+	case "margin_binance":
+		ex, err = margin_binance.New(config)
 	default:
 		err = fmt.Errorf("exchange %s is not supported", exchange)
 	}
 	return
 }
+
+// NewPaper creates exchange and wraps it for paper trading: market data is
+// live, orders and balances are simulated
+func NewPaper(exchange string, config *base.ExchangeConfig, paperConfig *paper.Config) (ex IExchange, err error) {
+	upstream, err := New(exchange, config)
+	if err != nil {
+		return
+	}
+	return paper.New(upstream, paperConfig)
+}
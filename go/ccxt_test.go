@@ -0,0 +1,22 @@
+package ccxt
+
+import (
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/margin_binance"
+	"github.com/georgexdz/ccxt/go/margin_bitmax"
+)
+
+func TestNewMarginExchanges(t *testing.T) {
+	ex, err := New("margin_bitmax", nil)
+	if _, ok := ex.(*margin_bitmax.MarginBitmax); err != nil || !ok {
+		t.Error("margin_bitmax:", ex, err)
+	}
+	ex, err = New("margin_binance", nil)
+	if _, ok := ex.(*margin_binance.MarginBinance); err != nil || !ok {
+		t.Error("margin_binance:", ex, err)
+	}
+	if _, err := New("margin_nowhere", nil); err == nil {
+		t.Error("unknown exchange created")
+	}
+}
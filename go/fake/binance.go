@@ -0,0 +1,457 @@
+package fake
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+var binanceErrors = map[string]apiError{
+	"AuthenticationError": {401, "-1022", "Signature for this request is not valid."},
+	"InsufficientFunds":   {400, "-2010", "Account has insufficient balance for requested action."},
+	"OrderNotFound":       {400, "-2013", "Order does not exist."},
+	"BadSymbol":           {400, "-1121", "Invalid symbol."},
+	"InvalidOrder":        {400, "-1013", "Invalid quantity."},
+	"InvalidNonce":        {400, "-1021", "Timestamp for this request is outside of the recvWindow."},
+	"InvalidAddress":      {400, "-4007", "Withdrawal address is invalid."},
+	"BadRequest":          {400, "-1100", "Illegal characters found in a parameter."},
+}
+
+// NewBinance emulates the binance spot api under /api/v3 and its deposit,
+// withdrawal, transfer and cross margin endpoints under /sapi/v1. The
+// margin, futures and delivery accounts only hold what is transferred to
+// them and, for margin, what is borrowed. Cross margin orders trade on an
+// engine of their own. Futures positions under /fapi/v1 are the ones the
+// test sets with SetPosition.
+func NewBinance(config *Config) *Server {
+	return newTradingServer(config, (*Server).binance, []string{"margin"}, "futures", "delivery")
+}
+
+// binanceTransfers are the accounts each transfer type moves between, by
+// endpoint
+var binanceTransfers = map[string][][2]string{
+	"margin/transfer":  {1: {"spot", "margin"}, 2: {"margin", "spot"}},
+	"futures/transfer": {1: {"spot", "futures"}, 2: {"futures", "spot"}, 3: {"spot", "delivery"}, 4: {"delivery", "spot"}},
+}
+
+func binanceMarketId(symbol string) string {
+	return strings.Replace(symbol, "/", "", 1)
+}
+
+func (s *Server) binanceFail(w http.ResponseWriter, err error) {
+	e := binanceErrors[errorClass(err)]
+	writeJSON(w, e.status, map[string]interface{}{"code": json.Number(e.code), "msg": e.message})
+}
+
+// binanceAuth checks the api key header and the signature appended to the
+// query string, or to the body of a POST, hex for an HMAC and base64 for an
+// asymmetric key
+func (s *Server) binanceAuth(r *request) bool {
+	signed := r.URL.RawQuery
+	if r.Method == "POST" {
+		signed = r.body
+	}
+	i := strings.LastIndex(signed, "&signature=")
+	if i < 0 || r.Header.Get("X-MBX-APIKEY") != s.config.ApiKey {
+		return false
+	}
+	if s.config.PublicKey != nil {
+		return checkSignature(s.config.PublicKey, signed[:i], signed[i+len("&signature="):])
+	}
+	return checkHmac(s.config.Secret, signed[:i], signed[i+len("&signature="):], "hex")
+}
+
+func (s *Server) binanceOrder(engine *paper.Engine, order *Order) map[string]interface{} {
+	status := "NEW"
+	switch {
+	case order.Status == "canceled":
+		status = "CANCELED"
+	case order.Status == "closed":
+		status = "FILLED"
+	case order.Filled > 0:
+		status = "PARTIALLY_FILLED"
+	}
+	return map[string]interface{}{
+		"symbol":              binanceMarketId(order.Symbol),
+		"orderId":             json.Number(order.Id),
+		"orderListId":         -1,
+		"clientOrderId":       s.clientIdIn(engine, order),
+		"transactTime":        order.Timestamp,
+		"time":                order.Timestamp,
+		"updateTime":          order.Timestamp,
+		"price":               formatFloat(order.Price),
+		"origQty":             formatFloat(order.Amount),
+		"executedQty":         formatFloat(order.Filled),
+		"cummulativeQuoteQty": formatFloat(order.Cost),
+		"status":              status,
+		"timeInForce":         "GTC",
+		"type":                strings.ToUpper(order.Type),
+		"side":                strings.ToUpper(order.Side),
+	}
+}
+
+func (s *Server) binance(w http.ResponseWriter, r *request) {
+	path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v3/"), "/sapi/v1/")
+	route := r.Method + " " + path
+	switch route {
+	case "GET time", "GET exchangeInfo", "GET depth":
+	default:
+		if !s.binanceAuth(r) {
+			s.binanceFail(w, AuthenticationError)
+			return
+		}
+		// signed requests must be stamped within recvWindow of the server clock
+		recvWindow := int64(5000)
+		if r.params["recvWindow"] != "" {
+			recvWindow = int64(parseFloat(r.params["recvWindow"]))
+		}
+		if skew := s.Engine.Now() - int64(parseFloat(r.params["timestamp"])); skew > recvWindow || skew < -1000 {
+			s.binanceFail(w, InvalidNonce)
+			return
+		}
+	}
+
+	if futures := strings.TrimPrefix(r.URL.Path, "/fapi/v1/"); futures != r.URL.Path {
+		s.binanceFutures(w, r, r.Method+" "+futures)
+		return
+	}
+
+	var symbol string
+	if id, ok := r.params["symbol"]; ok {
+		var err error
+		if symbol, err = s.symbol(id, binanceMarketId); err != nil {
+			s.binanceFail(w, err)
+			return
+		}
+	}
+
+	// orders under margin/ are on the cross margin account
+	engine := s.Engine
+	if strings.HasPrefix(path, "margin/") {
+		if r.params["isIsolated"] == "TRUE" {
+			s.binanceFail(w, TypedError("BadRequest", "fake has no isolated margin"))
+			return
+		}
+		engine = s.engines["margin"]
+	}
+
+	switch route {
+	case "GET time":
+		writeJSON(w, 200, map[string]interface{}{"serverTime": s.Engine.Now()})
+	case "GET exchangeInfo":
+		symbols := []interface{}{}
+		for _, symbol := range s.Symbols() {
+			parts := strings.Split(symbol, "/")
+			symbols = append(symbols, map[string]interface{}{
+				"symbol":              binanceMarketId(symbol),
+				"status":              "TRADING",
+				"baseAsset":           parts[0],
+				"baseAssetPrecision":  8,
+				"quoteAsset":          parts[1],
+				"quotePrecision":      8,
+				"quoteAssetPrecision": 8,
+				"orderTypes":          []string{"LIMIT", "MARKET"},
+				"filters": []interface{}{
+					map[string]interface{}{"filterType": "PRICE_FILTER", "minPrice": "0.00000001", "maxPrice": "10000000", "tickSize": "0.00000001"},
+					map[string]interface{}{"filterType": "LOT_SIZE", "minQty": "0.00000001", "maxQty": "10000000", "stepSize": "0.00000001"},
+				},
+				"permissions": []string{"SPOT"},
+			})
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"timezone":   "UTC",
+			"serverTime": s.Engine.Now(),
+			"symbols":    symbols,
+		})
+	case "GET depth":
+		book := s.Book(symbol)
+		if book == nil {
+			s.binanceFail(w, BadSymbol)
+			return
+		}
+		limit := int(parseFloat(r.params["limit"]))
+		writeJSON(w, 200, map[string]interface{}{
+			"lastUpdateId": s.Engine.Now(),
+			"bids":         stringLevels(levels(book.Bids, limit)),
+			"asks":         stringLevels(levels(book.Asks, limit)),
+		})
+	case "GET account":
+		balance := s.Engine.Balance()
+		balances := []interface{}{}
+		for _, code := range s.currencies() {
+			balances = append(balances, map[string]interface{}{
+				"asset":  code,
+				"free":   formatFloat(balance.Free[code]),
+				"locked": formatFloat(balance.Used[code]),
+			})
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"canTrade":    true,
+			"accountType": "SPOT",
+			"balances":    balances,
+		})
+	case "POST order", "POST margin/order":
+		order, err := s.placeIn(engine, symbol, strings.ToLower(r.params["type"]), strings.ToLower(r.params["side"]),
+			parseFloat(r.params["quantity"]), parseFloat(r.params["price"]), r.params["newClientOrderId"])
+		if err != nil {
+			s.binanceFail(w, err)
+			return
+		}
+		writeJSON(w, 200, s.binanceOrder(engine, order))
+	case "GET order", "DELETE order", "GET margin/order", "DELETE margin/order":
+		order, err := engine.FetchOrder(r.params["orderId"])
+		if err == nil && order.Symbol != symbol {
+			err = OrderNotFound
+		}
+		if err == nil && r.Method == "DELETE" {
+			order, err = engine.CancelOrder(order.Id)
+		}
+		if err != nil {
+			s.binanceFail(w, err)
+			return
+		}
+		writeJSON(w, 200, s.binanceOrder(engine, order))
+	case "GET openOrders", "GET margin/openOrders":
+		result := []interface{}{}
+		for _, order := range s.ordersIn(engine, symbol, "open") {
+			result = append(result, s.binanceOrder(engine, order))
+		}
+		writeJSON(w, 200, result)
+	case "GET capital/deposit/address":
+		address := s.depositAddress(r.params["coin"], r.params["network"], true)
+		writeJSON(w, 200, map[string]interface{}{
+			"address": address.Address,
+			"coin":    address.Code,
+			"tag":     address.Tag,
+			"url":     "",
+		})
+	case "POST capital/withdraw/apply":
+		withdrawal, err := s.withdraw(r.params["coin"], r.params["network"], r.params["address"], r.params["addressTag"], parseFloat(r.params["amount"]))
+		if err != nil {
+			s.binanceFail(w, err)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{"id": withdrawal.Id})
+	case "GET capital/deposit/hisrec":
+		result := []interface{}{}
+		for _, deposit := range s.Deposits() {
+			if binanceListed(r, deposit.Code, deposit.Timestamp, len(result)) {
+				result = append(result, map[string]interface{}{
+					"amount":       formatFloat(deposit.Amount),
+					"coin":         deposit.Code,
+					"network":      deposit.Network,
+					"status":       1,
+					"address":      deposit.Address,
+					"addressTag":   deposit.Tag,
+					"txId":         deposit.Txid,
+					"insertTime":   deposit.Timestamp,
+					"transferType": 0,
+					"confirmTimes": "12/12",
+				})
+			}
+		}
+		writeJSON(w, 200, result)
+	case "GET capital/withdraw/history":
+		result := []interface{}{}
+		for _, withdrawal := range s.Withdrawals() {
+			if binanceListed(r, withdrawal.Code, withdrawal.Timestamp, len(result)) {
+				result = append(result, map[string]interface{}{
+					"id":             withdrawal.Id,
+					"amount":         formatFloat(withdrawal.Amount),
+					"transactionFee": "0",
+					"coin":           withdrawal.Code,
+					"status":         4,
+					"address":        withdrawal.Address,
+					"addressTag":     withdrawal.Tag,
+					"txId":           "",
+					"applyTime":      time.Unix(0, withdrawal.Timestamp*int64(time.Millisecond)).UTC().Format("2006-01-02 15:04:05"),
+					"network":        withdrawal.Network,
+					"transferType":   0,
+				})
+			}
+		}
+		writeJSON(w, 200, result)
+	case "POST margin/transfer", "POST futures/transfer":
+		accounts := binanceTransfers[path]
+		typ := int(parseFloat(r.params["type"]))
+		if typ <= 0 || typ >= len(accounts) {
+			s.binanceFail(w, BadRequest)
+			return
+		}
+		transfer, err := s.transfer(r.params["asset"], accounts[typ][0], accounts[typ][1], parseFloat(r.params["amount"]), "spot")
+		if err != nil {
+			s.binanceFail(w, err)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{"tranId": json.Number(transfer.Id)})
+	case "GET margin/transfer", "GET futures/transfer":
+		rows := []interface{}{}
+		for _, transfer := range s.Transfers() {
+			if transfer.Code != r.params["asset"] || transfer.Timestamp < int64(parseFloat(r.params["startTime"])) {
+				continue
+			}
+			for typ, accounts := range binanceTransfers[path] {
+				if accounts != [2]string{transfer.From, transfer.To} {
+					continue
+				}
+				row := map[string]interface{}{
+					"asset":     transfer.Code,
+					"tranId":    json.Number(transfer.Id),
+					"amount":    formatFloat(transfer.Amount),
+					"type":      typ,
+					"timestamp": transfer.Timestamp,
+					"status":    "CONFIRMED",
+				}
+				if path == "margin/transfer" {
+					row["type"] = map[int]string{1: "ROLL_IN", 2: "ROLL_OUT"}[typ]
+				}
+				rows = append(rows, row)
+			}
+		}
+		writeJSON(w, 200, map[string]interface{}{"rows": rows, "total": len(rows)})
+	case "POST margin/loan", "POST margin/repay":
+		borrow := s.borrow
+		if path == "margin/repay" {
+			borrow = s.repay
+		}
+		if _, err := borrow("margin", r.params["asset"], parseFloat(r.params["amount"])); err != nil {
+			s.binanceFail(w, err)
+			return
+		}
+		s.mu.Lock()
+		s.nextLoanId++
+		id := 500000 + s.nextLoanId
+		s.mu.Unlock()
+		writeJSON(w, 200, map[string]interface{}{"tranId": id})
+	case "GET margin/maxBorrowable":
+		writeJSON(w, 200, map[string]interface{}{
+			"amount":      formatFloat(s.maxBorrowable("margin", r.params["asset"])),
+			"borrowLimit": formatFloat(s.config.BorrowLimits[r.params["asset"]]),
+		})
+	case "GET margin/interestRateHistory":
+		writeJSON(w, 200, []interface{}{map[string]interface{}{
+			"asset":             r.params["asset"],
+			"dailyInterestRate": formatFloat(s.config.BorrowRate),
+			"timestamp":         s.Engine.Now(),
+			"vipLevel":          0,
+		}})
+	case "GET margin/interestHistory":
+		rows := []interface{}{}
+		charges := s.InterestCharges()
+		// newest first
+		for i := len(charges) - 1; i >= 0; i-- {
+			charge := charges[i]
+			if charge.Account != "margin" || !binanceListed(r, charge.Code, charge.Timestamp, len(rows)) {
+				continue
+			}
+			rows = append(rows, map[string]interface{}{
+				"asset":               charge.Code,
+				"interest":            formatFloat(charge.Interest),
+				"interestAccuredTime": charge.Timestamp,
+				"interestRate":        formatFloat(charge.Rate),
+				"principal":           formatFloat(charge.Principal),
+				"type":                "PERIODIC",
+			})
+		}
+		writeJSON(w, 200, map[string]interface{}{"rows": rows, "total": len(rows)})
+	case "GET margin/account":
+		balance := engine.Balance()
+		assets := []interface{}{}
+		for _, code := range s.currencies() {
+			loan := s.Loan("margin", code)
+			assets = append(assets, map[string]interface{}{
+				"asset":    code,
+				"borrowed": formatFloat(loan.Principal),
+				"free":     formatFloat(balance.Free[code]),
+				"interest": formatFloat(loan.Interest),
+				"locked":   formatFloat(balance.Used[code]),
+				"netAsset": formatFloat(balance.Total[code] - loan.Principal - loan.Interest),
+			})
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"borrowEnabled": true,
+			"tradeEnabled":  true,
+			"userAssets":    assets,
+		})
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
+
+// binanceListed reports whether a deposit, withdrawal or interest charge
+// matches the coin or asset and startTime of a history request and fits in
+// its limit or size, given how many are listed already
+func binanceListed(r *request, code string, timestamp int64, listed int) bool {
+	if coin := r.params["coin"] + r.params["asset"]; coin != "" && coin != code {
+		return false
+	}
+	if limit := int(parseFloat(r.params["limit"] + r.params["size"])); limit > 0 && listed >= limit {
+		return false
+	}
+	return timestamp >= int64(parseFloat(r.params["startTime"]))
+}
+
+// binanceFutures serves the USDⓈ-M futures routes under /fapi/v1, in hedge
+// mode, where each side of a symbol is a position of its own
+func (s *Server) binanceFutures(w http.ResponseWriter, r *request, route string) {
+	var symbol string
+	if id, ok := r.params["symbol"]; ok {
+		var err error
+		if symbol, err = s.symbol(id, binanceMarketId); err != nil {
+			s.binanceFail(w, err)
+			return
+		}
+	}
+	switch route {
+	case "POST leverage":
+		leverage := parseFloat(r.params["leverage"])
+		if symbol == "" || leverage < 1 || leverage > 125 || leverage != math.Trunc(leverage) {
+			s.binanceFail(w, TypedError("BadRequest", "fake needs a symbol and a leverage from 1 to 125"))
+			return
+		}
+		s.setLeverage(symbol, "", leverage, "")
+		writeJSON(w, 200, map[string]interface{}{"symbol": r.params["symbol"], "leverage": int(leverage), "maxNotionalValue": "1000000"})
+	case "POST marginType":
+		mode := map[string]string{"CROSSED": "cross", "ISOLATED": "isolated"}[r.params["marginType"]]
+		if symbol == "" || mode == "" {
+			s.binanceFail(w, TypedError("BadRequest", "fake needs a symbol and a marginType"))
+			return
+		}
+		s.setLeverage(symbol, "", 0, mode)
+		writeJSON(w, 200, map[string]interface{}{"code": 200, "msg": "success"})
+	case "GET positionRisk":
+		result := []interface{}{}
+		positions := s.openPositions(symbol)
+		if symbol != "" && len(positions) == 0 {
+			// binance lists a symbol it was asked for even when flat
+			setting := s.Leverage(symbol)
+			positions = []Position{{Symbol: symbol, Side: "both", MarginMode: setting.MarginMode, Leverage: setting.LongLeverage}}
+		}
+		for _, position := range positions {
+			amount := position.Contracts
+			if position.Side == "short" {
+				amount = -amount
+			}
+			result = append(result, map[string]interface{}{
+				"symbol":           binanceMarketId(position.Symbol),
+				"positionAmt":      formatFloat(amount),
+				"entryPrice":       formatFloat(position.EntryPrice),
+				"markPrice":        formatFloat(position.MarkPrice),
+				"unRealizedProfit": formatFloat(position.UnrealizedPnl),
+				"liquidationPrice": formatFloat(position.LiquidationPrice),
+				"leverage":         formatFloat(position.Leverage),
+				"marginType":       position.MarginMode,
+				"positionSide":     strings.ToUpper(position.Side),
+				"updateTime":       s.Engine.Now(),
+			})
+		}
+		writeJSON(w, 200, result)
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
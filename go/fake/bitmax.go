@@ -0,0 +1,232 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+// bitmaxAccountGroup prefixes the private urls, as returned by /info
+const bitmaxAccountGroup = 8
+
+var bitmaxErrors = map[string]apiError{
+	"AuthenticationError": {200, "2100", "ApiKeyFailure"},
+	"InsufficientFunds":   {200, "6010", "Not enough balance."},
+	"OrderNotFound":       {200, "300013", "Order not found."},
+	"BadSymbol":           {200, "6001", "Invalid symbol."},
+	"InvalidOrder":        {200, "300001", "Invalid order."},
+	"BadRequest":          {200, "100001", "Invalid request."},
+}
+
+// NewBitmax emulates the bitmax pro api under /api/pro/v1 and
+// /{account-group}/api/pro/v1. The cash and margin account categories
+// share a single set of balances, the futures account only holds what is
+// transferred to it.
+func NewBitmax(config *Config) *Server {
+	return newServer(config, (*Server).bitmax, "futures")
+}
+
+func bitmaxMarketId(symbol string) string {
+	return symbol
+}
+
+func (s *Server) bitmaxFail(w http.ResponseWriter, err error) {
+	e := bitmaxErrors[errorClass(err)]
+	writeJSON(w, e.status, map[string]interface{}{"code": json.Number(e.code), "message": e.message})
+}
+
+func bitmaxOk(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, 200, map[string]interface{}{"code": 0, "data": data})
+}
+
+// bitmaxAuth checks the key header and the base64 signature of
+// timestamp + "+" + the path after /api/pro/v1/
+func (s *Server) bitmaxAuth(r *request) bool {
+	if r.Header.Get("x-auth-key") != s.config.ApiKey {
+		return false
+	}
+	path := r.URL.Path[strings.Index(r.URL.Path, "/api/pro/v1/")+len("/api/pro/v1/"):]
+	auth := r.Header.Get("x-auth-timestamp") + "+" + path
+	return checkHmac(s.config.Secret, auth, r.Header.Get("x-auth-signature"), "base64")
+}
+
+func (s *Server) bitmaxOrder(order *Order) map[string]interface{} {
+	status := "New"
+	switch {
+	case order.Status == "canceled":
+		status = "Canceled"
+	case order.Status == "closed":
+		status = "Filled"
+	case order.Filled > 0:
+		status = "PartiallyFilled"
+	}
+	var average float64
+	if order.Filled > 0 {
+		average = order.Cost / order.Filled
+	}
+	_, quote, _ := paper.SplitSymbol(order.Symbol)
+	return map[string]interface{}{
+		"avgPx":        formatFloat(average),
+		"cumFee":       formatFloat(order.Fee),
+		"cumFilledQty": formatFloat(order.Filled),
+		"errorCode":    "",
+		"feeAsset":     quote,
+		"lastExecTime": order.Timestamp,
+		"orderId":      order.Id,
+		"orderQty":     formatFloat(order.Amount),
+		"orderType":    strings.Title(order.Type),
+		"price":        formatFloat(order.Price),
+		"side":         strings.Title(order.Side),
+		"status":       status,
+		"stopPrice":    "",
+		"symbol":       bitmaxMarketId(order.Symbol),
+		"execInst":     "NULL_VAL",
+	}
+}
+
+// bitmaxAck is the acknowledgement of an order placement or cancel
+func (s *Server) bitmaxAck(category, action string, order *Order) map[string]interface{} {
+	return map[string]interface{}{
+		"ac":     strings.ToUpper(category),
+		"action": action,
+		"status": "Ack",
+		"info": map[string]interface{}{
+			"id":        s.clientId(order),
+			"orderId":   order.Id,
+			"orderType": strings.Title(order.Type),
+			"symbol":    bitmaxMarketId(order.Symbol),
+			"timestamp": s.Engine.Now(),
+		},
+	}
+}
+
+func (s *Server) bitmax(w http.ResponseWriter, r *request) {
+	var symbol string
+	if id, ok := r.params["symbol"]; ok {
+		var err error
+		if symbol, err = s.symbol(id, bitmaxMarketId); err != nil {
+			s.bitmaxFail(w, err)
+			return
+		}
+	}
+
+	if r.URL.Path == "/api/pro/v1/exchange-info" {
+		requestTime := int64(parseFloat(r.params["requestTime"]))
+		bitmaxOk(w, map[string]interface{}{
+			"requestTimeEcho":  requestTime,
+			"requestReceiveAt": s.Engine.Now(),
+			"latency":          s.Engine.Now() - requestTime,
+		})
+		return
+	}
+
+	if r.URL.Path == "/api/pro/v1/depth" {
+		book := s.Book(symbol)
+		if book == nil {
+			s.bitmaxFail(w, BadSymbol)
+			return
+		}
+		bitmaxOk(w, map[string]interface{}{
+			"m":      "depth-snapshot",
+			"symbol": bitmaxMarketId(symbol),
+			"data": map[string]interface{}{
+				"seqnum": s.Engine.Now(),
+				"ts":     s.Engine.Now(),
+				"bids":   stringLevels(book.Bids),
+				"asks":   stringLevels(book.Asks),
+			},
+		})
+		return
+	}
+
+	if !s.bitmaxAuth(r) {
+		s.bitmaxFail(w, AuthenticationError)
+		return
+	}
+	if r.URL.Path == "/api/pro/v1/info" {
+		bitmaxOk(w, map[string]interface{}{
+			"accountGroup":  bitmaxAccountGroup,
+			"cashAccount":   []string{"cash"},
+			"marginAccount": []string{"margin"},
+			"userUID":       "U0000000001",
+		})
+		return
+	}
+
+	prefix := "/" + strconv.Itoa(bitmaxAccountGroup) + "/api/pro/v1/"
+	if r.Method == "POST" && r.URL.Path == prefix+"transfer" {
+		_, err := s.transfer(r.params["asset"], r.params["fromAccount"], r.params["toAccount"], parseFloat(r.params["amount"]), "cash", "margin")
+		if err != nil {
+			s.bitmaxFail(w, err)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{"code": 0})
+		return
+	}
+	parts := strings.SplitN(strings.TrimPrefix(r.URL.Path, prefix), "/", 2)
+	if !strings.HasPrefix(r.URL.Path, prefix) || len(parts) != 2 || parts[0] != "cash" && parts[0] != "margin" {
+		http.NotFound(w, r.Request)
+		return
+	}
+	category := parts[0]
+	switch r.Method + " " + parts[1] {
+	case "GET balance":
+		balance := s.Engine.Balance()
+		result := []interface{}{}
+		for _, code := range s.currencies() {
+			asset := map[string]interface{}{
+				"asset":            code,
+				"totalBalance":     formatFloat(balance.Total[code]),
+				"availableBalance": formatFloat(balance.Free[code]),
+			}
+			if category == "margin" {
+				asset["borrowed"] = "0"
+				asset["interest"] = "0"
+			}
+			result = append(result, asset)
+		}
+		bitmaxOk(w, result)
+	case "POST order":
+		order, err := s.place(symbol, strings.ToLower(r.params["orderType"]), strings.ToLower(r.params["side"]),
+			parseFloat(r.params["orderQty"]), parseFloat(r.params["orderPrice"]), r.params["id"])
+		if err != nil {
+			s.bitmaxFail(w, err)
+			return
+		}
+		bitmaxOk(w, s.bitmaxAck(category, "place-order", order))
+	case "GET order/status", "DELETE order":
+		order, err := s.Engine.FetchOrder(r.params["orderId"])
+		if err == nil && r.Method == "DELETE" {
+			order, err = s.Engine.CancelOrder(order.Id)
+		}
+		if err != nil {
+			s.bitmaxFail(w, err)
+			return
+		}
+		if r.Method == "DELETE" {
+			bitmaxOk(w, s.bitmaxAck(category, "cancel-order", order))
+		} else {
+			writeJSON(w, 200, map[string]interface{}{
+				"code":            0,
+				"accountCategory": strings.ToUpper(category),
+				"data":            s.bitmaxOrder(order),
+			})
+		}
+	case "GET order/open":
+		result := []interface{}{}
+		for _, order := range s.orders(symbol, "open") {
+			result = append(result, s.bitmaxOrder(order))
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"code":            0,
+			"accountCategory": strings.ToUpper(category),
+			"data":            result,
+		})
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
@@ -0,0 +1,405 @@
+// Package fake runs in-process HTTP servers that emulate the REST surface
+// the adapters use, so they can be driven end to end without a network.
+//
+// Unlike a cassette a fake is stateful: order ids persist, balances move
+// when orders fill and cancels change the order status. Private requests
+// are authenticated exactly as the real exchange does it, so a request
+// that is signed wrongly by an adapter's Sign is rejected with the
+// exchange's own authentication error. Orders are kept by a paper.Engine
+// and fill against the books the test supplies, following the paper
+// trading matching model.
+//
+// Point an adapter at a fake with SetBaseUrl:
+//
+//	server := fake.NewBinance(&fake.Config{ApiKey: "key", Secret: "secret", ...})
+//	defer server.Close()
+//	ex.SetBaseUrl(server.URL)
+package fake
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+// Config for a fake exchange server
+type Config struct {
+	// ApiKey, Secret and Password are the credentials the server accepts
+	ApiKey   string
+	Secret   string
+	Password string
+	// PublicKey, if set, is the RSA or Ed25519 key of an asymmetric API key
+	// on the exchanges that support them, checked instead of Secret
+	PublicKey crypto.PublicKey
+	// Balances are the initial free balances by currency code
+	Balances map[string]float64
+	// Books are the initial order books by unified symbol, every symbol
+	// with a book is listed as a market
+	Books map[string]*OrderBook
+	// Maker and Taker are fee rates, e.g. 0.001 for 10 bps
+	Maker float64
+	Taker float64
+	// BorrowRate is the daily interest rate of margin loans and
+	// BorrowLimits the most of each currency that can be borrowed
+	BorrowRate   float64
+	BorrowLimits map[string]float64
+	// Contracts are the futures and swap instruments listed besides the
+	// spot markets of Books, by unified symbol, on the fakes that have them
+	Contracts []string
+}
+
+// Server is a running fake exchange
+type Server struct {
+	*httptest.Server
+
+	Engine *paper.Engine
+
+	config    Config
+	mu        sync.Mutex
+	books     map[string]*OrderBook
+	clientIds map[clientKey]string // client order id of each order
+	// engines trade for accounts other than the main one, e.g. cross margin
+	engines map[string]*paper.Engine
+
+	addresses   map[string]*Address // deposit addresses by code and network
+	deposits    []*Deposit
+	withdrawals []*Withdrawal
+	accounts    map[string]map[string]float64 // balances outside the engine by account
+	transfers   []*Transfer
+	loans       map[string]*Loan
+	charges     []*InterestCharge
+	nextLoanId  int
+	positions   map[string]*Position // by symbol and side
+	leverages   map[string]*Leverage // by symbol
+}
+
+// clientKey identifies an order, ids are only unique per engine
+type clientKey struct {
+	engine *paper.Engine
+	id     string
+}
+
+// request is an incoming call with its body already read
+type request struct {
+	*http.Request
+	body   string
+	params map[string]string // query, urlencoded form and JSON body merged
+}
+
+// apiError is how an exchange reports one class of failure
+type apiError struct {
+	status  int
+	code    string
+	message string
+}
+
+func newServer(config *Config, route func(s *Server, w http.ResponseWriter, r *request), accounts ...string) *Server {
+	return newTradingServer(config, route, nil, accounts...)
+}
+
+// newTradingServer is newServer with more trading accounts, each backed by
+// its own engine that starts empty and follows the main engine's clock
+func newTradingServer(config *Config, route func(s *Server, w http.ResponseWriter, r *request), engines []string, accounts ...string) *Server {
+	s := &Server{
+		books:     map[string]*OrderBook{},
+		clientIds: map[clientKey]string{},
+		engines:   map[string]*paper.Engine{},
+		addresses: map[string]*Address{},
+		accounts:  map[string]map[string]float64{},
+		loans:     map[string]*Loan{},
+		positions: map[string]*Position{},
+		leverages: map[string]*Leverage{},
+	}
+	for _, account := range accounts {
+		s.accounts[account] = map[string]float64{}
+	}
+	if config != nil {
+		s.config = *config
+	}
+	for symbol, book := range s.config.Books {
+		s.books[symbol] = book
+	}
+	s.Engine = paper.NewEngine(s.config.Balances, s.config.Maker, s.config.Taker)
+	for _, account := range engines {
+		engine := paper.NewEngine(nil, s.config.Maker, s.config.Taker)
+		engine.Now = func() int64 { return s.Engine.Now() }
+		s.engines[account] = engine
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route(s, w, readRequest(r))
+	}))
+	return s
+}
+
+func readRequest(r *http.Request) *request {
+	raw, _ := ioutil.ReadAll(r.Body)
+	req := &request{Request: r, body: string(raw), params: map[string]string{}}
+	for k, v := range r.URL.Query() {
+		req.params[k] = v[0]
+	}
+	var object map[string]interface{}
+	if json.Unmarshal(raw, &object) == nil {
+		for k, v := range object {
+			req.params[k] = stringify(v)
+		}
+	} else if form, err := url.ParseQuery(req.body); err == nil {
+		for k, v := range form {
+			req.params[k] = v[0]
+		}
+	}
+	return req
+}
+
+func stringify(v interface{}) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case float64:
+		return formatFloat(v)
+	case nil:
+		return ""
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func parseFloat(s string) float64 {
+	f, _ := strconv.ParseFloat(s, 64)
+	return f
+}
+
+func iso8601(ms int64) string {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+// checkHmac reports whether signature is the HMAC-SHA256 of payload under
+// secret, hex or base64 encoded
+func checkHmac(secret, payload, signature, encoding string) bool {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write([]byte(payload))
+	var expected string
+	if encoding == "hex" {
+		expected = hex.EncodeToString(h.Sum(nil))
+	} else {
+		expected = base64.StdEncoding.EncodeToString(h.Sum(nil))
+	}
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// checkSignature reports whether signature is the base64, possibly url
+// encoded, RSA PKCS#1 v1.5 SHA-256 or Ed25519 signature of payload
+func checkSignature(key crypto.PublicKey, payload, signature string) bool {
+	if unescaped, err := url.QueryUnescape(signature); err == nil {
+		signature = unescaped
+	}
+	raw, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	switch key := key.(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, []byte(payload), raw)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256([]byte(payload))
+		return rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], raw) == nil
+	}
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// errorClass reduces err to the classes the fakes report differently
+func errorClass(err error) string {
+	switch {
+	case errors.Is(err, AuthenticationError):
+		return "AuthenticationError"
+	case errors.Is(err, InsufficientFunds):
+		return "InsufficientFunds"
+	case errors.Is(err, OrderNotFound):
+		return "OrderNotFound"
+	case errors.Is(err, BadSymbol):
+		return "BadSymbol"
+	case errors.Is(err, InvalidOrder):
+		return "InvalidOrder"
+	case errors.Is(err, InvalidNonce):
+		return "InvalidNonce"
+	case errors.Is(err, InvalidAddress):
+		return "InvalidAddress"
+	}
+	return "BadRequest"
+}
+
+// SetBook replaces the order book of symbol and fills the resting orders
+// it crosses, as if the market had moved
+func (s *Server) SetBook(symbol string, book *OrderBook) {
+	s.mu.Lock()
+	s.books[symbol] = book
+	s.mu.Unlock()
+	s.Engine.Match(symbol, book)
+	for _, engine := range s.engines {
+		engine.Match(symbol, book)
+	}
+}
+
+// Book returns the current order book of symbol, nil if it is not listed
+func (s *Server) Book(symbol string) *OrderBook {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.books[symbol]
+}
+
+// Symbols returns the listed unified symbols, sorted
+func (s *Server) Symbols() (result []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for symbol := range s.books {
+		result = append(result, symbol)
+	}
+	sort.Strings(result)
+	return
+}
+
+// symbol maps an exchange market id back to the unified symbol
+func (s *Server) symbol(id string, marketId func(symbol string) string) (string, error) {
+	for _, symbol := range s.Symbols() {
+		if marketId(symbol) == id {
+			return symbol, nil
+		}
+	}
+	return "", TypedError("BadSymbol", "fake has no market "+id)
+}
+
+// currencies returns every currency code that is listed or held, sorted
+func (s *Server) currencies() (result []string) {
+	seen := map[string]bool{}
+	for _, symbol := range s.Symbols() {
+		base, quote, _ := paper.SplitSymbol(symbol)
+		seen[base], seen[quote] = true, true
+	}
+	for code := range s.Engine.Balance().Total {
+		seen[code] = true
+	}
+	for code := range seen {
+		result = append(result, code)
+	}
+	sort.Strings(result)
+	return
+}
+
+// levels returns at most limit levels of one side of a book, 0 for all
+func levels(side [][2]float64, limit int) [][2]float64 {
+	if limit > 0 && limit < len(side) {
+		return side[:limit]
+	}
+	return side
+}
+
+// stringLevels renders book levels as [["price","amount"], ...]
+func stringLevels(side [][2]float64) [][]string {
+	result := [][]string{}
+	for _, level := range side {
+		result = append(result, []string{formatFloat(level[0]), formatFloat(level[1])})
+	}
+	return result
+}
+
+// baseAmount is how much of the base currency cost buys walking the asks,
+// for exchanges that size market buys in the quote currency
+func (s *Server) baseAmount(symbol string, cost float64) float64 {
+	var amount float64
+	book := s.Book(symbol)
+	if book == nil {
+		return 0
+	}
+	for _, level := range book.Asks {
+		if cost <= level[0]*level[1] {
+			return amount + cost/level[0]
+		}
+		amount += level[1]
+		cost -= level[0] * level[1]
+	}
+	return amount
+}
+
+// place creates an order on the engine against the current book
+func (s *Server) place(symbol, typ, side string, amount, price float64, clientId string) (*Order, error) {
+	return s.placeIn(s.Engine, symbol, typ, side, amount, price, clientId)
+}
+
+// placeIn is place on one of the engines
+func (s *Server) placeIn(engine *paper.Engine, symbol, typ, side string, amount, price float64, clientId string) (*Order, error) {
+	book := s.Book(symbol)
+	if book == nil {
+		return nil, TypedError("BadSymbol", "fake has no market "+symbol)
+	}
+	order, err := engine.CreateOrder(symbol, typ, side, amount, price, book)
+	if err != nil {
+		return nil, err
+	}
+	if clientId != "" {
+		s.mu.Lock()
+		s.clientIds[clientKey{engine, order.Id}] = clientId
+		s.mu.Unlock()
+	}
+	return order, nil
+}
+
+// clientId returns the client order id order was placed with
+func (s *Server) clientId(order *Order) string {
+	return s.clientIdIn(s.Engine, order)
+}
+
+func (s *Server) clientIdIn(engine *paper.Engine, order *Order) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.clientIds[clientKey{engine, order.Id}]
+}
+
+// orders returns the orders of symbol (every symbol if empty) that have
+// one of the given statuses, oldest first
+func (s *Server) orders(symbol string, statuses ...string) []*Order {
+	return s.ordersIn(s.Engine, symbol, statuses...)
+}
+
+func (s *Server) ordersIn(engine *paper.Engine, symbol string, statuses ...string) (result []*Order) {
+	for id := engine.IdBase + 1; ; id++ {
+		order, err := engine.FetchOrder(strconv.FormatInt(id, 10))
+		if err != nil {
+			return
+		}
+		if symbol != "" && order.Symbol != symbol {
+			continue
+		}
+		for _, status := range statuses {
+			if order.Status == status {
+				result = append(result, order)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package fake
+
+import (
+	"fmt"
+	"strings"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// memoCurrencies are deposited to a shared address with a tag
+var memoCurrencies = map[string]bool{"XRP": true, "EOS": true, "XLM": true}
+
+// Address is a deposit address handed out by a fake server
+type Address struct {
+	Code    string
+	Network string
+	Address string
+	Tag     string
+}
+
+// Deposit is a deposit credited by a fake server
+type Deposit struct {
+	Id        string
+	Txid      string
+	Code      string
+	Network   string
+	Address   string
+	Tag       string
+	Amount    float64
+	Timestamp int64
+}
+
+// Withdrawal is a withdrawal accepted by a fake server
+type Withdrawal struct {
+	Id        string
+	Code      string
+	Network   string
+	Address   string
+	Tag       string
+	Amount    float64
+	Timestamp int64
+}
+
+func addressKey(code, network string) string {
+	return strings.ToUpper(code) + " " + strings.ToUpper(network)
+}
+
+// SetDepositAddress sets the deposit address of code on network, an empty
+// address is one the exchange is still generating
+func (s *Server) SetDepositAddress(code, network, address, tag string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addresses[addressKey(code, network)] = &Address{Code: code, Network: network, Address: address, Tag: tag}
+}
+
+// depositAddress returns the address of code on network, creating one if
+// create is set, or nil
+func (s *Server) depositAddress(code, network string, create bool) *Address {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := addressKey(code, network)
+	if s.addresses[key] == nil && create {
+		address := &Address{Code: code, Network: network, Address: fmt.Sprintf("%sdeposit%d", strings.ToLower(code), len(s.addresses)+1)}
+		if memoCurrencies[code] {
+			address.Address = strings.ToLower(code) + "deposit"
+			address.Tag = fmt.Sprintf("%d", 100000+len(s.addresses))
+		}
+		s.addresses[key] = address
+	}
+	return s.addresses[key]
+}
+
+// Deposit credits amount of code arriving on network to its deposit
+// address, as if the transaction had been confirmed
+func (s *Server) Deposit(code, network string, amount float64) *Deposit {
+	address := s.depositAddress(code, network, true)
+	s.Engine.Deposit(code, amount)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	deposit := &Deposit{
+		Id:        fmt.Sprintf("%d", 500000+len(s.deposits)),
+		Txid:      fmt.Sprintf("0x%064x", 500000+len(s.deposits)),
+		Code:      code,
+		Network:   network,
+		Address:   address.Address,
+		Tag:       address.Tag,
+		Amount:    amount,
+		Timestamp: s.Engine.Now(),
+	}
+	s.deposits = append(s.deposits, deposit)
+	return deposit
+}
+
+// Deposits returns the credited deposits in the order they arrived
+func (s *Server) Deposits() []Deposit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Deposit, len(s.deposits))
+	for i, deposit := range s.deposits {
+		result[i] = *deposit
+	}
+	return result
+}
+
+// withdraw checks the address and takes amount out of the balance
+func (s *Server) withdraw(code, network, address, tag string, amount float64) (*Withdrawal, error) {
+	if address == "" || strings.HasPrefix(address, "invalid") || memoCurrencies[code] && tag == "" {
+		return nil, TypedError("InvalidAddress", "fake rejects address "+address)
+	}
+	if err := s.Engine.Withdraw(code, amount); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	withdrawal := &Withdrawal{
+		Id:        fmt.Sprintf("%d", 700000+len(s.withdrawals)),
+		Code:      code,
+		Network:   network,
+		Address:   address,
+		Tag:       tag,
+		Amount:    amount,
+		Timestamp: s.Engine.Now(),
+	}
+	s.withdrawals = append(s.withdrawals, withdrawal)
+	return withdrawal, nil
+}
+
+// Withdrawals returns the accepted withdrawals in the order they were made
+func (s *Server) Withdrawals() []Withdrawal {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Withdrawal, len(s.withdrawals))
+	for i, withdrawal := range s.withdrawals {
+		result[i] = *withdrawal
+	}
+	return result
+}
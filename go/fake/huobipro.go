@@ -0,0 +1,376 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+// huobiAccountId is the id of the spot account, huobiSuperMarginId that of
+// the cross margin one
+const (
+	huobiAccountId     = 1000001
+	huobiSuperMarginId = 1000002
+)
+
+var huobiErrors = map[string]apiError{
+	"AuthenticationError": {200, "api-signature-not-valid", "Signature not valid: Verification failure"},
+	"InsufficientFunds":   {200, "account-frozen-balance-insufficient-error", "trade account balance is not enough"},
+	"OrderNotFound":       {200, "base-record-invalid", "record invalid"},
+	"BadSymbol":           {200, "invalid symbol", "invalid symbol"},
+	"InvalidOrder":        {200, "invalid-amount", "invalid amount"},
+	"InvalidAddress":      {200, "invalid-address", "invalid address"},
+	"BadRequest":          {200, "bad-request", "bad request"},
+}
+
+// NewHuobipro emulates the huobi pro spot api under /v1 and /market, and
+// deposit addresses under /v2. Orders on the super margin account, which
+// starts empty, come from the same id space as spot ones.
+func NewHuobipro(config *Config) *Server {
+	s := newTradingServer(config, (*Server).huobipro, []string{"super-margin"})
+	s.engines["super-margin"].IdBase = 500000000
+	return s
+}
+
+// huobiAccount returns the engine and the type of an account id, nil if
+// there is no such account
+func (s *Server) huobiAccount(id string) (*paper.Engine, string) {
+	switch id {
+	case strconv.Itoa(huobiAccountId):
+		return s.Engine, "spot"
+	case strconv.Itoa(huobiSuperMarginId):
+		return s.engines["super-margin"], "super-margin"
+	}
+	return nil, ""
+}
+
+// huobiFind looks order id up on every account
+func (s *Server) huobiFind(id string) (*paper.Engine, *Order, error) {
+	for _, engine := range []*paper.Engine{s.Engine, s.engines["super-margin"]} {
+		if order, err := engine.FetchOrder(id); err == nil {
+			return engine, order, nil
+		}
+	}
+	return nil, nil, OrderNotFound
+}
+
+func huobiMarketId(symbol string) string {
+	return strings.ToLower(strings.Replace(symbol, "/", "", 1))
+}
+
+func (s *Server) huobiFail(w http.ResponseWriter, err error) {
+	e := huobiErrors[errorClass(err)]
+	writeJSON(w, e.status, map[string]interface{}{"status": "error", "err-code": e.code, "err-msg": e.message})
+}
+
+func huobiOk(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, 200, map[string]interface{}{"status": "ok", "data": data})
+}
+
+// huobiAuth checks the signature version 2 query: the base64 signature of
+// method, host, path and the query without Signature, newline separated
+func (s *Server) huobiAuth(r *request) bool {
+	query := r.URL.RawQuery
+	i := strings.LastIndex(query, "&Signature=")
+	if i < 0 || r.params["AccessKeyId"] != s.config.ApiKey {
+		return false
+	}
+	signature, err := url.QueryUnescape(query[i+len("&Signature="):])
+	if err != nil {
+		return false
+	}
+	payload := strings.Join([]string{r.Method, r.Host, r.URL.Path, query[:i]}, "\n")
+	return checkHmac(s.config.Secret, payload, signature, "base64")
+}
+
+func (s *Server) huobiOrder(engine *paper.Engine, order *Order) map[string]interface{} {
+	state := "submitted"
+	switch {
+	case order.Status == "canceled" && order.Filled > 0:
+		state = "partial-canceled"
+	case order.Status == "canceled":
+		state = "canceled"
+	case order.Status == "closed":
+		state = "filled"
+	case order.Filled > 0:
+		state = "partial-filled"
+	}
+	accountId, source := huobiAccountId, "spot-api"
+	if engine != s.Engine {
+		accountId, source = huobiSuperMarginId, "super-margin-api"
+	}
+	return map[string]interface{}{
+		"id":                json.Number(order.Id),
+		"symbol":            huobiMarketId(order.Symbol),
+		"account-id":        accountId,
+		"amount":            formatFloat(order.Amount),
+		"price":             formatFloat(order.Price),
+		"created-at":        order.Timestamp,
+		"type":              order.Side + "-" + order.Type,
+		"field-amount":      formatFloat(order.Filled),
+		"field-cash-amount": formatFloat(order.Cost),
+		"field-fees":        formatFloat(order.Fee),
+		"source":            source,
+		"state":             state,
+	}
+}
+
+func (s *Server) huobipro(w http.ResponseWriter, r *request) {
+	var symbol string
+	if id, ok := r.params["symbol"]; ok {
+		var err error
+		if symbol, err = s.symbol(id, huobiMarketId); err != nil {
+			s.huobiFail(w, err)
+			return
+		}
+	}
+
+	switch r.URL.Path {
+	case "/v1/common/timestamp":
+		huobiOk(w, s.Engine.Now())
+		return
+	case "/v1/settings/currencys":
+		result := []interface{}{}
+		for _, code := range s.currencies() {
+			result = append(result, map[string]interface{}{
+				"name":                strings.ToLower(code),
+				"display-name":        code,
+				"withdraw-precision":  8,
+				"currency-type":       "eth",
+				"currency-partition":  "pro",
+				"deposit-min-amount":  "0",
+				"withdraw-min-amount": "0",
+				"visible":             true,
+				"deposit-enabled":     true,
+				"withdraw-enabled":    true,
+			})
+		}
+		huobiOk(w, result)
+		return
+	case "/v1/common/symbols":
+		result := []interface{}{}
+		for _, symbol := range s.Symbols() {
+			base, quote, _ := paper.SplitSymbol(symbol)
+			result = append(result, map[string]interface{}{
+				"base-currency":    strings.ToLower(base),
+				"quote-currency":   strings.ToLower(quote),
+				"price-precision":  8,
+				"amount-precision": 8,
+				"value-precision":  8,
+				"symbol-partition": "main",
+				"symbol":           huobiMarketId(symbol),
+				"state":            "online",
+				"min-order-amt":    0.00000001,
+				"max-order-amt":    10000000,
+				"min-order-value":  0,
+			})
+		}
+		huobiOk(w, result)
+		return
+	case "/market/depth":
+		book := s.Book(symbol)
+		if book == nil {
+			s.huobiFail(w, BadSymbol)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"status": "ok",
+			"ch":     "market." + huobiMarketId(symbol) + ".depth." + r.params["type"],
+			"ts":     s.Engine.Now(),
+			"tick": map[string]interface{}{
+				"bids":    book.Bids,
+				"asks":    book.Asks,
+				"ts":      s.Engine.Now(),
+				"version": s.Engine.Now(),
+			},
+		})
+		return
+	}
+
+	if !s.huobiAuth(r) {
+		s.huobiFail(w, AuthenticationError)
+		return
+	}
+	path := strings.Split(strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/v1/"), "/v2/"), "/")
+	route := r.Method + " " + strings.Join(path, "/")
+	switch {
+	case route == "GET account/accounts":
+		// huobi does not list the spot account first
+		huobiOk(w, []interface{}{
+			map[string]interface{}{"id": huobiSuperMarginId, "type": "super-margin", "subtype": "", "state": "working"},
+			map[string]interface{}{"id": huobiAccountId, "type": "spot", "subtype": "", "state": "working"},
+		})
+	case len(path) == 4 && route == "GET account/accounts/"+path[2]+"/balance":
+		engine, typ := s.huobiAccount(path[2])
+		if engine == nil {
+			s.huobiFail(w, BadRequest)
+			return
+		}
+		balance := engine.Balance()
+		list := []interface{}{}
+		for _, code := range s.currencies() {
+			list = append(list,
+				map[string]interface{}{"currency": strings.ToLower(code), "type": "trade", "balance": formatFloat(balance.Free[code])},
+				map[string]interface{}{"currency": strings.ToLower(code), "type": "frozen", "balance": formatFloat(balance.Used[code])},
+			)
+		}
+		huobiOk(w, map[string]interface{}{"id": json.Number(path[2]), "type": typ, "state": "working", "list": list})
+	case route == "POST order/orders/place":
+		parts := strings.Split(r.params["type"], "-")
+		if len(parts) != 2 {
+			s.huobiFail(w, InvalidOrder)
+			return
+		}
+		// super margin orders must say so in their source
+		engine, typ := s.huobiAccount(r.params["account-id"])
+		if engine == nil || typ == "super-margin" && r.params["source"] != "super-margin-api" {
+			s.huobiFail(w, BadRequest)
+			return
+		}
+		amount := parseFloat(r.params["amount"])
+		if parts[0] == "buy" && parts[1] == "market" {
+			amount = s.baseAmount(symbol, amount)
+		}
+		order, err := s.placeIn(engine, symbol, parts[1], parts[0], amount, parseFloat(r.params["price"]), r.params["client-order-id"])
+		if err != nil {
+			s.huobiFail(w, err)
+			return
+		}
+		huobiOk(w, order.Id)
+	case route == "GET order/orders":
+		states := strings.Split(r.params["states"], ",")
+		result := []interface{}{}
+		for _, engine := range []*paper.Engine{s.Engine, s.engines["super-margin"]} {
+			for _, order := range s.ordersIn(engine, symbol, "open", "closed", "canceled") {
+				o := s.huobiOrder(engine, order)
+				for _, state := range states {
+					if o["state"] == state {
+						result = append(result, o)
+						break
+					}
+				}
+			}
+		}
+		huobiOk(w, result)
+	case len(path) == 3 && route == "GET order/orders/"+path[2],
+		len(path) == 4 && route == "POST order/orders/"+path[2]+"/submitcancel":
+		engine, order, err := s.huobiFind(path[2])
+		if err == nil && r.Method == "POST" {
+			order, err = engine.CancelOrder(path[2])
+		}
+		if err != nil {
+			s.huobiFail(w, err)
+			return
+		}
+		if r.Method == "POST" {
+			huobiOk(w, order.Id)
+		} else {
+			huobiOk(w, s.huobiOrder(engine, order))
+		}
+	case route == "GET account/deposit/address":
+		code := strings.ToUpper(r.params["currency"])
+		data := []interface{}{}
+		if address := s.depositAddress(code, "", true); address.Address != "" {
+			data = append(data, map[string]interface{}{
+				"currency":   strings.ToLower(code),
+				"address":    address.Address,
+				"addressTag": address.Tag,
+				"chain":      strings.ToLower(code),
+			})
+		}
+		writeJSON(w, 200, map[string]interface{}{"code": 200, "data": data})
+	case route == "POST dw/withdraw/api/create":
+		code := strings.ToUpper(r.params["currency"])
+		network := strings.ToUpper(strings.TrimSuffix(r.params["chain"], strings.ToLower(code)))
+		withdrawal, err := s.withdraw(code, network, r.params["address"], r.params["addr-tag"], parseFloat(r.params["amount"]))
+		if err != nil {
+			s.huobiFail(w, err)
+			return
+		}
+		huobiOk(w, json.Number(withdrawal.Id))
+	case route == "GET query/deposit-withdraw":
+		var transactions []map[string]interface{}
+		if r.params["type"] == "deposit" {
+			for _, deposit := range s.Deposits() {
+				transactions = append(transactions, map[string]interface{}{
+					"id":          json.Number(deposit.Id),
+					"type":        "deposit",
+					"currency":    strings.ToLower(deposit.Code),
+					"chain":       huobiChain(deposit.Code, deposit.Network),
+					"tx-hash":     deposit.Txid,
+					"amount":      deposit.Amount,
+					"address":     deposit.Address,
+					"address-tag": deposit.Tag,
+					"fee":         0,
+					"state":       "safe",
+					"created-at":  deposit.Timestamp,
+					"updated-at":  deposit.Timestamp,
+				})
+			}
+		} else {
+			for _, withdrawal := range s.Withdrawals() {
+				transactions = append(transactions, map[string]interface{}{
+					"id":          json.Number(withdrawal.Id),
+					"type":        "withdraw",
+					"currency":    strings.ToLower(withdrawal.Code),
+					"chain":       huobiChain(withdrawal.Code, withdrawal.Network),
+					"tx-hash":     "",
+					"amount":      withdrawal.Amount,
+					"address":     withdrawal.Address,
+					"address-tag": withdrawal.Tag,
+					"fee":         0,
+					"state":       "submitted",
+					"created-at":  withdrawal.Timestamp,
+					"updated-at":  withdrawal.Timestamp,
+				})
+			}
+		}
+		// newest first, from is the first id of the page: prev pages back
+		// from it, next forward in ascending order
+		sort.Slice(transactions, func(i, j int) bool {
+			return parseFloat(string(transactions[i]["id"].(json.Number))) > parseFloat(string(transactions[j]["id"].(json.Number)))
+		})
+		if r.params["direct"] == "next" {
+			for i, j := 0, len(transactions)-1; i < j; i, j = i+1, j-1 {
+				transactions[i], transactions[j] = transactions[j], transactions[i]
+			}
+		}
+		size := int(parseFloat(r.params["size"]))
+		if size == 0 {
+			size = 100
+		}
+		if size > 500 {
+			s.huobiFail(w, TypedError("BadRequest", "fake size is at most 500"))
+			return
+		}
+		data := []interface{}{}
+		for _, transaction := range transactions {
+			id := parseFloat(string(transaction["id"].(json.Number)))
+			if from := r.params["from"]; from != "" && (r.params["direct"] == "next" && id < parseFloat(from) || r.params["direct"] != "next" && id > parseFloat(from)) {
+				continue
+			}
+			if r.params["currency"] != "" && transaction["currency"] != r.params["currency"] || len(data) >= size {
+				continue
+			}
+			data = append(data, transaction)
+		}
+		huobiOk(w, data)
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
+
+// huobiChain names the chain of a network, e.g. trc20usdt, or btc for the
+// native chain of a currency
+func huobiChain(code, network string) string {
+	if network == "" || strings.EqualFold(network, code) {
+		return strings.ToLower(code)
+	}
+	return strings.ToLower(network + code)
+}
@@ -0,0 +1,339 @@
+package fake
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+var kucoinErrors = map[string]apiError{
+	"AuthenticationError": {401, "400005", "Invalid KC-API-SIGN"},
+	"InsufficientFunds":   {400, "200004", "Balance insufficient!"},
+	"OrderNotFound":       {404, "400100", "order_not_exist"},
+	"BadSymbol":           {400, "400000", "Unsupported trading pair."},
+	"InvalidOrder":        {400, "400100", "Order size below the minimum requirement."},
+	"InvalidAddress":      {400, "260000", "Withdrawal address is invalid."},
+	"BadRequest":          {400, "400", "Bad Request"},
+}
+
+// NewKucoin emulates the kucoin v1 spot api under /api/v1 and inner
+// transfers under /api/v2. Deposit addresses have to be created before they
+// can be fetched, as on kucoin. The main and margin accounts only hold what
+// is transferred to them, margin orders come from the same id space as
+// spot ones.
+func NewKucoin(config *Config) *Server {
+	s := newTradingServer(config, (*Server).kucoin, []string{"margin"}, "main")
+	s.engines["margin"].IdBase = 500000000
+	return s
+}
+
+// kucoinEngine is the engine of a trade type, TRADE or MARGIN_TRADE
+func (s *Server) kucoinEngine(tradeType string) *paper.Engine {
+	if tradeType == "MARGIN_TRADE" {
+		return s.engines["margin"]
+	}
+	return s.Engine
+}
+
+func kucoinMarketId(symbol string) string {
+	return strings.Replace(symbol, "/", "-", 1)
+}
+
+func (s *Server) kucoinFail(w http.ResponseWriter, err error) {
+	e := kucoinErrors[errorClass(err)]
+	writeJSON(w, e.status, map[string]interface{}{"code": e.code, "msg": e.message})
+}
+
+func kucoinOk(w http.ResponseWriter, data interface{}) {
+	writeJSON(w, 200, map[string]interface{}{"code": "200000", "data": data})
+}
+
+// kucoinAuth checks the key headers and the base64 signature of
+// timestamp + method + endpoint (+ query) + body
+func (s *Server) kucoinAuth(r *request) bool {
+	if r.Header.Get("KC-API-KEY") != s.config.ApiKey || r.Header.Get("KC-API-PASSPHRASE") != s.config.Password {
+		return false
+	}
+	payload := r.Header.Get("KC-API-TIMESTAMP") + r.Method + r.URL.Path
+	if r.URL.RawQuery != "" {
+		payload += "?" + r.URL.RawQuery
+	}
+	payload += r.body
+	return checkHmac(s.config.Secret, payload, r.Header.Get("KC-API-SIGN"), "base64")
+}
+
+func (s *Server) kucoinOrder(engine *paper.Engine, order *Order) map[string]interface{} {
+	_, quote, _ := paper.SplitSymbol(order.Symbol)
+	tradeType := "TRADE"
+	if engine != s.Engine {
+		tradeType = "MARGIN_TRADE"
+	}
+	return map[string]interface{}{
+		"id":          order.Id,
+		"symbol":      kucoinMarketId(order.Symbol),
+		"opType":      "DEAL",
+		"type":        order.Type,
+		"side":        order.Side,
+		"price":       formatFloat(order.Price),
+		"size":        formatFloat(order.Amount),
+		"funds":       "0",
+		"dealFunds":   formatFloat(order.Cost),
+		"dealSize":    formatFloat(order.Filled),
+		"fee":         formatFloat(order.Fee),
+		"feeCurrency": quote,
+		"timeInForce": "GTC",
+		"channel":     "API",
+		"clientOid":   s.clientIdIn(engine, order),
+		"isActive":    order.Status == "open",
+		"cancelExist": order.Status == "canceled",
+		"createdAt":   order.Timestamp,
+		"tradeType":   tradeType,
+	}
+}
+
+func (s *Server) kucoin(w http.ResponseWriter, r *request) {
+	var symbol string
+	if id, ok := r.params["symbol"]; ok {
+		var err error
+		if symbol, err = s.symbol(id, kucoinMarketId); err != nil {
+			s.kucoinFail(w, err)
+			return
+		}
+	}
+
+	path := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/api/v1/"), "/api/v2/")
+	if path == "timestamp" {
+		kucoinOk(w, s.Engine.Now())
+		return
+	}
+	if strings.HasPrefix(path, "market/orderbook/level2_") {
+		book := s.Book(symbol)
+		if book == nil {
+			s.kucoinFail(w, BadSymbol)
+			return
+		}
+		limit := int(parseFloat(strings.TrimPrefix(path, "market/orderbook/level2_")))
+		kucoinOk(w, map[string]interface{}{
+			"time":     s.Engine.Now(),
+			"sequence": formatFloat(float64(s.Engine.Now())),
+			"bids":     stringLevels(levels(book.Bids, limit)),
+			"asks":     stringLevels(levels(book.Asks, limit)),
+		})
+		return
+	}
+
+	if !s.kucoinAuth(r) {
+		s.kucoinFail(w, AuthenticationError)
+		return
+	}
+	route := r.Method + " " + path
+	switch {
+	case route == "GET accounts":
+		result := []interface{}{}
+		for _, typ := range []string{"main", "trade", "margin"} {
+			if r.params["type"] != "" && r.params["type"] != typ {
+				continue
+			}
+			engine := s.accountEngine(typ, []string{"trade"})
+			for _, code := range s.currencies() {
+				total, free, used := s.AccountBalance(typ, code), s.AccountBalance(typ, code), 0.0
+				if engine != nil {
+					balance := engine.Balance()
+					total, free, used = balance.Total[code], balance.Free[code], balance.Used[code]
+				}
+				result = append(result, map[string]interface{}{
+					"id":        strings.ToLower(code) + "-" + typ,
+					"currency":  code,
+					"type":      typ,
+					"balance":   formatFloat(total),
+					"available": formatFloat(free),
+					"holds":     formatFloat(used),
+				})
+			}
+		}
+		kucoinOk(w, result)
+	case route == "POST orders", route == "POST margin/order":
+		amount := parseFloat(r.params["size"])
+		if r.params["funds"] != "" {
+			amount = s.baseAmount(symbol, parseFloat(r.params["funds"]))
+		}
+		engine := s.Engine
+		if route == "POST margin/order" {
+			engine = s.engines["margin"]
+		}
+		order, err := s.placeIn(engine, symbol, r.params["type"], r.params["side"], amount, parseFloat(r.params["price"]), r.params["clientOid"])
+		if err != nil {
+			s.kucoinFail(w, err)
+			return
+		}
+		kucoinOk(w, map[string]interface{}{"orderId": order.Id})
+	case route == "GET orders":
+		statuses := []string{"open", "closed", "canceled"}
+		switch r.params["status"] {
+		case "active":
+			statuses = []string{"open"}
+		case "done":
+			statuses = []string{"closed", "canceled"}
+		}
+		items := []interface{}{}
+		engine := s.kucoinEngine(r.params["tradeType"])
+		for _, order := range s.ordersIn(engine, symbol, statuses...) {
+			items = append(items, s.kucoinOrder(engine, order))
+		}
+		kucoinOk(w, map[string]interface{}{
+			"currentPage": 1,
+			"pageSize":    len(items),
+			"totalNum":    len(items),
+			"totalPage":   1,
+			"items":       items,
+		})
+	case strings.HasPrefix(route, "GET orders/"), strings.HasPrefix(route, "DELETE orders/"):
+		id := strings.TrimPrefix(path, "orders/")
+		engine := s.Engine
+		if _, err := engine.FetchOrder(id); err != nil {
+			engine = s.engines["margin"]
+		}
+		order, err := engine.FetchOrder(id)
+		if err == nil && r.Method == "DELETE" {
+			order, err = engine.CancelOrder(id)
+		}
+		if err != nil {
+			s.kucoinFail(w, err)
+			return
+		}
+		if r.Method == "DELETE" {
+			kucoinOk(w, map[string]interface{}{"cancelledOrderIds": []string{order.Id}})
+		} else {
+			kucoinOk(w, s.kucoinOrder(engine, order))
+		}
+	case route == "GET deposit-addresses", route == "POST deposit-addresses":
+		address := s.depositAddress(r.params["currency"], r.params["chain"], r.Method == "POST")
+		if address == nil {
+			kucoinOk(w, nil)
+			return
+		}
+		kucoinOk(w, map[string]interface{}{
+			"address": address.Address,
+			"memo":    address.Tag,
+			"chain":   r.params["chain"],
+		})
+	case route == "POST withdrawals":
+		withdrawal, err := s.withdraw(r.params["currency"], strings.ToUpper(r.params["chain"]), r.params["address"], r.params["memo"], parseFloat(r.params["amount"]))
+		if err != nil {
+			s.kucoinFail(w, err)
+			return
+		}
+		kucoinOk(w, map[string]interface{}{"withdrawalId": withdrawal.Id})
+	case route == "GET deposits", route == "GET withdrawals":
+		var transactions []map[string]interface{}
+		if path == "deposits" {
+			for _, deposit := range s.Deposits() {
+				transactions = append(transactions, map[string]interface{}{
+					"address":    deposit.Address,
+					"memo":       deposit.Tag,
+					"amount":     formatFloat(deposit.Amount),
+					"fee":        "0",
+					"currency":   deposit.Code,
+					"chain":      strings.ToLower(deposit.Network),
+					"isInner":    false,
+					"walletTxId": deposit.Txid + "@0",
+					"status":     "SUCCESS",
+					"createdAt":  deposit.Timestamp,
+					"updatedAt":  deposit.Timestamp,
+				})
+			}
+		} else {
+			for _, withdrawal := range s.Withdrawals() {
+				transactions = append(transactions, map[string]interface{}{
+					"id":         withdrawal.Id,
+					"address":    withdrawal.Address,
+					"memo":       withdrawal.Tag,
+					"amount":     formatFloat(withdrawal.Amount),
+					"fee":        "0",
+					"currency":   withdrawal.Code,
+					"chain":      strings.ToLower(withdrawal.Network),
+					"isInner":    false,
+					"walletTxId": "",
+					"status":     "PROCESSING",
+					"createdAt":  withdrawal.Timestamp,
+					"updatedAt":  withdrawal.Timestamp,
+				})
+			}
+		}
+		items := []interface{}{}
+		for _, transaction := range transactions {
+			if r.params["currency"] != "" && transaction["currency"] != r.params["currency"] || transaction["createdAt"].(int64) < int64(parseFloat(r.params["startAt"])) {
+				continue
+			}
+			items = append(items, transaction)
+		}
+		kucoinOk(w, map[string]interface{}{
+			"currentPage": 1,
+			"pageSize":    len(items),
+			"totalNum":    len(items),
+			"totalPage":   1,
+			"items":       items,
+		})
+	case route == "GET accounts/ledgers":
+		// a transfer is an out entry on one account and an in entry on the
+		// other, newest first
+		entries := []interface{}{}
+		transfers := s.Transfers()
+		for i := len(transfers) - 1; i >= 0; i-- {
+			transfer := transfers[i]
+			if r.params["bizType"] != "Transfer" || r.params["currency"] != "" && transfer.Code != r.params["currency"] || transfer.Timestamp < int64(parseFloat(r.params["startAt"])) {
+				continue
+			}
+			for n, account := range []string{transfer.From, transfer.To} {
+				entries = append(entries, map[string]interface{}{
+					"id":          fmt.Sprintf("%s%d", transfer.Id, n),
+					"currency":    transfer.Code,
+					"amount":      formatFloat(transfer.Amount),
+					"fee":         "0",
+					"balance":     "0",
+					"accountType": strings.ToUpper(account),
+					"bizType":     "Transfer",
+					"direction":   []string{"out", "in"}[n],
+					"createdAt":   transfer.Timestamp,
+					"context":     "",
+				})
+			}
+		}
+		page, size := int(parseFloat(r.params["currentPage"])), int(parseFloat(r.params["pageSize"]))
+		if page < 1 {
+			page = 1
+		}
+		if size < 1 {
+			size = 50
+		}
+		totalPage := (len(entries) + size - 1) / size
+		items := []interface{}{}
+		if start := (page - 1) * size; start < len(entries) {
+			items = entries[start:int(math.Min(float64(start+size), float64(len(entries))))]
+		}
+		kucoinOk(w, map[string]interface{}{
+			"currentPage": page,
+			"pageSize":    size,
+			"totalNum":    len(entries),
+			"totalPage":   totalPage,
+			"items":       items,
+		})
+	case route == "POST accounts/inner-transfer":
+		if r.params["clientOid"] == "" {
+			s.kucoinFail(w, BadRequest)
+			return
+		}
+		transfer, err := s.transfer(r.params["currency"], r.params["from"], r.params["to"], parseFloat(r.params["amount"]), "trade")
+		if err != nil {
+			s.kucoinFail(w, err)
+			return
+		}
+		kucoinOk(w, map[string]interface{}{"orderId": transfer.Id})
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
@@ -0,0 +1,128 @@
+package fake
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// Loan is what is owed on one currency of a margin account of a fake server
+type Loan struct {
+	Principal float64
+	Interest  float64
+}
+
+// InterestCharge is one accrual of interest on a loan
+type InterestCharge struct {
+	Account   string
+	Code      string
+	Principal float64
+	Rate      float64
+	Interest  float64
+	Timestamp int64
+}
+
+// loans are keyed by account and code
+func loanKey(account, code string) string {
+	return account + " " + code
+}
+
+// borrow lends amount of code to a margin account, up to
+// Config.BorrowLimits
+func (s *Server) borrow(account, code string, amount float64) (*Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.known(account) || amount <= 0 {
+		return nil, TypedError("BadRequest", fmt.Sprintf("fake cannot lend %v %s to %s", amount, code, account))
+	}
+	loan := s.loans[loanKey(account, code)]
+	if loan == nil {
+		loan = &Loan{}
+	}
+	if loan.Principal+amount > s.config.BorrowLimits[code] {
+		return nil, TypedError("InsufficientFunds", fmt.Sprintf("fake %s borrowed %v + %v exceeds the limit of %v", code, loan.Principal, amount, s.config.BorrowLimits[code]))
+	}
+	loan.Principal += amount
+	s.loans[loanKey(account, code)] = loan
+	s.credit(account, code, amount)
+	copied := *loan
+	return &copied, nil
+}
+
+// repay pays interest first and then principal out of the margin account,
+// amount may not exceed what is owed
+func (s *Server) repay(account, code string, amount float64) (*Loan, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	loan := s.loans[loanKey(account, code)]
+	if loan == nil || amount <= 0 || amount > loan.Principal+loan.Interest+1e-12 {
+		return nil, TypedError("BadRequest", fmt.Sprintf("fake %s repaid %v is more than owed", code, amount))
+	}
+	if err := s.debit(account, code, amount); err != nil {
+		return nil, err
+	}
+	interest := math.Min(amount, loan.Interest)
+	loan.Interest -= interest
+	loan.Principal = math.Max(loan.Principal-(amount-interest), 0)
+	copied := *loan
+	return &copied, nil
+}
+
+// Loan returns what is owed on code by a margin account
+func (s *Server) Loan(account, code string) Loan {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if loan := s.loans[loanKey(account, code)]; loan != nil {
+		return *loan
+	}
+	return Loan{}
+}
+
+// maxBorrowable is how much more of code the limit allows to borrow
+func (s *Server) maxBorrowable(account, code string) float64 {
+	return math.Max(s.config.BorrowLimits[code]-s.Loan(account, code).Principal, 0)
+}
+
+// AccrueInterest charges one period of Config.BorrowRate on every
+// outstanding principal, as the exchange does once a day
+func (s *Server) AccrueInterest() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	keys := []string{}
+	for key := range s.loans {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	now := s.Engine.Now()
+	for _, key := range keys {
+		loan := s.loans[key]
+		if loan.Principal <= 0 {
+			continue
+		}
+		var account, code string
+		fmt.Sscan(key, &account, &code)
+		interest := loan.Principal * s.config.BorrowRate
+		loan.Interest += interest
+		s.charges = append(s.charges, &InterestCharge{
+			Account:   account,
+			Code:      code,
+			Principal: loan.Principal,
+			Rate:      s.config.BorrowRate,
+			Interest:  interest,
+			Timestamp: now,
+		})
+	}
+}
+
+// InterestCharges returns every accrual of interest so far, oldest first
+func (s *Server) InterestCharges() []InterestCharge {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]InterestCharge, len(s.charges))
+	for i, charge := range s.charges {
+		result[i] = *charge
+	}
+	return result
+}
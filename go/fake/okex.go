@@ -0,0 +1,759 @@
+package fake
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+var okexErrors = map[string]apiError{
+	"AuthenticationError": {401, "30013", "Invalid Sign"},
+	"InsufficientFunds":   {400, "33017", "Insufficient balance"},
+	"OrderNotFound":       {400, "33014", "Order does not exist"},
+	"BadSymbol":           {400, "30032", "The currency pair is suspended"},
+	"InvalidOrder":        {400, "33013", "Order placement failed"},
+	"InvalidAddress":      {400, "34002", "Please add a withdrawal address"},
+	"BadRequest":          {400, "30023", "Required parameter cannot be blank"},
+}
+
+// NewOkex emulates the okex v3 spot api under /api/spot/v3, deposits,
+// withdrawals and transfers under /api/account/v3 and margin loans under
+// /api/margin/v3. Futures and swap instruments are those of
+// Config.Contracts, with the positions the test sets with SetPosition and
+// the leverage and margin mode set through their accounts/ routes. The
+// accounts other than spot ("1") only hold what is
+// transferred to them. Each instrument has an isolated margin account
+// ("5:" and its id) that trades under /api/margin/v3 and only holds what is
+// borrowed on it.
+func NewOkex(config *Config) *Server {
+	margin := []string{}
+	if config != nil {
+		for symbol := range config.Books {
+			margin = append(margin, "5:"+okexMarketId(symbol))
+		}
+	}
+	return newTradingServer(config, (*Server).okex, margin, "3", "5", "6", "9", "12")
+}
+
+func okexMarketId(symbol string) string {
+	return strings.Replace(symbol, "/", "-", 1)
+}
+
+func (s *Server) okexFail(w http.ResponseWriter, err error) {
+	e := okexErrors[errorClass(err)]
+	writeJSON(w, e.status, map[string]interface{}{"code": json.Number(e.code), "message": e.message})
+}
+
+// okexAuth checks the access headers and the base64 signature of
+// timestamp + method + path (+ query) + body
+func (s *Server) okexAuth(r *request) bool {
+	if r.Header.Get("OK-ACCESS-KEY") != s.config.ApiKey || r.Header.Get("OK-ACCESS-PASSPHRASE") != s.config.Password {
+		return false
+	}
+	auth := r.Header.Get("OK-ACCESS-TIMESTAMP") + r.Method + r.URL.Path
+	if r.URL.RawQuery != "" {
+		auth += "?" + r.URL.RawQuery
+	}
+	auth += r.body
+	return checkHmac(s.config.Secret, auth, r.Header.Get("OK-ACCESS-SIGN"), "base64")
+}
+
+func (s *Server) okexOrder(engine *paper.Engine, order *Order) map[string]interface{} {
+	state := "0"
+	switch {
+	case order.Status == "canceled":
+		state = "-1"
+	case order.Status == "closed":
+		state = "2"
+	case order.Filled > 0:
+		state = "1"
+	}
+	var average float64
+	if order.Filled > 0 {
+		average = order.Cost / order.Filled
+	}
+	_, quote, _ := paper.SplitSymbol(order.Symbol)
+	return map[string]interface{}{
+		"order_id":        order.Id,
+		"client_oid":      s.clientIdIn(engine, order),
+		"created_at":      iso8601(order.Timestamp),
+		"timestamp":       iso8601(order.Timestamp),
+		"instrument_id":   okexMarketId(order.Symbol),
+		"product_id":      okexMarketId(order.Symbol),
+		"price":           formatFloat(order.Price),
+		"price_avg":       formatFloat(average),
+		"size":            formatFloat(order.Amount),
+		"filled_size":     formatFloat(order.Filled),
+		"filled_notional": formatFloat(order.Cost),
+		"side":            order.Side,
+		"type":            order.Type,
+		"order_type":      "0",
+		"state":           state,
+		"fee":             formatFloat(-order.Fee),
+		"fee_currency":    quote,
+	}
+}
+
+func (s *Server) okex(w http.ResponseWriter, r *request) {
+	path := r.URL.Path
+	switch {
+	case path == "/api/general/v3/time":
+		now := s.Engine.Now()
+		writeJSON(w, 200, map[string]interface{}{"iso": iso8601(now), "epoch": formatFloat(float64(now) / 1000)})
+		return
+	case strings.HasSuffix(path, "/instruments"):
+		if typ := strings.TrimSuffix(strings.TrimPrefix(path, "/api/"), "/v3/instruments"); typ == "futures" || typ == "swap" {
+			writeJSON(w, 200, s.okexContracts(typ))
+			return
+		} else if typ != "spot" {
+			writeJSON(w, 200, []interface{}{})
+			return
+		}
+		result := []interface{}{}
+		for _, symbol := range s.Symbols() {
+			base, quote, _ := paper.SplitSymbol(symbol)
+			result = append(result, map[string]interface{}{
+				"instrument_id":  okexMarketId(symbol),
+				"base_currency":  base,
+				"quote_currency": quote,
+				"category":       "1",
+				"min_size":       "0.00000001",
+				"size_increment": "0.00000001",
+				"tick_size":      "0.00000001",
+			})
+		}
+		writeJSON(w, 200, result)
+		return
+	case strings.HasSuffix(path, "/mark_price"):
+		// the mark price is the one the test gave the positions, 0 without any
+		id := path[strings.LastIndex(strings.TrimSuffix(path, "/mark_price"), "/")+1 : len(path)-len("/mark_price")]
+		markPrice := 0.0
+		for _, position := range s.openPositions(id) {
+			markPrice = position.MarkPrice
+		}
+		writeJSON(w, 200, map[string]interface{}{"instrument_id": id, "mark_price": formatFloat(markPrice), "timestamp": iso8601(s.Engine.Now())})
+		return
+	case strings.HasPrefix(path, "/api/spot/v3/instruments/") && strings.HasSuffix(path, "/book"):
+		id := strings.TrimSuffix(strings.TrimPrefix(path, "/api/spot/v3/instruments/"), "/book")
+		symbol, err := s.symbol(id, okexMarketId)
+		if err != nil {
+			s.okexFail(w, err)
+			return
+		}
+		book := s.Book(symbol)
+		limit := int(parseFloat(r.params["size"]))
+		writeJSON(w, 200, map[string]interface{}{
+			"bids":      okexLevels(levels(book.Bids, limit)),
+			"asks":      okexLevels(levels(book.Asks, limit)),
+			"timestamp": iso8601(s.Engine.Now()),
+		})
+		return
+	}
+
+	if !s.okexAuth(r) {
+		s.okexFail(w, AuthenticationError)
+		return
+	}
+	for _, typ := range []string{"futures", "swap"} {
+		if contract := strings.TrimPrefix(path, "/api/"+typ+"/v3/"); contract != path {
+			s.okexContract(w, r, typ, contract)
+			return
+		}
+	}
+	var symbol string
+	if id, ok := r.params["instrument_id"]; ok {
+		var err error
+		if symbol, err = s.symbol(id, okexMarketId); err != nil {
+			s.okexFail(w, err)
+			return
+		}
+	}
+
+	// margin orders are on the isolated margin account of the instrument,
+	// the other margin routes are about loans
+	engine := s.Engine
+	if margin := strings.TrimPrefix(path, "/api/margin/v3/"); margin != path {
+		if !strings.HasPrefix(margin, "orders") && !strings.HasPrefix(margin, "cancel_orders/") {
+			s.okexMargin(w, r, margin, symbol)
+			return
+		}
+		if engine = s.engines["5:"+okexMarketId(symbol)]; engine == nil || r.Method == "POST" && margin == "orders" && r.params["margin_trading"] != "2" {
+			s.okexFail(w, BadRequest)
+			return
+		}
+		path = "/api/spot/v3/" + margin
+	}
+	route := r.Method + " " + strings.TrimPrefix(strings.TrimPrefix(path, "/api/spot/v3/"), "/api/account/v3/")
+	switch {
+	case route == "GET accounts":
+		balance := s.Engine.Balance()
+		result := []interface{}{}
+		for _, code := range s.currencies() {
+			result = append(result, map[string]interface{}{
+				"id":        "",
+				"currency":  code,
+				"balance":   formatFloat(balance.Total[code]),
+				"available": formatFloat(balance.Free[code]),
+				"hold":      formatFloat(balance.Used[code]),
+				"holds":     formatFloat(balance.Used[code]),
+				"frozen":    formatFloat(balance.Used[code]),
+			})
+		}
+		writeJSON(w, 200, result)
+	case route == "POST orders":
+		amount := parseFloat(r.params["size"])
+		if r.params["type"] == "market" && r.params["side"] == "buy" {
+			amount = s.baseAmount(symbol, parseFloat(r.params["notional"]))
+		}
+		order, err := s.placeIn(engine, symbol, r.params["type"], r.params["side"], amount, parseFloat(r.params["price"]), r.params["client_oid"])
+		if err != nil {
+			s.okexFail(w, err)
+			return
+		}
+		writeJSON(w, 200, okexResult(order))
+	case route == "GET orders":
+		statuses := []string{"open", "closed", "canceled"}
+		switch r.params["state"] {
+		case "0", "1", "6":
+			statuses = []string{"open"}
+		case "2":
+			statuses = []string{"closed"}
+		case "-1":
+			statuses = []string{"canceled"}
+		case "7":
+			statuses = []string{"closed", "canceled"}
+		}
+		result := []interface{}{}
+		for _, order := range s.ordersIn(engine, symbol, statuses...) {
+			if r.params["state"] == "1" && order.Filled == 0 || r.params["state"] == "0" && order.Filled > 0 {
+				continue
+			}
+			result = append(result, s.okexOrder(engine, order))
+		}
+		writeJSON(w, 200, result)
+	case strings.HasPrefix(route, "GET orders/"), strings.HasPrefix(route, "POST cancel_orders/"):
+		id := path[strings.LastIndex(path, "/")+1:]
+		order, err := engine.FetchOrder(id)
+		if err == nil && order.Symbol != symbol {
+			err = OrderNotFound
+		}
+		if err == nil && r.Method == "POST" {
+			order, err = engine.CancelOrder(id)
+		}
+		if err != nil {
+			s.okexFail(w, err)
+			return
+		}
+		if r.Method == "POST" {
+			writeJSON(w, 200, okexResult(order))
+		} else {
+			writeJSON(w, 200, s.okexOrder(engine, order))
+		}
+	case route == "GET deposit/address":
+		code := strings.ToUpper(r.params["currency"])
+		result := []interface{}{}
+		if address := s.depositAddress(code, "", true); address.Address != "" {
+			result = append(result, map[string]interface{}{
+				"address":      address.Address,
+				"tag":          address.Tag,
+				"currency":     strings.ToLower(code),
+				"chain":        code + "-" + code,
+				"can_deposit":  1,
+				"can_withdraw": 1,
+			})
+		}
+		writeJSON(w, 200, result)
+	case route == "POST withdrawal":
+		if r.params["trade_pwd"] != s.config.Password {
+			s.okexFail(w, AuthenticationError)
+			return
+		}
+		code := strings.ToUpper(r.params["currency"])
+		address, tag := r.params["to_address"], ""
+		if i := strings.LastIndex(address, ":"); i >= 0 {
+			address, tag = address[:i], address[i+1:]
+		}
+		network := strings.TrimPrefix(r.params["chain"], code+"-")
+		withdrawal, err := s.withdraw(code, network, address, tag, parseFloat(r.params["amount"]))
+		if err != nil {
+			s.okexFail(w, err)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"amount":        r.params["amount"],
+			"withdrawal_id": withdrawal.Id,
+			"currency":      strings.ToLower(code),
+			"result":        true,
+		})
+	case route == "POST transfer":
+		transfer, err := s.transfer(strings.ToUpper(r.params["currency"]), r.params["from"], r.params["to"], parseFloat(r.params["amount"]), "1")
+		if err != nil {
+			s.okexFail(w, err)
+			return
+		}
+		writeJSON(w, 200, map[string]interface{}{
+			"transfer_id": transfer.Id,
+			"currency":    transfer.Code,
+			"from":        transfer.From,
+			"amount":      r.params["amount"],
+			"to":          transfer.To,
+			"result":      true,
+		})
+	case route == "GET ledger":
+		// the funding account ledger lists only its own transfers, newest
+		// first and paged back by ledger id
+		names := map[string]string{"1": "spot", "3": "futures", "5": "margin", "9": "perpetual swap"}
+		transfers := s.Transfers()
+		result := []interface{}{}
+		limit := 100
+		if r.params["limit"] != "" {
+			limit = int(parseFloat(r.params["limit"]))
+		}
+		for i := len(transfers) - 1; i >= 0 && len(result) < limit; i-- {
+			transfer := transfers[i]
+			if r.params["after"] != "" && parseFloat(transfer.Id) >= parseFloat(r.params["after"]) ||
+				r.params["currency"] != "" && !strings.EqualFold(r.params["currency"], transfer.Code) {
+				continue
+			}
+			amount, typename := transfer.Amount, ""
+			if transfer.From == "6" {
+				amount, typename = -amount, "Into "+names[strings.SplitN(transfer.To, ":", 2)[0]]+" account"
+			} else if transfer.To == "6" {
+				typename = "Out of " + names[strings.SplitN(transfer.From, ":", 2)[0]] + " account"
+			} else {
+				continue
+			}
+			result = append(result, map[string]interface{}{
+				"ledger_id": transfer.Id,
+				"currency":  transfer.Code,
+				"amount":    formatFloat(amount),
+				"balance":   formatFloat(s.AccountBalance("6", transfer.Code)),
+				"fee":       "0",
+				"typename":  typename,
+				"timestamp": iso8601(transfer.Timestamp),
+			})
+		}
+		writeJSON(w, 200, result)
+	case route == "GET deposit/history", strings.HasPrefix(route, "GET deposit/history/"):
+		// newest first, as okex lists them
+		deposits := s.Deposits()
+		result := []interface{}{}
+		for i := len(deposits) - 1; i >= 0; i-- {
+			deposit := deposits[i]
+			if okexListed(path, deposit.Code) {
+				result = append(result, map[string]interface{}{
+					"deposit_id": deposit.Id,
+					"amount":     formatFloat(deposit.Amount),
+					"txid":       deposit.Txid,
+					"currency":   deposit.Code,
+					"chain":      deposit.Code + "-" + deposit.Network,
+					"from":       "",
+					"to":         deposit.Address,
+					"tag":        deposit.Tag,
+					"timestamp":  iso8601(deposit.Timestamp),
+					"status":     "2",
+				})
+			}
+		}
+		writeJSON(w, 200, result)
+	case route == "GET withdrawal/history", strings.HasPrefix(route, "GET withdrawal/history/"):
+		withdrawals := s.Withdrawals()
+		result := []interface{}{}
+		for i := len(withdrawals) - 1; i >= 0; i-- {
+			withdrawal := withdrawals[i]
+			if okexListed(path, withdrawal.Code) {
+				result = append(result, map[string]interface{}{
+					"withdrawal_id": withdrawal.Id,
+					"amount":        formatFloat(withdrawal.Amount),
+					"fee":           "0" + strings.ToLower(withdrawal.Code),
+					"txid":          "",
+					"currency":      withdrawal.Code,
+					"chain":         withdrawal.Code + "-" + withdrawal.Network,
+					"from":          "",
+					"to":            withdrawal.Address,
+					"tag":           withdrawal.Tag,
+					"timestamp":     iso8601(withdrawal.Timestamp),
+					"status":        "1",
+				})
+			}
+		}
+		writeJSON(w, 200, result)
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
+
+// okexMargin serves the margin endpoints. Each instrument has its own
+// isolated margin account, named "5:" and the instrument id.
+func (s *Server) okexMargin(w http.ResponseWriter, r *request, path, symbol string) {
+	// the instrument is either a parameter or the second part of the path
+	route := r.Method + " " + path
+	if parts := strings.SplitN(path, "/", 3); len(parts) > 1 && strings.Contains(parts[1], "-") {
+		var err error
+		if symbol, err = s.symbol(parts[1], okexMarketId); err != nil {
+			s.okexFail(w, err)
+			return
+		}
+		route = r.Method + " " + strings.Replace(path, parts[1], "{instrument_id}", 1)
+	}
+	symbols := s.Symbols()
+	if symbol != "" {
+		symbols = []string{symbol}
+	}
+	account := func(symbol string) string {
+		name := "5:" + okexMarketId(symbol)
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.engines[name] == nil && s.accounts[name] == nil {
+			s.accounts[name] = map[string]float64{}
+		}
+		return name
+	}
+	// rows lists one entry per instrument with a "currency:CODE" object for
+	// each side of it
+	rows := func(currency func(account, code string) map[string]interface{}) []interface{} {
+		result := []interface{}{}
+		for _, symbol := range symbols {
+			row := map[string]interface{}{"instrument_id": okexMarketId(symbol)}
+			base, quote, _ := paper.SplitSymbol(symbol)
+			for _, code := range []string{base, quote} {
+				row["currency:"+code] = currency(account(symbol), code)
+			}
+			result = append(result, row)
+		}
+		return result
+	}
+	switch route {
+	case "GET accounts", "GET accounts/{instrument_id}":
+		result := rows(func(account, code string) map[string]interface{} {
+			free, used, loan := s.AccountBalance(account, code), 0.0, s.Loan(account, code)
+			if engine := s.engines[account]; engine != nil {
+				balance := engine.Balance()
+				free, used = balance.Free[code], balance.Used[code]
+			}
+			return map[string]interface{}{
+				"available":    formatFloat(free),
+				"balance":      formatFloat(free + used),
+				"borrowed":     formatFloat(loan.Principal),
+				"can_withdraw": formatFloat(free),
+				"frozen":       formatFloat(used),
+				"hold":         formatFloat(used),
+				"holds":        formatFloat(used),
+				"lending_fee":  formatFloat(loan.Interest),
+			}
+		})
+		if route == "GET accounts" {
+			writeJSON(w, 200, result)
+		} else {
+			writeJSON(w, 200, result[0])
+		}
+	case "GET accounts/availability", "GET accounts/{instrument_id}/availability":
+		writeJSON(w, 200, rows(func(account, code string) map[string]interface{} {
+			return map[string]interface{}{
+				"available":      formatFloat(s.maxBorrowable(account, code)),
+				"leverage":       "3",
+				"leverage_ratio": "3",
+				"rate":           formatFloat(s.config.BorrowRate),
+			}
+		}))
+	case "GET accounts/borrowed", "GET accounts/{instrument_id}/borrowed":
+		charges := s.InterestCharges()
+		result := []interface{}{}
+		for _, symbol := range symbols {
+			base, quote, _ := paper.SplitSymbol(symbol)
+			for _, code := range []string{base, quote} {
+				name := account(symbol)
+				loan, charged, last := s.Loan(name, code), 0.0, s.Engine.Now()
+				for _, charge := range charges {
+					if charge.Account == name && charge.Code == code {
+						charged, last = charged+charge.Interest, charge.Timestamp
+					}
+				}
+				if loan.Principal == 0 && charged == 0 {
+					continue
+				}
+				result = append(result, map[string]interface{}{
+					"borrow_id":          name + "-" + code,
+					"instrument_id":      okexMarketId(symbol),
+					"currency":           code,
+					"amount":             formatFloat(loan.Principal),
+					"rate":               formatFloat(s.config.BorrowRate),
+					"interest":           formatFloat(charged),
+					"paid_interest":      formatFloat(charged - loan.Interest),
+					"repay_interest":     formatFloat(loan.Interest),
+					"last_interest_time": iso8601(last),
+					"created_at":         iso8601(last),
+					"timestamp":          iso8601(last),
+				})
+			}
+		}
+		writeJSON(w, 200, result)
+	case "POST accounts/borrow", "POST accounts/repayment":
+		if symbol == "" {
+			s.okexFail(w, BadRequest)
+			return
+		}
+		borrow, key := s.borrow, "borrow_id"
+		if route == "POST accounts/repayment" {
+			borrow, key = s.repay, "repayment_id"
+		}
+		if _, err := borrow(account(symbol), strings.ToUpper(r.params["currency"]), parseFloat(r.params["amount"])); err != nil {
+			s.okexFail(w, err)
+			return
+		}
+		s.mu.Lock()
+		s.nextLoanId++
+		id := 500000 + s.nextLoanId
+		s.mu.Unlock()
+		writeJSON(w, 200, map[string]interface{}{
+			key:          formatFloat(float64(id)),
+			"client_oid": r.params["client_oid"],
+			"result":     true,
+		})
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
+
+// okexContracts lists the futures or the swap instruments of
+// Config.Contracts, swaps are those whose id ends in -SWAP
+func (s *Server) okexContracts(typ string) []interface{} {
+	result := []interface{}{}
+	for _, id := range s.config.Contracts {
+		parts := strings.Split(id, "-")
+		if len(parts) != 3 || (parts[2] == "SWAP") != (typ == "swap") {
+			continue
+		}
+		instrument := map[string]interface{}{
+			"instrument_id":    id,
+			"underlying_index": parts[0],
+			"underlying":       parts[0] + "-" + parts[1],
+			"base_currency":    parts[0],
+			"quote_currency":   parts[1],
+			"contract_val":     "100",
+			"tick_size":        "0.01",
+			"size_increment":   "1",
+			"trade_increment":  "1",
+		}
+		if typ == "futures" {
+			instrument["alias"] = "quarter"
+		}
+		result = append(result, instrument)
+	}
+	return result
+}
+
+// okexMarginMode is how okex names a margin mode
+func okexMarginMode(mode string) string {
+	if mode == "isolated" {
+		return "fixed"
+	}
+	return "crossed"
+}
+
+// okexContract serves the futures and swap routes under /api/futures/v3
+// and /api/swap/v3, typ tells which
+func (s *Server) okexContract(w http.ResponseWriter, r *request, typ, path string) {
+	segments, id, underlying := strings.Split(path, "/"), "", ""
+	for i, segment := range segments {
+		switch strings.Count(segment, "-") {
+		case 2:
+			id, segments[i] = segment, "{instrument_id}"
+		case 1:
+			underlying, segments[i] = segment, "{underlying}"
+		}
+	}
+	route := r.Method + " " + strings.Join(segments, "/")
+	// futures report both sides of an instrument in one row, swaps one row
+	// per side. The last trade sits a tick above the mark price, so that
+	// reading one for the other shows.
+	holding := func(id string) (rows []interface{}, mode string) {
+		mode = "crossed"
+		if typ == "futures" {
+			row := map[string]interface{}{"instrument_id": id, "long_qty": "0", "short_qty": "0"}
+			for _, position := range s.openPositions(id) {
+				side := position.Side
+				mode = okexMarginMode(position.MarginMode)
+				row[side+"_qty"] = formatFloat(position.Contracts)
+				row[side+"_avail_qty"] = formatFloat(position.Contracts)
+				row[side+"_avg_cost"] = formatFloat(position.EntryPrice)
+				row[side+"_unrealised_pnl"] = formatFloat(position.UnrealizedPnl)
+				row[side+"_liqui_price"] = formatFloat(position.LiquidationPrice)
+				row[side+"_leverage"] = formatFloat(position.Leverage)
+				row["liquidation_price"] = formatFloat(position.LiquidationPrice)
+				row["leverage"] = formatFloat(position.Leverage)
+				row["last"] = formatFloat(position.MarkPrice + 0.01)
+				row["updated_at"] = iso8601(s.Engine.Now())
+			}
+			row["margin_mode"] = mode
+			if row["last"] == nil {
+				return nil, mode
+			}
+			return []interface{}{row}, mode
+		}
+		rows = []interface{}{}
+		for _, position := range s.openPositions(id) {
+			mode = okexMarginMode(position.MarginMode)
+			rows = append(rows, map[string]interface{}{
+				"instrument_id":     id,
+				"side":              position.Side,
+				"position":          formatFloat(position.Contracts),
+				"avail_position":    formatFloat(position.Contracts),
+				"avg_cost":          formatFloat(position.EntryPrice),
+				"last":              formatFloat(position.MarkPrice + 0.01),
+				"unrealized_pnl":    formatFloat(position.UnrealizedPnl),
+				"liquidation_price": formatFloat(position.LiquidationPrice),
+				"leverage":          formatFloat(position.Leverage),
+				"timestamp":         iso8601(s.Engine.Now()),
+			})
+		}
+		return rows, mode
+	}
+	listed, known := []string{}, map[string]bool{}
+	for _, instrument := range s.okexContracts(typ) {
+		listed = append(listed, instrument.(map[string]interface{})["instrument_id"].(string))
+		known[listed[len(listed)-1]] = true
+	}
+	if id != "" && !known[id] {
+		s.okexFail(w, BadSymbol)
+		return
+	}
+	// futures leverage is set per underlying in crossed mode and per
+	// instrument in fixed mode
+	if underlying == "" {
+		underlying = r.params["underlying"]
+	}
+	instruments := []string{}
+	for _, listed := range listed {
+		if underlying != "" && strings.HasPrefix(listed, underlying+"-") {
+			instruments = append(instruments, listed)
+		}
+	}
+	if underlying != "" && len(instruments) == 0 {
+		s.okexFail(w, BadSymbol)
+		return
+	}
+	leverage := parseFloat(r.params["leverage"])
+	switch route {
+	case "GET accounts/{underlying}/leverage":
+		mode := okexMarginMode(s.Leverage(instruments[0]).MarginMode)
+		result := map[string]interface{}{"margin_mode": mode}
+		if mode == "crossed" {
+			result["leverage"] = formatFloat(s.Leverage(instruments[0]).LongLeverage)
+			result["underlying"] = underlying
+		} else {
+			for _, instrument := range instruments {
+				setting := s.Leverage(instrument)
+				result[instrument] = map[string]interface{}{
+					"long_leverage":  formatFloat(setting.LongLeverage),
+					"short_leverage": formatFloat(setting.ShortLeverage),
+				}
+			}
+		}
+		writeJSON(w, 200, result)
+	case "POST accounts/{underlying}/leverage":
+		mode := okexMarginMode(s.Leverage(instruments[0]).MarginMode)
+		if leverage < 1 || leverage > 125 || (mode == "fixed") != (r.params["instrument_id"] != "") {
+			s.okexFail(w, TypedError("BadRequest", "fake leverage does not match the margin mode"))
+			return
+		}
+		if mode == "crossed" {
+			for _, instrument := range instruments {
+				s.setLeverage(instrument, "", leverage, "")
+			}
+			writeJSON(w, 200, map[string]interface{}{"result": true, "margin_mode": mode, "leverage": r.params["leverage"], "underlying": underlying})
+			return
+		}
+		direction := r.params["direction"]
+		if !known[r.params["instrument_id"]] || (direction != "long" && direction != "short") {
+			s.okexFail(w, TypedError("BadRequest", "fake needs an instrument_id and a direction"))
+			return
+		}
+		s.setLeverage(r.params["instrument_id"], direction, leverage, "")
+		writeJSON(w, 200, map[string]interface{}{"result": true, "margin_mode": mode, "leverage": r.params["leverage"],
+			"instrument_id": r.params["instrument_id"], "direction": direction})
+	case "POST accounts/margin_mode":
+		mode := map[string]string{"crossed": "cross", "fixed": "isolated"}[r.params["margin_mode"]]
+		if underlying == "" || mode == "" {
+			s.okexFail(w, TypedError("BadRequest", "fake needs an underlying and a margin_mode"))
+			return
+		}
+		for _, instrument := range instruments {
+			s.setLeverage(instrument, "", 0, mode)
+		}
+		writeJSON(w, 200, map[string]interface{}{"result": true, "underlying": underlying, "margin_mode": r.params["margin_mode"]})
+	case "GET accounts/{instrument_id}/settings":
+		writeJSON(w, 200, okexSwapSettings(id, s.Leverage(id)))
+	case "POST accounts/{instrument_id}/leverage":
+		sides := map[string][2]string{"1": {"long", "isolated"}, "2": {"short", "isolated"}, "3": {"", "cross"}}
+		side, ok := sides[r.params["side"]]
+		if !ok || leverage < 1 || leverage > 100 {
+			s.okexFail(w, TypedError("BadRequest", "fake needs a side and a leverage"))
+			return
+		}
+		s.setLeverage(id, side[0], leverage, side[1])
+		writeJSON(w, 200, okexSwapSettings(id, s.Leverage(id)))
+	case "GET position":
+		if typ == "futures" {
+			all := []interface{}{}
+			for _, id := range listed {
+				if rows, _ := holding(id); rows != nil {
+					all = append(all, rows)
+				}
+			}
+			writeJSON(w, 200, map[string]interface{}{"result": true, "holding": all})
+			return
+		}
+		result := []interface{}{}
+		for _, id := range listed {
+			if rows, mode := holding(id); len(rows) > 0 {
+				result = append(result, map[string]interface{}{"margin_mode": mode, "timestamp": iso8601(s.Engine.Now()), "holding": rows})
+			}
+		}
+		writeJSON(w, 200, result)
+	default:
+		http.NotFound(w, r.Request)
+	}
+}
+
+// okexSwapSettings is how okex reports the leverage of a swap
+func okexSwapSettings(id string, setting Leverage) map[string]interface{} {
+	return map[string]interface{}{
+		"instrument_id":  id,
+		"margin_mode":    okexMarginMode(setting.MarginMode),
+		"long_leverage":  formatFloat(setting.LongLeverage),
+		"short_leverage": formatFloat(setting.ShortLeverage),
+	}
+}
+
+// okexListed reports whether a history path, which may end in a currency,
+// lists the transactions of code
+func okexListed(path, code string) bool {
+	if strings.HasSuffix(path, "/history") {
+		return true
+	}
+	return strings.EqualFold(path[strings.LastIndex(path, "/")+1:], code)
+}
+
+// okexResult is the acknowledgement of an order placement or cancel
+func okexResult(order *Order) map[string]interface{} {
+	return map[string]interface{}{
+		"order_id":      order.Id,
+		"client_oid":    "",
+		"result":        true,
+		"error_code":    "0",
+		"error_message": "",
+	}
+}
+
+// okexLevels renders book levels as [["price","amount","orders"], ...]
+func okexLevels(side [][2]float64) [][]string {
+	result := [][]string{}
+	for _, level := range stringLevels(side) {
+		result = append(result, append(level, "1"))
+	}
+	return result
+}
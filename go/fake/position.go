@@ -0,0 +1,93 @@
+package fake
+
+import (
+	"sort"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// SetPosition opens or changes the position on one side of a symbol, as the
+// test wants the exchange to report it, zero Contracts closes it. Symbol is
+// unified, for okex contracts the instrument id. A non zero Leverage or a
+// MarginMode also becomes the setting of the symbol, as SetLeverage would.
+func (s *Server) SetPosition(position Position) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	setting := s.setting(position.Symbol)
+	if position.Leverage != 0 {
+		if position.Side == "short" {
+			setting.ShortLeverage = position.Leverage
+		} else {
+			setting.LongLeverage = position.Leverage
+		}
+	}
+	if position.MarginMode != "" {
+		setting.MarginMode = position.MarginMode
+	}
+	key := position.Symbol + " " + position.Side
+	if position.Contracts == 0 {
+		delete(s.positions, key)
+		return
+	}
+	s.positions[key] = &position
+}
+
+// openPositions returns the positions on symbol, every symbol if empty,
+// ordered by symbol and side, with the leverage and margin mode the symbol
+// is set to
+func (s *Server) openPositions(symbol string) []Position {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := []Position{}
+	for _, position := range s.positions {
+		if symbol == "" || position.Symbol == symbol {
+			open := *position
+			setting := s.setting(open.Symbol)
+			open.MarginMode, open.Leverage = setting.MarginMode, setting.LongLeverage
+			if open.Side == "short" {
+				open.Leverage = setting.ShortLeverage
+			}
+			result = append(result, open)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Symbol != result[j].Symbol {
+			return result[i].Symbol < result[j].Symbol
+		}
+		return result[i].Side < result[j].Side
+	})
+	return result
+}
+
+// setting is how symbol is traded, 10x cross margin until changed, callers
+// hold s.mu
+func (s *Server) setting(symbol string) *Leverage {
+	if s.leverages[symbol] == nil {
+		s.leverages[symbol] = &Leverage{Symbol: symbol, MarginMode: "cross", LongLeverage: 10, ShortLeverage: 10}
+	}
+	return s.leverages[symbol]
+}
+
+// Leverage returns the leverage and margin mode symbol is set to
+func (s *Server) Leverage(symbol string) Leverage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return *s.setting(symbol)
+}
+
+// setLeverage sets the leverage of one side of symbol, both if side is
+// empty, and its margin mode if marginMode is not empty
+func (s *Server) setLeverage(symbol, side string, leverage float64, marginMode string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	setting := s.setting(symbol)
+	if leverage != 0 && side != "short" {
+		setting.LongLeverage = leverage
+	}
+	if leverage != 0 && side != "long" {
+		setting.ShortLeverage = leverage
+	}
+	if marginMode != "" {
+		setting.MarginMode = marginMode
+	}
+}
@@ -0,0 +1,110 @@
+package fake
+
+import (
+	"fmt"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+// Transfer is a move between two accounts accepted by a fake server
+type Transfer struct {
+	Id        string
+	Code      string
+	From      string
+	To        string
+	Amount    float64
+	Timestamp int64
+}
+
+// accountEngine returns the engine that holds the balances of account: the
+// main one for the trading accounts named in spot, another one for those
+// a fake's constructor added, nil for the accounts the server keeps itself
+func (s *Server) accountEngine(account string, spot []string) *paper.Engine {
+	for _, name := range spot {
+		if account == name {
+			return s.Engine
+		}
+	}
+	return s.engines[account]
+}
+
+// debit takes amount of code out of the free balance of an account, s.mu
+// must be held
+func (s *Server) debit(account, code string, amount float64, spot ...string) error {
+	if engine := s.accountEngine(account, spot); engine != nil {
+		return engine.Withdraw(code, amount)
+	}
+	if s.accounts[account][code] < amount {
+		return TypedError("InsufficientFunds", fmt.Sprintf("fake %s %s %v < required %v", account, code, s.accounts[account][code], amount))
+	}
+	s.accounts[account][code] -= amount
+	return nil
+}
+
+// credit adds amount of code to an account, s.mu must be held
+func (s *Server) credit(account, code string, amount float64, spot ...string) {
+	if engine := s.accountEngine(account, spot); engine != nil {
+		engine.Deposit(code, amount)
+	} else {
+		s.accounts[account][code] += amount
+	}
+}
+
+// known reports whether the server has account, s.mu must be held
+func (s *Server) known(account string, spot ...string) bool {
+	return s.accountEngine(account, spot) != nil || s.accounts[account] != nil
+}
+
+// transfer moves amount of code between two accounts. The main engine holds
+// the balances of the trading accounts named in spot, the server those of
+// the others, which start empty and are created by the fake's constructor.
+func (s *Server) transfer(code, from, to string, amount float64, spot ...string) (*Transfer, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if from == to || !s.known(from, spot...) || !s.known(to, spot...) {
+		return nil, TypedError("BadRequest", "fake cannot transfer from "+from+" to "+to)
+	}
+	if err := s.debit(from, code, amount, spot...); err != nil {
+		return nil, err
+	}
+	s.credit(to, code, amount, spot...)
+	transfer := &Transfer{
+		Id:        fmt.Sprintf("%d", 900000+len(s.transfers)),
+		Code:      code,
+		From:      from,
+		To:        to,
+		Amount:    amount,
+		Timestamp: s.Engine.Now(),
+	}
+	s.transfers = append(s.transfers, transfer)
+	return transfer, nil
+}
+
+// AccountBalance returns the total balance of code in an account other
+// than the main trading one, whose balances are the engine's
+func (s *Server) AccountBalance(account, code string) float64 {
+	if engine := s.engines[account]; engine != nil {
+		return engine.Balance().Total[code]
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.accounts[account][code]
+}
+
+// AccountEngine returns the engine of a trading account other than the
+// main one, nil if the fake has none by that name
+func (s *Server) AccountEngine(account string) *paper.Engine {
+	return s.engines[account]
+}
+
+// Transfers returns the accepted transfers in the order they were made
+func (s *Server) Transfers() []Transfer {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	result := make([]Transfer, len(s.transfers))
+	for i, transfer := range s.transfers {
+		result[i] = *transfer
+	}
+	return result
+}
@@ -7,4 +7,5 @@ require (
 	github.com/imdario/mergo v0.3.10
 	github.com/satori/go.uuid v1.2.0
 	github.com/thoas/go-funk v0.7.0
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
 )
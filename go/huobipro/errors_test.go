@@ -0,0 +1,24 @@
+package huobipro
+
+import (
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base/conformance"
+)
+
+func TestHandleErrors(t *testing.T) {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.RunErrors(t, ex, []conformance.ErrorCase{
+		{Status: 200, Body: `{"status":"ok","data":[]}`},
+		{Status: 200, Body: `{"status":"error","err-code":"account-frozen-balance-insufficient-error","err-msg":"trade account balance is not enough"}`, Class: "InsufficientFunds"},
+		{Status: 200, Body: `{"status":"error","err-code":"order-orderstate-error","err-msg":"order state error"}`, Class: "OrderNotFound"},
+		{Status: 200, Body: `{"status":"error","err-code":"api-signature-check-failed","err-msg":"Signature not valid"}`, Class: "AuthenticationError"},
+		{Status: 200, Body: `{"status":"error","err-code":"something-new","err-msg":"invalid symbol"}`, Class: "BadSymbol"},
+		{Status: 200, Body: `{"status":"error","err-code":"something-new","err-msg":"something new"}`, Class: "ExchangeError"},
+		{Status: 403, Body: `Forbidden`, Class: "PermissionDenied"},
+		{Status: 504, Body: ``, Class: "RequestTimeout"},
+	})
+}
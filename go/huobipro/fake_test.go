@@ -0,0 +1,260 @@
+package huobipro
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/base/conformance"
+	"github.com/georgexdz/ccxt/go/fake"
+)
+
+func newFakeServer() *fake.Server {
+	return fake.NewHuobipro(&fake.Config{
+		ApiKey:   "fake-key",
+		Secret:   "fake-secret",
+		Balances: map[string]float64{"USDT": 1000},
+		Books: map[string]*base.OrderBook{
+			"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}},
+		},
+	})
+}
+
+func newFakeExchange(t *testing.T, server *fake.Server, secret string) *Huobipro {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.ApiKey = "fake-key"
+	ex.Secret = secret
+	ex.SetBaseUrl(server.URL)
+	return ex
+}
+
+func TestFakeOrderFillsWhenMarketMoves(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Used["USDT"] != 90 || balance.Free["USDT"] != 910 {
+		t.Fatal("locked balance:", ex.Json(balance))
+	}
+
+	server.SetBook("BTC/USDT", &base.OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Id != order.Id || o.Status != "closed" || o.Filled != 0.01 {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	balance, err = ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Total["BTC"] != 0.01 || balance.Total["USDT"] != 910 || balance.Used["USDT"] != 0 {
+		t.Fatal("filled balance:", ex.Json(balance))
+	}
+}
+
+func TestFakeCancelOrder(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", ex.Json(openOrders))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "canceled" {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, base.OrderNotFound) {
+		t.Fatal("second cancel:", err)
+	}
+}
+
+func TestFakeRejectsBadSignature(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "wrong-secret")
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+		t.Fatal("FetchBalance:", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	conformance.Run(t, newFakeExchange(t, server, "fake-secret"), server)
+}
+
+func TestFakeFetchTime(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	// the exchange's clock runs an hour ahead of ours
+	server.Engine.Now = func() int64 { return time.Now().UnixNano()/int64(time.Millisecond) + 3600000 }
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	difference, err := ex.LoadTimeDifference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difference > -3599000 || difference < -3601000 {
+		t.Fatal("time difference:", difference)
+	}
+	if _, err := ex.FetchBalance(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeDepositAndWithdraw(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	address, err := ex.FetchDepositAddress("BTC", "", nil)
+	if err != nil || address.Address == "" || address.Tag != "" {
+		t.Fatal("BTC address:", address, err)
+	}
+	if address, err = ex.FetchDepositAddress("XRP", "XRP", nil); err != nil || address.Tag == "" {
+		t.Fatal("XRP tag:", address, err)
+	}
+	server.SetDepositAddress("ETH", "", "", "")
+	if _, err := ex.FetchDepositAddress("ETH", "", nil); !errors.Is(err, base.AddressPending) {
+		t.Fatal("pending address:", err)
+	}
+
+	withdrawal, err := ex.Withdraw("USDT", 100, "0xabc", "", "TRC20", nil)
+	if err != nil || withdrawal.Id == "" || withdrawal.Status != "pending" {
+		t.Fatal("Withdraw:", withdrawal, err)
+	}
+	if sent := server.Withdrawals(); len(sent) != 1 || sent[0].Network != "TRC20" {
+		t.Fatal("withdrawals:", sent)
+	}
+	if _, err := ex.Withdraw("XRP", 1, "xrpaddress", "", "", nil); !errors.Is(err, base.InvalidAddress) {
+		t.Fatal("XRP without a tag:", err)
+	}
+	if _, err := ex.Withdraw("USDT", 5000, "0xabc", "", "", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Fatal("insufficient funds:", err)
+	}
+}
+
+func TestFakeTransactionHistory(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	now := server.Engine.Now
+	server.Engine.Now = func() int64 { return 1600000000000 }
+	server.Deposit("BTC", "BTC", 0.5)
+	server.Engine.Now = func() int64 { return 1600000100000 }
+	server.Deposit("USDT", "TRC20", 200)
+	server.Engine.Now = now
+	if _, err := ex.Withdraw("USDT", 100, "0xabc", "", "ERC20", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	deposits, err := ex.FetchDeposits("", 0, 0, nil)
+	if err != nil || len(deposits) != 2 || deposits[0].Network != "BTC" || deposits[1].Network != "TRC20" || deposits[1].Status != "ok" {
+		t.Fatal("FetchDeposits:", deposits, err)
+	}
+	if deposits, err := ex.FetchDeposits("", 1600000000001, 0, nil); err != nil || len(deposits) != 1 || deposits[0].Currency != "USDT" {
+		t.Fatal("deposits since:", deposits, err)
+	}
+	withdrawals, err := ex.FetchWithdrawals("USDT", 0, 0, nil)
+	if err != nil || len(withdrawals) != 1 || withdrawals[0].Status != "pending" || withdrawals[0].Network != "ERC20" {
+		t.Fatal("FetchWithdrawals:", withdrawals, err)
+	}
+	transactions, err := ex.FetchTransactions("USDT", 0, 0, nil)
+	if err != nil || len(transactions) != 2 || transactions[0].Type != "deposit" || transactions[1].Type != "withdrawal" {
+		t.Fatal("FetchTransactions:", transactions, err)
+	}
+}
+
+func TestFakeTransactionHistoryPages(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	// more deposits than one page of 500
+	now := server.Engine.Now
+	for i := int64(0); i < 600; i++ {
+		server.Engine.Now = func() int64 { return 1600000000000 + i*1000 }
+		server.Deposit("USDT", "TRC20", 1)
+	}
+	server.Engine.Now = now
+
+	deposits, err := ex.FetchDeposits("USDT", 1600000000000, 0, nil)
+	if err != nil || len(deposits) != 600 || deposits[0].Timestamp != 1600000000000 || deposits[599].Timestamp != 1600000599000 {
+		t.Fatal("deposits since the first:", len(deposits), err)
+	}
+	if deposits, err := ex.FetchDeposits("USDT", 1600000050000, 10, nil); err != nil || len(deposits) != 10 || deposits[0].Timestamp != 1600000050000 {
+		t.Fatal("deposits since with a limit:", deposits, err)
+	}
+	if deposits, err := ex.FetchDeposits("USDT", 0, 2, nil); err != nil || len(deposits) != 2 || deposits[1].Timestamp != 1600000599000 {
+		t.Fatal("newest deposits:", deposits, err)
+	}
+}
+
+func TestFakeAccountType(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+	server.AccountEngine("super-margin").Deposit("USDT", 300)
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, map[string]interface{}{"accountType": "margin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Engine.OpenOrders("")) != 0 || len(server.AccountEngine("super-margin").OpenOrders("")) != 1 {
+		t.Fatal("order not placed on the super margin account")
+	}
+	ex.ExchangeConfig.AccountType = "margin"
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 300 || balance.Used["USDT"] != 90 {
+		t.Fatal("margin balance:", balance, err)
+	}
+	if o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil); err != nil || o.Id != order.Id || o.Status != "open" {
+		t.Fatal("FetchOrder:", o, err)
+	}
+	if _, err := ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// an account id, and the param over the config
+	for _, accountType := range []string{"spot", "1000001"} {
+		spot, err := ex.FetchBalance(map[string]interface{}{"accountType": accountType})
+		if err != nil || spot.Total["USDT"] != 1000 {
+			t.Fatal("spot balance:", accountType, spot, err)
+		}
+	}
+	if _, err := ex.FetchBalance(map[string]interface{}{"accountType": "isolated-margin", "symbol": "BTC/USDT"}); !errors.Is(err, base.NotSupported) {
+		t.Error("isolated margin:", err)
+	}
+}
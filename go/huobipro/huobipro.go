@@ -14,7 +14,10 @@ type Huobipro struct {
 
 func New(config *ExchangeConfig) (ex *Huobipro, err error) {
 	ex = new(Huobipro)
-	err = ex.Init(config)
+	if err = ex.Init(config); err != nil {
+		ex = nil
+		return
+	}
 	ex.Child = ex
 
 	err = ex.InitDescribe()
@@ -53,7 +56,8 @@ func (self *Huobipro) Describe() []byte {
         "withdraw": true,
         "fetchCurrencies": true,
         "fetchDeposits": true,
-        "fetchWithdrawals": true
+        "fetchWithdrawals": true,
+        "fetchTransactions": true
     },
     "timeframes": {
         "1m": "1min",
@@ -201,6 +205,8 @@ func (self *Huobipro) Describe() []byte {
             "base-record-invalid": "OrderNotFound",
             "invalid symbol": "BadSymbol",
             "invalid-parameter": "BadRequest",
+            "invalid-address": "InvalidAddress",
+            "dw-withdraw-address-error": "InvalidAddress",
             "base-symbol-trade-disabled": "BadSymbol"
         }
     },
@@ -220,6 +226,16 @@ func (self *Huobipro) Describe() []byte {
 }`)
 }
 
+func (self *Huobipro) FetchTime(params map[string]interface{}) (timestamp int64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	response := self.ApiFunc("publicGetCommonTimestamp", params, nil, nil)
+	return self.SafeInteger(response, "data", 0), nil
+}
+
 func (self *Huobipro) FetchMarkets(params map[string]interface{}) []interface{} {
 	method := self.Member(self.Options, "fetchMarketsMethod")
 	response := self.ApiFunc(method.(string), params, nil, nil)
@@ -238,12 +254,12 @@ func (self *Huobipro) FetchMarkets(params map[string]interface{}) []interface{}
 		quote := self.SafeCurrencyCode(quoteId)
 		symbol := base + "/" + quote
 		precision := map[string]interface{}{
-			"amount": self.Member(market, "amount-precision"),
-			"price":  self.Member(market, "price-precision"),
+			"amount": int(self.SafeInteger(market, "amount-precision", 0)),
+			"price":  int(self.SafeInteger(market, "price-precision", 0)),
 		}
 		maker := self.IfThenElse(self.ToBool(base == "OMG"), 0., 0.2/100)
 		taker := self.IfThenElse(self.ToBool(base == "OMG"), 0., 0.2/100)
-		minAmount := self.SafeFloat(market, "min-order-amt", math.Pow10(int(-precision["amount"].(float64))))
+		minAmount := self.SafeFloat(market, "min-order-amt", math.Pow10(-precision["amount"].(int)))
 		maxAmount := self.SafeFloat(market, "max-order-amt", 0)
 		minCost := self.SafeFloat(market, "min-order-value", 0)
 		state := self.SafeString(market, "state", "")
@@ -265,7 +281,7 @@ func (self *Huobipro) FetchMarkets(params map[string]interface{}) []interface{}
 					"max": maxAmount,
 				},
 				"price": map[string]interface{}{
-					"min": math.Pow10(-int(precision["price"].(float64))),
+					"min": math.Pow10(-precision["price"].(int)),
 					"max": nil,
 				},
 				"cost": map[string]interface{}{
@@ -374,10 +390,10 @@ func (self *Huobipro) FetchBalance(params map[string]interface{}) (balanceResult
 		}
 	}()
 	self.LoadMarkets()
-	self.LoadAccounts()
+	symbol := self.SafeString(params, "symbol", "")
 	method := self.Member(self.Options, "fetchBalanceMethod").(string)
 	request := map[string]interface{}{
-		"id": self.Member(self.Member(self.Accounts, 0), "id"),
+		"id": self.Member(self.SelectAccount(symbol, params), "id"),
 	}
 	response := self.ApiFunc(method, request, nil, nil)
 	balances := self.SafeValue(self.Member(response, "data"), "list", []interface{}{})
@@ -405,6 +421,36 @@ func (self *Huobipro) FetchBalance(params map[string]interface{}) (balanceResult
 	return self.ParseBalance(result), nil
 }
 
+// SelectAccount returns the account of a call on symbol: the unified
+// account type picks the spot, the super-margin (cross margin) or the
+// isolated margin account of symbol, any other value is an account id such
+// as a sub-account's. The spot account is the default.
+func (self *Huobipro) SelectAccount(symbol string, params map[string]interface{}) interface{} {
+	self.LoadAccounts()
+	accountType := self.SelectAccountType(params, "spot")
+	typ, subtype := accountType, ""
+	switch accountType {
+	case "spot":
+	case "margin":
+		typ = "super-margin"
+	case "isolated-margin":
+		if symbol == "" {
+			self.RaiseException("ArgumentsRequired", self.Id+" isolated margin requires a symbol")
+		}
+		typ, subtype = "margin", self.MarketId(symbol)
+	}
+	for _, account := range self.Accounts {
+		if self.SafeString(account, "id", "") == accountType {
+			return account
+		}
+		if self.SafeString(account, "type", "") == typ && self.SafeString(account, "subtype", "") == subtype {
+			return account
+		}
+	}
+	self.RaiseException("NotSupported", self.Id+" has no "+accountType+" account")
+	return nil
+}
+
 func (self *Huobipro) FetchOrder(id string, symbol string, params map[string]interface{}) (result *Order, err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -562,13 +608,19 @@ func (self *Huobipro) CreateOrder(symbol string, typ string, side string, amount
 		}
 	}()
 	self.LoadMarkets()
-	self.LoadAccounts()
 	market := self.Market(symbol)
+	account := self.SelectAccount(symbol, params)
 	request := map[string]interface{}{
-		"account-id": self.Member(self.Member(self.Accounts, 0), "id"),
+		"account-id": self.Member(account, "id"),
 		"symbol":     market.Id,
 		"type":       side + "-" + typ,
 	}
+	switch self.SafeString(account, "type", "") {
+	case "super-margin":
+		self.SetValue(request, "source", "super-margin-api")
+	case "margin":
+		self.SetValue(request, "source", "margin-api")
+	}
 	if self.ToBool(typ == "market" && side == "buy") {
 		if self.ToBool(self.Member(self.Options, "createMarketBuyOrderRequiresPrice")) {
 			if self.ToBool(self.TestNil(price)) {
@@ -626,6 +678,172 @@ func (self *Huobipro) CancelOrder(id string, symbol string, params map[string]in
 	}), nil
 }
 
+func (self *Huobipro) FetchDepositAddress(code string, network string, params map[string]interface{}) (result *DepositAddress, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"currency": strings.ToLower(self.CurrencyId(code)),
+	}
+	response := self.ApiFunc("v2PrivateGetAccountDepositAddress", self.Extend(request, params), nil, nil)
+	data := self.SafeValue(response, "data", []interface{}{})
+	for i := 0; i < self.Length(data); i++ {
+		item := self.Member(data, i)
+		if self.NetworkMatches(self.SafeString(item, "chain", ""), network, code) {
+			address := self.SafeString(item, "address", "")
+			tag := self.SafeString(item, "addressTag", "")
+			return self.ToDepositAddress(code, address, tag, network, item), nil
+		}
+	}
+	return self.ToDepositAddress(code, "", "", network, response), nil
+}
+
+func (self *Huobipro) Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (result *Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.CheckAddress(address)
+	self.LoadMarkets()
+	currencyId := strings.ToLower(self.CurrencyId(code))
+	request := map[string]interface{}{
+		"address":  address,
+		"amount":   self.NumberToString(amount),
+		"currency": currencyId,
+	}
+	if tag != "" {
+		self.SetValue(request, "addr-tag", tag)
+	}
+	// token chains are named like trc20usdt, the native chain after the currency
+	if chain := strings.ToLower(network); chain != "" {
+		if chain != currencyId && !strings.Contains(chain, currencyId) {
+			chain += currencyId
+		}
+		self.SetValue(request, "chain", chain)
+	}
+	response := self.ApiFunc("privatePostDwWithdrawApiCreate", self.Extend(request, params), nil, nil)
+	return &Transaction{
+		Id:       self.SafeString(response, "data", ""),
+		Type:     "withdrawal",
+		Currency: code,
+		Network:  network,
+		Address:  address,
+		Tag:      tag,
+		Amount:   amount,
+		Status:   "pending",
+		Info:     response,
+	}, nil
+}
+
+func (self *Huobipro) FetchDeposits(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("deposit", code, since, limit, params), nil
+}
+
+func (self *Huobipro) FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("withdrawal", code, since, limit, params), nil
+}
+
+// FetchTransactionsByType lists deposits or withdrawals. Huobi has no time
+// filter, it lists the newest first and pages back by id, so with since the
+// pages are followed back until one reaches past it, without since the
+// newest limit are returned.
+func (self *Huobipro) FetchTransactionsByType(typ string, code string, since int64, limit int64, params map[string]interface{}) []*Transaction {
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"type":   typ,
+		"direct": "prev",
+	}
+	if typ == "withdrawal" {
+		self.SetValue(request, "type", "withdraw")
+	}
+	if code != "" {
+		self.SetValue(request, "currency", strings.ToLower(self.CurrencyId(code)))
+	}
+	size := int64(500)
+	if since <= 0 && limit > 0 {
+		size = int64(math.Min(float64(limit), 500))
+	}
+	self.SetValue(request, "size", size)
+	result := []*Transaction{}
+	for {
+		response := self.ApiFunc("privateGetQueryDepositWithdraw", self.Extend(request, params), nil, nil)
+		data := self.SafeValue(response, "data", []interface{}{})
+		for i := 0; i < self.Length(data); i++ {
+			result = append(result, self.ParseTransaction(self.Member(data, i), typ))
+		}
+		if since <= 0 || int64(self.Length(data)) < size || result[len(result)-1].Timestamp < since {
+			break
+		}
+		self.SetValue(request, "from", self.SafeInteger(self.Member(data, self.Length(data)-1), "id", 0)-1)
+	}
+	return self.FilterTransactions(result, code, since, limit)
+}
+
+func (self *Huobipro) ParseTransactionStatus(status string) string {
+	statuses := map[string]interface{}{
+		// deposits
+		"unknown":    "failed",
+		"confirming": "pending",
+		"confirmed":  "ok",
+		"safe":       "ok",
+		"orphan":     "failed",
+		// withdrawals
+		"submitted":       "pending",
+		"canceled":        "canceled",
+		"reexamine":       "pending",
+		"reject":          "failed",
+		"pass":            "pending",
+		"wallet-reject":   "failed",
+		"confirm-error":   "failed",
+		"repealed":        "failed",
+		"wallet-transfer": "pending",
+		"pre-transfer":    "pending",
+	}
+	return self.SafeString(statuses, status, status)
+}
+
+func (self *Huobipro) ParseTransaction(transaction interface{}, typ string) *Transaction {
+	currencyId := self.SafeString(transaction, "currency", "")
+	// token chains are named like trc20usdt, the native chain after the currency
+	network := self.SafeString(transaction, "chain", "")
+	if network == currencyId {
+		network = strings.ToUpper(network)
+	} else {
+		network = strings.ToUpper(strings.Replace(network, currencyId, "", 1))
+	}
+	timestamp := self.SafeInteger(transaction, "created-at", 0)
+	return &Transaction{
+		Id:        self.SafeString(transaction, "id", ""),
+		Txid:      self.SafeString(transaction, "tx-hash", ""),
+		Type:      typ,
+		Currency:  self.SafeCurrencyCode(currencyId),
+		Network:   network,
+		Address:   self.SafeString(transaction, "address", ""),
+		Tag:       self.SafeString(transaction, "address-tag", ""),
+		Amount:    self.SafeFloat(transaction, "amount", 0),
+		Fee:       self.SafeFloat(transaction, "fee", 0),
+		Status:    self.ParseTransactionStatus(self.SafeString(transaction, "state", "")),
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Updated:   self.SafeInteger(transaction, "updated-at", 0),
+		Info:      transaction,
+	}
+}
+
 func (self *Huobipro) Sign(path string, api string, method string, params map[string]interface{}, headers interface{}, body interface{}) (ret interface{}) {
 	url := "/"
 	if self.ToBool(api == "market") {
@@ -638,11 +856,13 @@ func (self *Huobipro) Sign(path string, api string, method string, params map[st
 	url += "/" + self.ImplodeParams(path, params)
 	query := self.Omit(params, self.ExtractParams(path))
 	if self.ToBool(api == "private" || api == "v2Private") {
-		timestamp := self.Ymdhms(self.Milliseconds(), "T")
+		self.CheckRequiredCredentials()
+		credentials := self.Credentials()
+		timestamp := self.Ymdhms(self.ServerMilliseconds(), "T")
 		request := map[string]interface{}{
 			"SignatureMethod":  "HmacSHA256",
 			"SignatureVersion": "2",
-			"AccessKeyId":      self.ApiKey,
+			"AccessKeyId":      credentials.ApiKey,
 			"Timestamp":        timestamp,
 		}
 		if self.ToBool(method != "POST") {
@@ -652,7 +872,7 @@ func (self *Huobipro) Sign(path string, api string, method string, params map[st
 		// request = self.Keysort(request)
 		auth := self.Urlencode(request)
 		payload := strings.Join([]string{method, self.Hostname, url, auth}, "\n")
-		signature := self.Hmac(self.Encode(payload), self.Encode(self.Secret), "sha256", "base64")
+		signature := self.Hmac(self.Encode(payload), self.Encode(credentials.Secret), "sha256", "base64")
 		auth += "&" + self.Urlencode(map[string]interface{}{
 			"Signature": signature,
 		})
@@ -672,6 +892,9 @@ func (self *Huobipro) Sign(path string, api string, method string, params map[st
 			url += "?" + self.Urlencode(params)
 		}
 	}
+	if self.ToBool(!self.ToBool(self.InMap(api, self.Member(self.Urls, "api")))) {
+		self.RaiseException("NotSupported", self.Id+" does not have a testnet/sandbox URL for "+api+" endpoints")
+	}
 	url = self.ImplodeParams(self.Member(self.Member(self.Urls, "api"), api).(string), map[string]interface{}{
 		"hostname": self.Hostname,
 	}) + url
@@ -692,9 +915,16 @@ func (self *Huobipro) HandleErrors(httpCode int64, reason string, url string, me
 		if self.ToBool(status == "error") {
 			code := self.SafeString(response, "err-code", "")
 			feedback := self.Id + " " + body
-			self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), code, feedback)
 			message := self.SafeString(response, "err-msg", "")
-			self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), message, feedback)
+			self.ThrowMatchedException(feedback, code, message)
+			self.RaiseException("ExchangeError", feedback)
+		}
+	} else if self.ToBool(self.InMap("code", response)) {
+		// the v2 api reports errors as a code other than 200 and a message
+		code := self.SafeString(response, "code", "")
+		if code != "200" {
+			feedback := self.Id + " " + body
+			self.ThrowMatchedException(feedback, code, self.SafeString(response, "message", ""))
 			self.RaiseException("ExchangeError", feedback)
 		}
 	}
@@ -0,0 +1,23 @@
+package kucoin
+
+import (
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base/conformance"
+)
+
+func TestHandleErrors(t *testing.T) {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.RunErrors(t, ex, []conformance.ErrorCase{
+		{Status: 200, Body: `{"code":"200000","data":[]}`},
+		{Status: 400, Body: `{"code":"200004","msg":"Balance insufficient!"}`, Class: "InsufficientFunds"},
+		{Status: 404, Body: `{"code":"400100","msg":"order not exist"}`, Class: "OrderNotFound"},
+		{Status: 401, Body: `{"code":"400002","msg":"KC-API-TIMESTAMP Invalid"}`, Class: "InvalidNonce"},
+		{Status: 403, Body: `{"code":"411100","msg":"User are frozen"}`, Class: "AccountSuspended"},
+		{Status: 503, Body: `Exceeded the access frequency`, Class: "RateLimitExceeded"},
+		{Status: 400, Body: `Bad Request`, Class: "BadRequest"},
+	})
+}
@@ -0,0 +1,299 @@
+package kucoin
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/base/conformance"
+	"github.com/georgexdz/ccxt/go/fake"
+)
+
+func newFakeServer() *fake.Server {
+	return fake.NewKucoin(&fake.Config{
+		ApiKey:   "fake-key",
+		Secret:   "fake-secret",
+		Password: "fake-password",
+		Balances: map[string]float64{"USDT": 1000},
+		Books: map[string]*base.OrderBook{
+			"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}},
+		},
+	})
+}
+
+func newFakeExchange(t *testing.T, server *fake.Server, secret string) *Kucoin {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.ApiKey = "fake-key"
+	ex.Secret = secret
+	ex.Password = "fake-password"
+	ex.SetBaseUrl(server.URL)
+	return ex
+}
+
+func TestFakeOrderFillsWhenMarketMoves(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Used["USDT"] != 90 || balance.Free["USDT"] != 910 {
+		t.Fatal("locked balance:", ex.Json(balance))
+	}
+
+	server.SetBook("BTC/USDT", &base.OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Id != order.Id || o.Status != "closed" || o.Filled != 0.01 {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	balance, err = ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Total["BTC"] != 0.01 || balance.Total["USDT"] != 910 || balance.Used["USDT"] != 0 {
+		t.Fatal("filled balance:", ex.Json(balance))
+	}
+}
+
+func TestFakeCancelOrder(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", ex.Json(openOrders))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "canceled" {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, base.OrderNotFound) {
+		t.Fatal("second cancel:", err)
+	}
+}
+
+func TestFakeRejectsBadSignature(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "wrong-secret")
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+		t.Fatal("FetchBalance:", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	conformance.Run(t, newFakeExchange(t, server, "fake-secret"), server)
+}
+
+func TestFakeFetchTime(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	// the exchange's clock runs an hour ahead of ours
+	server.Engine.Now = func() int64 { return time.Now().UnixNano()/int64(time.Millisecond) + 3600000 }
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	difference, err := ex.LoadTimeDifference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difference > -3599000 || difference < -3601000 {
+		t.Fatal("time difference:", difference)
+	}
+	if _, err := ex.FetchBalance(nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFakeDepositAndWithdraw(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	if _, err := ex.FetchDepositAddress("USDT", "TRC20", nil); !errors.Is(err, base.AddressPending) {
+		t.Fatal("address not created yet:", err)
+	}
+	created, err := ex.CreateDepositAddress("USDT", "TRC20", nil)
+	if err != nil || created.Address == "" || created.Network != "TRC20" {
+		t.Fatal("CreateDepositAddress:", created, err)
+	}
+	if address, err := ex.FetchDepositAddress("USDT", "TRC20", nil); err != nil || address.Address != created.Address {
+		t.Fatal("FetchDepositAddress:", address, err)
+	}
+
+	withdrawal, err := ex.Withdraw("USDT", 100, "0xabc", "", "ERC20", nil)
+	if err != nil || withdrawal.Id == "" || withdrawal.Status != "pending" {
+		t.Fatal("Withdraw:", withdrawal, err)
+	}
+	if sent := server.Withdrawals(); len(sent) != 1 || sent[0].Network != "ERC20" {
+		t.Fatal("withdrawals:", sent)
+	}
+	if _, err := ex.Withdraw("USDT", 1, "invalid-address", "", "", nil); !errors.Is(err, base.InvalidAddress) {
+		t.Fatal("invalid address:", err)
+	}
+}
+
+func TestFakeTransactionHistory(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	now := server.Engine.Now
+	server.Engine.Now = func() int64 { return 1600000000000 }
+	server.Deposit("BTC", "BTC", 0.5)
+	server.Engine.Now = func() int64 { return 1600000100000 }
+	deposit := server.Deposit("USDT", "TRC20", 200)
+	server.Engine.Now = now
+	if _, err := ex.Withdraw("USDT", 100, "0xabc", "", "ERC20", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	deposits, err := ex.FetchDeposits("", 0, 0, nil)
+	if err != nil || len(deposits) != 2 || deposits[1].Network != "TRC20" || deposits[1].Txid != deposit.Txid || deposits[1].Status != "ok" {
+		t.Fatal("FetchDeposits:", deposits, err)
+	}
+	if deposits, err := ex.FetchDeposits("", 1600000000001, 0, nil); err != nil || len(deposits) != 1 || deposits[0].Currency != "USDT" {
+		t.Fatal("deposits since:", deposits, err)
+	}
+	withdrawals, err := ex.FetchWithdrawals("USDT", 0, 0, nil)
+	if err != nil || len(withdrawals) != 1 || withdrawals[0].Status != "pending" || withdrawals[0].Network != "ERC20" {
+		t.Fatal("FetchWithdrawals:", withdrawals, err)
+	}
+	transactions, err := ex.FetchTransactions("USDT", 0, 0, nil)
+	if err != nil || len(transactions) != 2 || transactions[0].Type != "deposit" || transactions[1].Type != "withdrawal" {
+		t.Fatal("FetchTransactions:", transactions, err)
+	}
+}
+
+func TestFakeTransfer(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	transfer, err := ex.Transfer("USDT", 300, "spot", "funding", nil)
+	if err != nil || transfer.Id == "" || transfer.FromAccount != "spot" || transfer.ToAccount != "funding" {
+		t.Fatal("Transfer:", transfer, err)
+	}
+	if sent := server.Transfers(); len(sent) != 1 || sent[0].From != "trade" || sent[0].To != "main" {
+		t.Fatal("transfers:", sent)
+	}
+	if _, err := ex.Transfer("USDT", 100, "funding", "margin", nil); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 700 || server.AccountBalance("main", "USDT") != 200 || server.AccountBalance("margin", "USDT") != 100 {
+		t.Fatal("balances:", balance, err)
+	}
+	if _, err := ex.Transfer("USDT", 1000, "spot", "funding", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Error("overdrawn spot:", err)
+	}
+
+	transfers, err := ex.FetchTransfers("USDT", 0, 0, nil)
+	if err != nil || len(transfers) != 2 {
+		t.Fatal("FetchTransfers:", transfers, err)
+	}
+	moves := map[string]float64{}
+	for _, transfer := range transfers {
+		if transfer.Status != "ok" || transfer.Currency != "USDT" || transfer.Timestamp == 0 {
+			t.Error("transfer:", transfer)
+		}
+		moves[transfer.FromAccount+" "+transfer.ToAccount] = transfer.Amount
+	}
+	if moves["spot funding"] != 300 || moves["funding margin"] != 100 {
+		t.Error("transfers:", moves)
+	}
+	if transfers, err := ex.FetchTransfers("USDT", 0, 1, nil); err != nil || len(transfers) != 1 {
+		t.Error("limit:", transfers, err)
+	}
+}
+
+func TestFakeFetchTransfersPages(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	for i := 0; i < 300; i++ {
+		from, to := "spot", "funding"
+		if i%2 == 1 {
+			from, to = to, from
+		}
+		if _, err := ex.Transfer("USDT", 1, from, to, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if transfers, err := ex.FetchTransfers("USDT", 0, 0, nil); err != nil || len(transfers) != 300 {
+		t.Fatal("FetchTransfers:", len(transfers), err)
+	}
+}
+
+func TestFakeAccountType(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+	if _, err := ex.Transfer("USDT", 400, "spot", "margin", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ex.Transfer("USDT", 100, "spot", "funding", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, map[string]interface{}{"accountType": "margin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Engine.OpenOrders("")) != 0 || len(server.AccountEngine("margin").OpenOrders("")) != 1 {
+		t.Fatal("order not placed on the margin account")
+	}
+	ex.ExchangeConfig.AccountType = "margin"
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 400 || balance.Used["USDT"] != 90 {
+		t.Fatal("margin balance:", balance, err)
+	}
+	if openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil); err != nil || len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", openOrders, err)
+	}
+	if _, err := ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the param overrides the config
+	if funding, err := ex.FetchBalance(map[string]interface{}{"accountType": "funding"}); err != nil || funding.Total["USDT"] != 100 {
+		t.Fatal("funding balance:", funding, err)
+	}
+	if spot, err := ex.FetchBalance(map[string]interface{}{"accountType": "spot"}); err != nil || spot.Total["USDT"] != 500 {
+		t.Fatal("spot balance:", spot, err)
+	}
+	if _, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, map[string]interface{}{"accountType": "funding"}); !errors.Is(err, base.NotSupported) {
+		t.Error("order on the funding account:", err)
+	}
+}
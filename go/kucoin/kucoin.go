@@ -1,7 +1,6 @@
 package kucoin
 
 import (
-	"encoding/json"
 	"fmt"
 	. "github.com/georgexdz/ccxt/go/base"
 	"reflect"
@@ -14,32 +13,18 @@ type Kucoin struct {
 
 func New(config *ExchangeConfig) (ex *Kucoin, err error) {
 	ex = new(Kucoin)
-	err = ex.Init(config)
-	ex.Child = ex
-
-	err = ex.InitDescribe()
-	if err != nil {
+	if err = ex.Init(config); err != nil {
 		ex = nil
 		return
 	}
+	ex.Child = ex
 
-	return
-}
-
-func (self *Kucoin) InitDescribe() (err error) {
-	err = json.Unmarshal(self.Child.Describe(), &self.DescribeMap)
-	if err != nil {
-		return
-	}
-
-	err = self.DefineRestApi()
+	err = ex.InitDescribe()
 	if err != nil {
+		ex = nil
 		return
 	}
 
-	self.Options = self.DescribeMap["options"].(map[string]interface{})
-	self.Urls = self.DescribeMap["urls"].(map[string]interface{})
-	self.Exceptions = self.DescribeMap["exceptions"].(map[string]interface{})
 	return
 }
 
@@ -72,6 +57,9 @@ func (self *Kucoin) Describe() []byte {
         "withdraw": true,
         "fetchDeposits": true,
         "fetchWithdrawals": true,
+        "fetchTransactions": true,
+        "transfer": true,
+        "fetchTransfers": true,
         "fetchBalance": true,
         "fetchTrades": true,
         "fetchMyTrades": true,
@@ -133,6 +121,7 @@ func (self *Kucoin) Describe() []byte {
             "get": [
                 "accounts",
                 "accounts/{accountId}",
+                "accounts/ledgers",
                 "accounts/{accountId}/ledgers",
                 "accounts/{accountId}/holds",
                 "accounts/transferable",
@@ -171,6 +160,7 @@ func (self *Kucoin) Describe() []byte {
                 "withdrawals",
                 "orders",
                 "orders/multi",
+                "margin/order",
                 "margin/borrow",
                 "margin/repay/all",
                 "margin/repay/single",
@@ -219,6 +209,7 @@ func (self *Kucoin) Describe() []byte {
             "503": "ExchangeNotAvailable",
             "200004": "InsufficientFunds",
             "230003": "InsufficientFunds",
+            "260000": "InvalidAddress",
             "260100": "InsufficientFunds",
             "300000": "InvalidOrder",
             "400000": "BadSymbol",
@@ -262,10 +253,19 @@ func (self *Kucoin) Describe() []byte {
     "options": {
         "version": "v1",
         "symbolSeparator": "-",
+        "rateLimitHeaders": {
+            "remaining": "gw-ratelimit-remaining",
+            "limit": "gw-ratelimit-limit"
+        },
         "fetchMyTradesMethod": "private_get_fills",
         "fetchBalance": {
             "type": "trade"
         },
+        "accountsByType": {
+            "funding": "main",
+            "spot": "trade",
+            "margin": "margin"
+        },
         "versions": {
             "public": {
                 "GET": {
@@ -287,6 +287,16 @@ func (self *Kucoin) Describe() []byte {
 }`)
 }
 
+func (self *Kucoin) FetchTime(params map[string]interface{}) (timestamp int64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	response := self.ApiFunc("publicGetTimestamp", params, nil, nil)
+	return self.SafeInteger(response, "data", 0), nil
+}
+
 func (self *Kucoin) FetchMarkets(params map[string]interface{}) []interface{} {
 	response := self.ApiFunc("publicGetSymbols", params, nil, nil)
 	data := self.Member(response, "data")
@@ -390,6 +400,10 @@ func (self *Kucoin) CreateOrder(symbol string, _type string, side string, amount
 		}
 	}()
 	self.LoadMarkets()
+	method := "privatePostOrders"
+	if self.TradeType(params) == "MARGIN_TRADE" {
+		method = "privatePostMarginOrder"
+	}
 	marketId := self.MarketId(symbol)
 	clientOrderId := self.SafeString2(params, "clientOid", "clientOrderId", self.Uuid())
 	params = self.Omit(params, []interface{}{"clientOid", "clientOrderId"})
@@ -409,7 +423,7 @@ func (self *Kucoin) CreateOrder(symbol string, _type string, side string, amount
 			self.SetValue(request, "size", self.Float64ToString(amount))
 		}
 	}
-	response := self.ApiFunc("privatePostOrders", self.Extend(request, params), nil, nil)
+	response := self.ApiFunc(method, self.Extend(request, params), nil, nil)
 	data := self.SafeValue(response, "data", map[string]interface{}{})
 	timestamp := self.Milliseconds()
 	order := map[string]interface{}{
@@ -434,6 +448,20 @@ func (self *Kucoin) CreateOrder(symbol string, _type string, side string, amount
 	return self.ToOrder(order), nil
 }
 
+// TradeType returns the tradeType of the unified account type of a call,
+// TRADE for spot or MARGIN_TRADE for cross margin
+func (self *Kucoin) TradeType(params map[string]interface{}) string {
+	switch accountType := self.SelectAccountType(params, "spot"); accountType {
+	case "spot":
+		return "TRADE"
+	case "margin":
+		return "MARGIN_TRADE"
+	default:
+		self.RaiseException("NotSupported", self.Id+" cannot trade on the "+accountType+" account")
+	}
+	return ""
+}
+
 func (self *Kucoin) CancelOrder(id string, symbol string, params map[string]interface{}) (response interface{}, err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -452,6 +480,10 @@ func (self *Kucoin) FetchOrdersByStatus(status string, symbol string, since int6
 	request := map[string]interface{}{
 		"status": status,
 	}
+	// spot orders are the default
+	if tradeType := self.TradeType(params); tradeType != "TRADE" {
+		self.SetValue(request, "tradeType", tradeType)
+	}
 	var market interface{}
 	if self.ToBool(!self.TestNil(symbol)) {
 		market = self.Market(symbol)
@@ -575,6 +607,7 @@ func (self *Kucoin) FetchBalance(params map[string]interface{}) (balanceResult *
 		}
 	}()
 	self.LoadMarkets()
+	accountType := self.SelectAccountType(params, "")
 	var _type interface{}
 	request := map[string]interface{}{}
 	if self.ToBool(self.InMap("type", params)) {
@@ -586,6 +619,10 @@ func (self *Kucoin) FetchBalance(params map[string]interface{}) (balanceResult *
 	} else {
 		options := self.SafeValue(self.Options, "fetchBalance", map[string]interface{}{})
 		_type = self.SafeString(options, "type", "trade")
+		if accountType != "" {
+			_type = self.AccountId(accountType)
+			self.SetValue(request, "type", _type)
+		}
 	}
 	response := self.ApiFunc("privateGetAccounts", self.Extend(request, params), nil, nil)
 	data := self.SafeValue(response, "data", []interface{}{})
@@ -608,6 +645,260 @@ func (self *Kucoin) FetchBalance(params map[string]interface{}) (balanceResult *
 	return self.ParseBalance(result), nil
 }
 
+func (self *Kucoin) FetchDepositAddress(code string, network string, params map[string]interface{}) (result *DepositAddress, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	request := map[string]interface{}{
+		"currency": self.CurrencyId(code),
+	}
+	if network != "" {
+		self.SetValue(request, "chain", strings.ToLower(network))
+	}
+	response := self.ApiFunc("privateGetDepositAddresses", self.Extend(request, params), nil, nil)
+	data := self.SafeValue(response, "data", nil)
+	if self.TestNil(data) {
+		// kucoin only hands out an address once it has been created, until
+		// then it is pending like one the exchange is still generating
+		self.RaiseException("AddressPending", self.Id+" has no "+code+" deposit address, create one with CreateDepositAddress")
+	}
+	address := self.SafeString(data, "address", "")
+	tag := self.SafeString(data, "memo", "")
+	return self.ToDepositAddress(code, address, tag, network, data), nil
+}
+
+func (self *Kucoin) CreateDepositAddress(code string, network string, params map[string]interface{}) (result *DepositAddress, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	request := map[string]interface{}{
+		"currency": self.CurrencyId(code),
+	}
+	if network != "" {
+		self.SetValue(request, "chain", strings.ToLower(network))
+	}
+	response := self.ApiFunc("privatePostDepositAddresses", self.Extend(request, params), nil, nil)
+	data := self.SafeValue(response, "data", map[string]interface{}{})
+	address := self.SafeString(data, "address", "")
+	tag := self.SafeString(data, "memo", "")
+	return self.ToDepositAddress(code, address, tag, network, data), nil
+}
+
+func (self *Kucoin) Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (result *Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.CheckAddress(address)
+	request := map[string]interface{}{
+		"currency": self.CurrencyId(code),
+		"address":  address,
+		"amount":   self.NumberToString(amount),
+	}
+	if tag != "" {
+		self.SetValue(request, "memo", tag)
+	}
+	if network != "" {
+		self.SetValue(request, "chain", strings.ToLower(network))
+	}
+	response := self.ApiFunc("privatePostWithdrawals", self.Extend(request, params), nil, nil)
+	data := self.SafeValue(response, "data", map[string]interface{}{})
+	return &Transaction{
+		Id:       self.SafeString(data, "withdrawalId", ""),
+		Type:     "withdrawal",
+		Currency: code,
+		Network:  network,
+		Address:  address,
+		Tag:      tag,
+		Amount:   amount,
+		Status:   "pending",
+		Info:     response,
+	}, nil
+}
+
+func (self *Kucoin) FetchDeposits(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("deposit", code, since, limit, params), nil
+}
+
+func (self *Kucoin) FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("withdrawal", code, since, limit, params), nil
+}
+
+func (self *Kucoin) FetchTransactionsByType(typ string, code string, since int64, limit int64, params map[string]interface{}) []*Transaction {
+	request := map[string]interface{}{}
+	if code != "" {
+		self.SetValue(request, "currency", self.CurrencyId(code))
+	}
+	if since > 0 {
+		self.SetValue(request, "startAt", since)
+	}
+	if limit > 0 {
+		self.SetValue(request, "pageSize", limit)
+	}
+	method := "privateGetDeposits"
+	if typ == "withdrawal" {
+		method = "privateGetWithdrawals"
+	}
+	response := self.ApiFunc(method, self.Extend(request, params), nil, nil)
+	responseData := self.SafeValue(response, "data", map[string]interface{}{})
+	items := self.SafeValue(responseData, "items", []interface{}{})
+	result := []*Transaction{}
+	for i := 0; i < self.Length(items); i++ {
+		result = append(result, self.ParseTransaction(self.Member(items, i), typ))
+	}
+	return self.FilterTransactions(result, code, since, limit)
+}
+
+func (self *Kucoin) ParseTransactionStatus(status string) string {
+	statuses := map[string]interface{}{
+		"PROCESSING":        "pending",
+		"WALLET_PROCESSING": "pending",
+		"SUCCESS":           "ok",
+		"FAILURE":           "failed",
+	}
+	return self.SafeString(statuses, status, status)
+}
+
+func (self *Kucoin) ParseTransaction(transaction interface{}, typ string) *Transaction {
+	// deposit txids are suffixed with the output, e.g. "0x123@0"
+	txid := self.SafeString(transaction, "walletTxId", "")
+	if i := strings.Index(txid, "@"); i >= 0 {
+		txid = txid[:i]
+	}
+	timestamp := self.SafeInteger(transaction, "createdAt", 0)
+	return &Transaction{
+		Id:        self.SafeString(transaction, "id", ""),
+		Txid:      txid,
+		Type:      typ,
+		Currency:  self.SafeCurrencyCode(self.SafeString(transaction, "currency", "")),
+		Network:   strings.ToUpper(self.SafeString(transaction, "chain", "")),
+		Address:   self.SafeString(transaction, "address", ""),
+		Tag:       self.SafeString(transaction, "memo", ""),
+		Amount:    self.SafeFloat(transaction, "amount", 0),
+		Fee:       self.SafeFloat(transaction, "fee", 0),
+		Status:    self.ParseTransactionStatus(self.SafeString(transaction, "status", "")),
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Updated:   self.SafeInteger(transaction, "updatedAt", 0),
+		Info:      transaction,
+	}
+}
+
+func (self *Kucoin) Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (result *TransferEntry, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	request := map[string]interface{}{
+		"clientOid": self.SafeString(params, "clientOid", self.Uuid()),
+		"currency":  self.CurrencyId(code),
+		"from":      self.AccountId(fromAccount),
+		"to":        self.AccountId(toAccount),
+		"amount":    self.NumberToString(amount),
+	}
+	response := self.ApiFunc("privatePostAccountsInnerTransfer", self.Extend(request, params), nil, nil)
+	data := self.SafeValue(response, "data", map[string]interface{}{})
+	timestamp := self.Milliseconds()
+	return &TransferEntry{
+		Id:          self.SafeString(data, "orderId", ""),
+		Currency:    code,
+		Amount:      amount,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Status:      "ok",
+		Timestamp:   timestamp,
+		Datetime:    self.Iso8601(timestamp),
+		Info:        response,
+	}, nil
+}
+
+// FetchTransfers reads the transfers off the account ledgers. Kucoin books
+// each one as an "out" entry on one account and an "in" entry on the other,
+// those with the same currency, amount and time are paired up.
+func (self *Kucoin) FetchTransfers(code string, since int64, limit int64, params map[string]interface{}) (result []*TransferEntry, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"bizType":     "Transfer",
+		"currentPage": 1,
+		"pageSize":    500,
+	}
+	if code != "" {
+		self.SetValue(request, "currency", self.CurrencyId(code))
+	}
+	if since > 0 {
+		self.SetValue(request, "startAt", since)
+	}
+	entries := []interface{}{}
+	for {
+		response := self.ApiFunc("privateGetAccountsLedgers", self.Extend(request, params), nil, nil)
+		data := self.SafeValue(response, "data", map[string]interface{}{})
+		items := self.SafeValue(data, "items", []interface{}{})
+		for i := 0; i < self.Length(items); i++ {
+			entries = append(entries, self.Member(items, i))
+		}
+		page := self.SafeInteger(data, "currentPage", 0)
+		if page <= 0 || page >= self.SafeInteger(data, "totalPage", 0) {
+			break
+		}
+		self.SetValue(request, "currentPage", page+1)
+	}
+	result = []*TransferEntry{}
+	paired := map[int]bool{}
+	for i, out := range entries {
+		if self.SafeString(out, "direction", "") != "out" {
+			continue
+		}
+		for j, in := range entries {
+			if !paired[j] && self.SafeString(in, "direction", "") == "in" &&
+				self.SafeString(in, "currency", "") == self.SafeString(out, "currency", "") &&
+				self.SafeFloat(in, "amount", 0) == self.SafeFloat(out, "amount", 0) &&
+				self.SafeInteger(in, "createdAt", 0) == self.SafeInteger(out, "createdAt", 0) {
+				paired[i], paired[j] = true, true
+				result = append(result, self.ParseTransfer(out, in))
+				break
+			}
+		}
+	}
+	return self.FilterTransfers(result, since, limit), nil
+}
+
+// ParseTransfer joins the two ledger entries of a transfer
+func (self *Kucoin) ParseTransfer(out interface{}, in interface{}) *TransferEntry {
+	timestamp := self.SafeInteger(out, "createdAt", 0)
+	return &TransferEntry{
+		Id:          self.SafeString(out, "id", ""),
+		Currency:    self.SafeCurrencyCode(self.SafeString(out, "currency", "")),
+		Amount:      self.SafeFloat(out, "amount", 0),
+		FromAccount: self.AccountType(strings.ToLower(self.SafeString(out, "accountType", ""))),
+		ToAccount:   self.AccountType(strings.ToLower(self.SafeString(in, "accountType", ""))),
+		Status:      "ok",
+		Timestamp:   timestamp,
+		Datetime:    self.Iso8601(timestamp),
+		Info:        []interface{}{out, in},
+	}
+}
+
 func (self *Kucoin) Sign(path string, api string, method string, params map[string]interface{}, headers interface{}, body interface{}) (ret interface{}) {
 	versions := self.SafeValue(self.Options, "versions", map[string]interface{}{})
 	apiVersions := self.SafeValue(versions, api, nil)
@@ -630,14 +921,15 @@ func (self *Kucoin) Sign(path string, api string, method string, params map[stri
 	url := fmt.Sprintf("%v", self.Member(self.Member(self.Urls, "api"), api)) + endpoint
 	if self.ToBool(api == "private") {
 		self.CheckRequiredCredentials()
-		timestamp := fmt.Sprintf("%v", self.Nonce())
+		credentials := self.Credentials()
+		timestamp := fmt.Sprintf("%v", self.ServerMilliseconds())
 		headers = self.Extend(map[string]interface{}{
-			"KC-API-KEY":        self.ApiKey,
+			"KC-API-KEY":        credentials.ApiKey,
 			"KC-API-TIMESTAMP":  timestamp,
-			"KC-API-PASSPHRASE": self.Password,
+			"KC-API-PASSPHRASE": credentials.Password,
 		}, headers)
 		payload := timestamp + method + endpoint + endpart
-		signature := self.Hmac(self.Encode(payload), self.Encode(self.Secret), "sha256", "base64")
+		signature := self.Hmac(self.Encode(payload), self.Encode(credentials.Secret), "sha256", "base64")
 		self.SetValue(headers, "KC-API-SIGN", self.Decode(signature))
 	}
 	return map[string]interface{}{
@@ -650,13 +942,12 @@ func (self *Kucoin) Sign(path string, api string, method string, params map[stri
 
 func (self *Kucoin) HandleErrors(code int64, reason string, url string, method string, headers interface{}, body string, response interface{}, requestHeaders interface{}, requestBody interface{}) {
 	if self.ToBool(!self.ToBool(response)) {
-		self.ThrowBroadlyMatchedException(self.Member(self.Exceptions, "broad"), body, body)
+		self.ThrowMatchedException(body, body)
 		return
 	}
 	errorCode := self.SafeString(response, "code", "")
 	message := self.SafeString(response, "msg", "")
-	self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), message, message)
-	self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), errorCode, message)
+	self.ThrowMatchedException(message, message, errorCode)
 }
 
 func (self *Kucoin) LoadMarkets() map[string]*Market {
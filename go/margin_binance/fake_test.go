@@ -0,0 +1,150 @@
+package margin_binance
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/base/conformance"
+	"github.com/georgexdz/ccxt/go/fake"
+)
+
+func newFakeServer() *fake.Server {
+	return fake.NewBinance(&fake.Config{
+		ApiKey:       "fake-key",
+		Secret:       "fake-secret",
+		Balances:     map[string]float64{"USDT": 1000},
+		BorrowLimits: map[string]float64{"USDT": 1000},
+		Books: map[string]*base.OrderBook{
+			"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}},
+		},
+	})
+}
+
+func newFakeExchange(t *testing.T, server *fake.Server, secret string) *MarginBinance {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.ApiKey = "fake-key"
+	ex.Secret = secret
+	ex.SetBaseUrl(server.URL)
+	return ex
+}
+
+// fundMargin moves the whole spot balance to the margin account
+func fundMargin(t *testing.T, ex *MarginBinance) *MarginBinance {
+	if _, err := ex.Transfer("USDT", 1000, "spot", "margin", nil); err != nil {
+		t.Fatal(err)
+	}
+	return ex
+}
+
+func TestFakeOrderFillsWhenMarketMoves(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := fundMargin(t, newFakeExchange(t, server, "fake-secret"))
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Used["USDT"] != 90 || balance.Free["USDT"] != 910 {
+		t.Fatal("locked balance:", ex.Json(balance))
+	}
+	if len(server.Engine.OpenOrders("")) != 0 {
+		t.Fatal("order placed on the spot account")
+	}
+
+	server.SetBook("BTC/USDT", &base.OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Id != order.Id || o.Status != "closed" || o.Filled != 0.01 {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	balance, err = ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Total["BTC"] != 0.01 || balance.Total["USDT"] != 910 || balance.Used["USDT"] != 0 {
+		t.Fatal("filled balance:", ex.Json(balance))
+	}
+	spot, err := ex.FetchBalance(map[string]interface{}{"type": "spot"})
+	if err != nil || spot.Total["USDT"] != 0 || spot.Total["BTC"] != 0 {
+		t.Fatal("spot balance:", spot, err)
+	}
+}
+
+func TestFakeCancelOrder(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := fundMargin(t, newFakeExchange(t, server, "fake-secret"))
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", ex.Json(openOrders))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "canceled" {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, base.OrderNotFound) {
+		t.Fatal("second cancel:", err)
+	}
+}
+
+func TestFakeTradeBorrowedFunds(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := fundMargin(t, newFakeExchange(t, server, "fake-secret"))
+
+	if _, err := ex.Borrow("USDT", 500, "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.15, 9000, nil); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Used["USDT"] != 1350 || balance.Total["USDT"] != 1500 || balance.Debt["USDT"] != 500 {
+		t.Fatal("margin balance:", ex.Json(balance))
+	}
+}
+
+func TestFakeRejectsBadSignature(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "wrong-secret")
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+		t.Fatal("FetchBalance:", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	conformance.Run(t, fundMargin(t, newFakeExchange(t, server, "fake-secret")), server)
+}
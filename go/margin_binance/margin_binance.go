@@ -0,0 +1,32 @@
+package margin_binance
+
+import (
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/binance"
+)
+
+type MarginBinance struct {
+	binance.Binance
+}
+
+func New(config *ExchangeConfig) (ex *MarginBinance, err error) {
+	ex = new(MarginBinance)
+	if err = ex.Init(config); err != nil {
+		ex = nil
+		return
+	}
+	ex.Child = ex
+
+	err = ex.InitDescribe()
+	if err != nil {
+		ex = nil
+		return
+	}
+
+	return
+}
+
+// Describe is binance's with margin as the default account
+func (self *MarginBinance) Describe() []byte {
+	return ExtendDescribe(self.Binance.Describe(), `{"id": "margin_binance", "options": {"defaultType": "margin"}}`)
+}
@@ -0,0 +1,21 @@
+package margin_bitmax
+
+import (
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base/conformance"
+)
+
+func TestHandleErrors(t *testing.T) {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.RunErrors(t, ex, []conformance.ErrorCase{
+		{Status: 200, Body: `{"code":0,"data":[]}`},
+		{Status: 200, Body: `{"code":6010,"message":"Not enough balance."}`, Class: "InsufficientFunds"},
+		{Status: 200, Body: `{"code":60060,"message":"Invalid order"}`, Class: "InvalidOrder"},
+		{Status: 200, Body: `{"code":200014,"message":"No permission"}`, Class: "PermissionDenied"},
+		{Status: 403, Body: ``, Class: "PermissionDenied"},
+	})
+}
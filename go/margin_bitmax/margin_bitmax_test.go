@@ -1,90 +1,112 @@
 package margin_bitmax
 
 import (
-	"encoding/json"
-	"fmt"
-	"io/ioutil"
 	"log"
+	"os"
 	"testing"
+
+	"github.com/georgexdz/ccxt/go/base"
 )
 
 func init() {
 	log.SetFlags(log.LstdFlags | log.Lmicroseconds)
 }
 
-// api.json 需要放到和此文件同一目录
+// api.json 需要放到和此文件同一目录, 只在录制时使用
 func loadApiKey(ex *MarginBitmax) {
-	plan, err := ioutil.ReadFile("api.json")
-	if err != nil {
-		return
-	}
-
-	var data map[string]interface{}
-	err = json.Unmarshal(plan, &data)
-	if err != nil {
-		return
-	}
-
-	ex.ApiKey = data["apiKey"].(string)
-	ex.Secret = data["secret"].(string)
-	if data["password"] != nil {
-		ex.Password = data["password"].(string)
+	if _, err := os.Stat("api.json"); err == nil {
+		ex.SetCredentialProvider(base.NewFileCredentials("api.json"))
 	}
 }
 
-func TestFetchOrderBook(t *testing.T) {
+// 默认从 testdata/margin_bitmax_synthetic.json 离线回放, 它是按交易所 api 格式手写的合成数据
+// (见其 comment 字段), 并非真实录制. CCXT_RECORD=1 时访问交易所并重新录制,
+// 录制结果请另存为 testdata/margin_bitmax.json 并修改下面的路径
+func newTestExchange(t *testing.T) *MarginBitmax {
 	ex, err := New(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
+	rec, err := base.NewRecorder("testdata/margin_bitmax_synthetic.json", base.RecorderModeFromEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.SetTransport(rec)
+	if rec.Mode == base.RecordMode {
+		loadApiKey(ex)
+		t.Cleanup(func() {
+			if err := rec.Save(); err != nil {
+				t.Error(err)
+			}
+		})
+	} else {
+		ex.ApiKey = "test-key"
+		ex.Secret = "test-secret"
+	}
+	return ex
+}
 
-	ex.Verbose = true
-	loadApiKey(ex)
-	fmt.Println(ex.ApiKey)
+func TestFetchOrderBook(t *testing.T) {
+	ex := newTestExchange(t)
 
-	// @ FetchOrderBook
 	orderbook, err := ex.FetchOrderBook("BTC/USDT", 5, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("##### FetchOrderBook:", orderbook)
+	if len(orderbook.Bids) != 5 || len(orderbook.Asks) != 5 {
+		t.Fatal("depth:", orderbook)
+	}
+	if orderbook.Bids[0][0] >= orderbook.Asks[0][0] {
+		t.Fatal("crossed book:", orderbook)
+	}
+}
+
+func TestFetchBalance(t *testing.T) {
+	ex := newTestExchange(t)
 
-	// @ FetchBalance
 	balance, err := ex.FetchBalance(nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("##### FetchBalance:", ex.Json(balance))
-	return
+	if balance.Free["USDT"] != 100 || balance.Used["USDT"] != 16 || balance.Total["USDT"] != 116 {
+		t.Fatal("USDT:", ex.Json(balance))
+	}
+}
+
+func TestOrderLifecycle(t *testing.T) {
+	ex := newTestExchange(t)
 
-	// @ CreateOrder
 	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.001, 8000., nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("##### CreateOrder:", order.Id)
+	if order.Id == "" || order.Symbol != "BTC/USDT" {
+		t.Fatal("CreateOrder:", ex.Json(order))
+	}
 
-	// @ FetchOrder
 	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("##### FetchOrder:", ex.Json(o))
+	if o.Id != order.Id || o.Price != 8000 || o.Amount != 0.001 || o.Side != "buy" {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
 
-	// @ FetchOpenOrders
 	openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 1000, nil)
 	if err != nil {
 		t.Fatal(err)
 	}
-	log.Println("##### FetchOpenOrders:", ex.Json(openOrders))
+	if len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", ex.Json(openOrders))
+	}
 
-	// @ CancelOrder
 	for _, order := range openOrders {
 		resp, err := ex.CancelOrder(order.Id, "BTC/USDT", nil)
 		if err != nil {
 			t.Fatal(err)
 		}
-		log.Println("##### CancelOrder:", resp)
+		if resp.(map[string]interface{})["id"] != order.Id {
+			t.Fatal("CancelOrder:", ex.Json(resp))
+		}
 	}
-
 }
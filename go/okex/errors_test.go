@@ -0,0 +1,25 @@
+package okex
+
+import (
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base/conformance"
+)
+
+func TestHandleErrors(t *testing.T) {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conformance.RunErrors(t, ex, []conformance.ErrorCase{
+		{Status: 200, Body: `{"iso":"2020-01-01T00:00:00.000Z"}`},
+		{Status: 400, Body: `{"code":33014,"message":"Order does not exist"}`, Class: "OrderNotFound"},
+		{Status: 400, Body: `{"error_code":"33003","message":"Insufficient balance for margin trading"}`, Class: "InsufficientFunds"},
+		{Status: 401, Body: `{"code":30001,"message":"OK-ACCESS-KEY header is required"}`, Class: "AuthenticationError"},
+		{Status: 400, Body: `{"code":30005,"message":"Invalid OK-ACCESS-TIMESTAMP"}`, Class: "InvalidNonce"},
+		{Status: 502, Body: `{"message":"failure to get a peer from the ring-balancer"}`, Class: "ExchangeNotAvailable"},
+		{Status: 400, Body: `{"code":99999,"message":"something new"}`, Class: "ExchangeError"},
+		{Status: 400, Body: `Bad Request`, Class: "BadRequest"},
+		{Status: 503, Body: ``, Class: "ExchangeNotAvailable"},
+	})
+}
@@ -0,0 +1,551 @@
+package okex
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/base/conformance"
+	"github.com/georgexdz/ccxt/go/fake"
+)
+
+func newFakeServer() *fake.Server {
+	return fake.NewOkex(&fake.Config{
+		ApiKey:       "fake-key",
+		Secret:       "fake-secret",
+		Password:     "fake-password",
+		Balances:     map[string]float64{"USDT": 1000},
+		BorrowRate:   0.001,
+		BorrowLimits: map[string]float64{"USDT": 1000},
+		Books: map[string]*base.OrderBook{
+			"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}},
+		},
+	})
+}
+
+func newFakeExchange(t *testing.T, server *fake.Server, secret string) *Okex {
+	ex, err := New(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.ApiKey = "fake-key"
+	ex.Secret = secret
+	ex.Password = "fake-password"
+	ex.SetBaseUrl(server.URL)
+	return ex
+}
+
+func TestFakeOrderFillsWhenMarketMoves(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Used["USDT"] != 90 || balance.Free["USDT"] != 910 {
+		t.Fatal("locked balance:", ex.Json(balance))
+	}
+
+	server.SetBook("BTC/USDT", &base.OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Id != order.Id || o.Status != "closed" || o.Filled != 0.01 {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	balance, err = ex.FetchBalance(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if balance.Total["BTC"] != 0.01 || balance.Total["USDT"] != 910 || balance.Used["USDT"] != 0 {
+		t.Fatal("filled balance:", ex.Json(balance))
+	}
+}
+
+func TestFakeCancelOrder(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", ex.Json(openOrders))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o.Status != "canceled" {
+		t.Fatal("FetchOrder:", ex.Json(o))
+	}
+	if _, err = ex.CancelOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, base.OrderNotFound) {
+		t.Fatal("second cancel:", err)
+	}
+}
+
+func TestFakeRejectsBadSignature(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "wrong-secret")
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+		t.Fatal("FetchBalance:", err)
+	}
+}
+
+func TestConformance(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	conformance.Run(t, newFakeExchange(t, server, "fake-secret"), server)
+}
+
+func TestFakeFetchTime(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	// the exchange's clock runs an hour ahead of ours
+	server.Engine.Now = func() int64 { return time.Now().UnixNano()/int64(time.Millisecond) + 3600000 }
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	difference, err := ex.LoadTimeDifference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if difference > -3599000 || difference < -3601000 {
+		t.Fatal("time difference:", difference)
+	}
+	if _, err := ex.FetchBalance(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// setting the clock back an hour keeps nonces increasing
+	last := ex.Nonce()
+	server.Engine.Now = func() int64 { return time.Now().UnixNano() / int64(time.Millisecond) }
+	if _, err := ex.LoadTimeDifference(); err != nil {
+		t.Fatal(err)
+	}
+	if nonce := ex.Nonce(); nonce <= last {
+		t.Fatal("nonce went back:", nonce, last)
+	}
+}
+
+func TestFakeCredentialRotation(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "")
+	dir, err := ioutil.TempDir("", "okex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "api.json")
+	write := func(secret string, password string) {
+		data := fmt.Sprintf(`{"apiKey":"fake-key","secret":%q,"password":%q}`, secret, password)
+		if err := ioutil.WriteFile(path, []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("old", "fake-password")
+	ex.SetCredentialProvider(base.NewFileCredentials(path))
+
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) {
+		t.Fatal("old secret:", err)
+	}
+	write("fake-secret", "fake-password")
+	if _, err := ex.FetchBalance(nil); err != nil {
+		t.Fatal("rotated secret:", err)
+	}
+	write("fake-secret", "")
+	if _, err := ex.FetchBalance(nil); !errors.Is(err, base.AuthenticationError) || !strings.Contains(err.Error(), "`password`") {
+		t.Fatal("missing password:", err)
+	}
+}
+
+func TestFakeDepositAndWithdraw(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	address, err := ex.FetchDepositAddress("BTC", "", nil)
+	if err != nil || address.Address == "" || address.Tag != "" {
+		t.Fatal("BTC address:", address, err)
+	}
+	if address, err = ex.FetchDepositAddress("XRP", "XRP", nil); err != nil || address.Tag == "" {
+		t.Fatal("XRP tag:", address, err)
+	}
+	server.SetDepositAddress("ETH", "", "", "")
+	if _, err := ex.FetchDepositAddress("ETH", "", nil); !errors.Is(err, base.AddressPending) {
+		t.Fatal("pending address:", err)
+	}
+
+	if _, err := ex.Withdraw("USDT", 100, "0xabc", "", "ERC20", nil); !errors.Is(err, base.ArgumentsRequired) {
+		t.Fatal("withdrawal without a fee:", err)
+	}
+	withdrawal, err := ex.Withdraw("USDT", 100, "0xabc", "", "ERC20", map[string]interface{}{"fee": "1"})
+	if err != nil || withdrawal.Id == "" || withdrawal.Status != "pending" {
+		t.Fatal("Withdraw:", withdrawal, err)
+	}
+	if _, err := ex.Withdraw("USDT", 1, "invalid-address", "", "", map[string]interface{}{"fee": "1"}); !errors.Is(err, base.InvalidAddress) {
+		t.Fatal("invalid address:", err)
+	}
+	if sent := server.Withdrawals(); len(sent) != 1 || sent[0].Network != "ERC20" || sent[0].Address != "0xabc" {
+		t.Fatal("withdrawals:", sent)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 900 {
+		t.Fatal("balance after withdrawal:", balance, err)
+	}
+}
+
+func TestFakeTransactionHistory(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	now := server.Engine.Now
+	server.Engine.Now = func() int64 { return 1600000000000 }
+	server.Deposit("BTC", "BTC", 0.5)
+	server.Engine.Now = func() int64 { return 1600000100000 }
+	server.Deposit("USDT", "TRC20", 200)
+	server.Engine.Now = now
+	if _, err := ex.Withdraw("USDT", 100, "0xabc", "", "ERC20", map[string]interface{}{"fee": "0"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deposits, err := ex.FetchDeposits("", 0, 0, nil)
+	if err != nil || len(deposits) != 2 || deposits[0].Currency != "BTC" || deposits[1].Network != "TRC20" || deposits[1].Status != "ok" {
+		t.Fatal("FetchDeposits:", deposits, err)
+	}
+	if deposits, err := ex.FetchDeposits("", 1600000000001, 0, nil); err != nil || len(deposits) != 1 || deposits[0].Currency != "USDT" {
+		t.Fatal("deposits since:", deposits, err)
+	}
+	withdrawals, err := ex.FetchWithdrawals("USDT", 0, 0, nil)
+	if err != nil || len(withdrawals) != 1 || withdrawals[0].Status != "pending" || withdrawals[0].Network != "ERC20" || withdrawals[0].Address != "0xabc" {
+		t.Fatal("FetchWithdrawals:", withdrawals, err)
+	}
+	transactions, err := ex.FetchTransactions("USDT", 0, 0, nil)
+	if err != nil || len(transactions) != 2 || transactions[0].Type != "deposit" || transactions[1].Type != "withdrawal" {
+		t.Fatal("FetchTransactions:", transactions, err)
+	}
+}
+
+func TestFakeTransfer(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	transfer, err := ex.Transfer("USDT", 300, "spot", "swap", nil)
+	if err != nil || transfer.Id == "" || transfer.ToAccount != "swap" {
+		t.Fatal("Transfer:", transfer, err)
+	}
+	if sent := server.Transfers(); len(sent) != 1 || sent[0].From != "1" || sent[0].To != "9" {
+		t.Fatal("transfers:", sent)
+	}
+	if _, err := ex.Transfer("USDT", 100, "swap", "funding", nil); err != nil {
+		t.Fatal(err)
+	}
+	balance, err := ex.FetchBalance(nil)
+	if err != nil || balance.Total["USDT"] != 700 || server.AccountBalance("9", "USDT") != 200 || server.AccountBalance("6", "USDT") != 100 {
+		t.Fatal("balances:", balance, err)
+	}
+	if _, err := ex.Transfer("USDT", 500, "swap", "spot", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Error("overdrawn swap:", err)
+	}
+	if _, err := ex.Transfer("USDT", 40, "funding", "spot", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the funding ledger has no record of spot to swap
+	transfers, err := ex.FetchTransfers("USDT", 0, 0, nil)
+	if err != nil || len(transfers) != 2 {
+		t.Fatal("FetchTransfers:", transfers, err)
+	}
+	moves := map[string]float64{}
+	for _, transfer := range transfers {
+		if transfer.Status != "ok" || transfer.Currency != "USDT" || transfer.Timestamp == 0 {
+			t.Error("transfer:", transfer)
+		}
+		moves[transfer.FromAccount+" "+transfer.ToAccount] = transfer.Amount
+	}
+	if moves["swap funding"] != 100 || moves["funding spot"] != 40 {
+		t.Error("transfers:", moves)
+	}
+	if transfers, err := ex.FetchTransfers("BTC", 0, 0, nil); err != nil || len(transfers) != 0 {
+		t.Error("other currency:", transfers, err)
+	}
+}
+
+func TestFakeFetchTransfersPages(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	for i := 0; i < 150; i++ {
+		from, to := "spot", "funding"
+		if i%2 == 1 {
+			from, to = to, from
+		}
+		if _, err := ex.Transfer("USDT", 1, from, to, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if transfers, err := ex.FetchTransfers("USDT", 0, 0, nil); err != nil || len(transfers) != 100 {
+		t.Fatal("newest page:", len(transfers), err)
+	}
+	transfers, err := ex.FetchTransfers("USDT", 1, 0, nil)
+	if err != nil || len(transfers) != 150 {
+		t.Fatal("since:", len(transfers), err)
+	}
+}
+
+func TestFakeMarginLoan(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	loan, err := ex.Borrow("USDT", 500, "BTC/USDT", nil)
+	if err != nil || loan.Id == "" || loan.Symbol != "BTC/USDT" {
+		t.Fatal("Borrow:", loan, err)
+	}
+	if _, err := ex.Borrow("USDT", 600, "BTC/USDT", nil); !errors.Is(err, base.InsufficientFunds) {
+		t.Error("over the limit:", err)
+	}
+	if _, err := ex.Borrow("USDT", 1, "", nil); !errors.Is(err, base.ArgumentsRequired) {
+		t.Error("cross margin:", err)
+	}
+	if max, err := ex.FetchMaxBorrowable("USDT", "BTC/USDT", nil); err != nil || max != 500 {
+		t.Error("FetchMaxBorrowable:", max, err)
+	}
+	if rate, err := ex.FetchBorrowRate("USDT", nil); err != nil || rate.Rate != 0.001 {
+		t.Error("FetchBorrowRate:", rate, err)
+	}
+	var paths []string
+	ex.Use(func(call *base.Call, next base.Next) (interface{}, error) {
+		paths = append(paths, call.Path)
+		return next(call)
+	})
+	if rate, err := ex.FetchBorrowRate("USDT", map[string]interface{}{"instrument_id": "BTC-USDT"}); err != nil || rate.Rate != 0.001 ||
+		len(paths) != 1 || paths[0] != "accounts/{instrument_id}/availability" {
+		t.Error("FetchBorrowRate of an instrument:", rate, err, paths)
+	}
+	if _, err := ex.FetchBorrowRate("USDT", map[string]interface{}{"instrument_id": "NOPE-USDT"}); !errors.Is(err, base.BadSymbol) {
+		t.Error("unknown instrument:", err)
+	}
+
+	server.AccrueInterest()
+	interest, err := ex.FetchBorrowInterest("USDT", "", 0, 0, nil)
+	if err != nil || len(interest) != 1 || interest[0].Interest != 0.5 || interest[0].Amount != 500 || interest[0].Symbol != "BTC/USDT" {
+		t.Fatal("FetchBorrowInterest:", interest, err)
+	}
+	for _, params := range []map[string]interface{}{{"type": "margin", "symbol": "BTC/USDT"}, {"type": "margin", "instrument_id": "BTC-USDT"}} {
+		balance, err := ex.FetchBalance(params)
+		if err != nil || balance.Total["USDT"] != 500 || balance.Debt["USDT"] != 500 || balance.Interest["USDT"] != 0.5 {
+			t.Fatal("margin balance:", params, balance, err)
+		}
+	}
+	// isolated accounts are not added up across instruments
+	if _, err := ex.FetchBalance(map[string]interface{}{"type": "margin"}); !errors.Is(err, base.ArgumentsRequired) {
+		t.Error("margin balance without an instrument:", err)
+	}
+
+	if _, err := ex.Repay("USDT", 100.5, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	if owed := server.Loan("5:BTC-USDT", "USDT"); owed.Principal != 400 || owed.Interest != 0 {
+		t.Error("after repaying:", owed)
+	}
+}
+
+func TestFakeAccountType(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+	if _, err := ex.Borrow("USDT", 200, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := ex.CreateOrder("BTC/USDT", "limit", "buy", 0.01, 9000, map[string]interface{}{"accountType": "margin"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(server.Engine.OpenOrders("")) != 0 || len(server.AccountEngine("5:BTC-USDT").OpenOrders("")) != 1 {
+		t.Fatal("order not placed on the margin account")
+	}
+
+	ex.ExchangeConfig.AccountType = "isolated-margin"
+	balance, err := ex.FetchBalance(map[string]interface{}{"symbol": "BTC/USDT"})
+	if err != nil || balance.Total["USDT"] != 200 || balance.Used["USDT"] != 90 || balance.Debt["USDT"] != 200 {
+		t.Fatal("margin balance:", balance, err)
+	}
+	if openOrders, err := ex.FetchOpenOrders("BTC/USDT", 0, 0, nil); err != nil || len(openOrders) != 1 || openOrders[0].Id != order.Id {
+		t.Fatal("FetchOpenOrders:", openOrders, err)
+	}
+	server.SetBook("BTC/USDT", &base.OrderBook{Bids: [][2]float64{{8980, 1}}, Asks: [][2]float64{{8990, 1}}})
+	if o, err := ex.FetchOrder(order.Id, "BTC/USDT", nil); err != nil || o.Status != "closed" {
+		t.Fatal("FetchOrder:", o, err)
+	}
+	// the param overrides the config
+	if spot, err := ex.FetchBalance(map[string]interface{}{"accountType": "spot"}); err != nil || spot.Total["USDT"] != 1000 || spot.Total["BTC"] != 0 {
+		t.Fatal("spot balance:", spot, err)
+	}
+	if _, err := ex.FetchBalance(map[string]interface{}{"accountType": "savings"}); !errors.Is(err, base.NotSupported) {
+		t.Error("unknown account:", err)
+	}
+}
+
+func TestFakeFetchPositions(t *testing.T) {
+	server := fake.NewOkex(&fake.Config{
+		ApiKey:    "fake-key",
+		Secret:    "fake-secret",
+		Password:  "fake-password",
+		Books:     map[string]*base.OrderBook{"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}}},
+		Contracts: []string{"BTC-USD-201225", "BTC-USD-SWAP", "ETH-USD-SWAP"},
+	})
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	server.SetPosition(base.Position{Symbol: "BTC-USD-201225", Side: "long", Contracts: 3, EntryPrice: 10000, MarkPrice: 10100,
+		UnrealizedPnl: 0.0003, LiquidationPrice: 9100, MarginMode: "isolated", Leverage: 10})
+	server.SetPosition(base.Position{Symbol: "BTC-USD-201225", Side: "short", Contracts: 1, EntryPrice: 10200, MarkPrice: 10100,
+		UnrealizedPnl: 0.0001, LiquidationPrice: 11200, MarginMode: "isolated", Leverage: 5})
+	server.SetPosition(base.Position{Symbol: "BTC-USD-SWAP", Side: "short", Contracts: 2, EntryPrice: 10050, MarkPrice: 10100,
+		UnrealizedPnl: -0.0001, LiquidationPrice: 14000, MarginMode: "cross", Leverage: 3})
+	server.SetPosition(base.Position{Symbol: "ETH-USD-SWAP", Side: "long", Contracts: 5, EntryPrice: 300, MarkPrice: 310,
+		UnrealizedPnl: 0.05, LiquidationPrice: 200, MarginMode: "cross", Leverage: 3})
+
+	positions, err := ex.FetchPositions(nil, nil)
+	if err != nil || len(positions) != 4 {
+		t.Fatal("FetchPositions:", positions, err)
+	}
+	bySide := map[string]*base.Position{}
+	for _, position := range positions {
+		bySide[position.Symbol+" "+position.Side] = position
+	}
+	long, short := bySide["BTC-USD-201225 long"], bySide["BTC-USD-201225 short"]
+	if long == nil || long.Contracts != 3 || long.EntryPrice != 10000 || long.MarkPrice != 10100 || long.UnrealizedPnl != 0.0003 ||
+		long.LiquidationPrice != 9100 || long.MarginMode != "isolated" || long.Leverage != 10 {
+		t.Error("futures long:", long)
+	}
+	if short == nil || short.Contracts != 1 || short.LiquidationPrice != 11200 || short.Leverage != 5 {
+		t.Error("futures short:", short)
+	}
+	if swap := bySide["BTC-USD-SWAP short"]; swap == nil || swap.Contracts != 2 || swap.EntryPrice != 10050 || swap.UnrealizedPnl != -0.0001 ||
+		swap.LiquidationPrice != 14000 || swap.MarginMode != "cross" || swap.Leverage != 3 || swap.Timestamp == 0 {
+		t.Error("swap short:", swap)
+	}
+
+	positions, err = ex.FetchPositions([]string{"ETH-USD-SWAP"}, map[string]interface{}{"type": "swap"})
+	if err != nil || len(positions) != 1 || positions[0].Side != "long" || positions[0].Contracts != 5 {
+		t.Fatal("ETH swap:", positions, err)
+	}
+	server.SetPosition(base.Position{Symbol: "ETH-USD-SWAP", Side: "long"})
+	if positions, err = ex.FetchPositions([]string{"ETH-USD-SWAP"}, nil); err != nil || len(positions) != 0 {
+		t.Fatal("closed position:", positions, err)
+	}
+}
+
+func TestFakeLeverage(t *testing.T) {
+	server := fake.NewOkex(&fake.Config{
+		ApiKey:    "fake-key",
+		Secret:    "fake-secret",
+		Password:  "fake-password",
+		Books:     map[string]*base.OrderBook{"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}}},
+		Contracts: []string{"BTC-USD-201225", "BTC-USD-210326", "BTC-USD-SWAP"},
+	})
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+
+	leverage, err := ex.FetchLeverage("BTC-USD-201225", nil)
+	if err != nil || leverage.MarginMode != "cross" || leverage.LongLeverage != 10 || leverage.ShortLeverage != 10 {
+		t.Fatal("FetchLeverage:", leverage, err)
+	}
+	// crossed futures leverage covers every instrument of the underlying
+	if err := ex.SetLeverage("BTC-USD-201225", 20, nil); err != nil {
+		t.Fatal("SetLeverage:", err)
+	}
+	if setting := server.Leverage("BTC-USD-210326"); setting.LongLeverage != 20 || setting.ShortLeverage != 20 {
+		t.Error("crossed leverage:", setting)
+	}
+	if err := ex.SetMarginMode("BTC-USD-201225", "isolated", nil); err != nil {
+		t.Fatal("SetMarginMode:", err)
+	}
+	if err := ex.SetLeverage("BTC-USD-201225", 5, map[string]interface{}{"direction": "short"}); err != nil {
+		t.Fatal("SetLeverage short:", err)
+	}
+	leverage, err = ex.FetchLeverage("BTC-USD-201225", nil)
+	if err != nil || leverage.MarginMode != "isolated" || leverage.LongLeverage != 20 || leverage.ShortLeverage != 5 {
+		t.Error("fixed leverage:", leverage, err)
+	}
+
+	if err := ex.SetMarginMode("BTC-USD-SWAP", "isolated", nil); err != nil {
+		t.Fatal("swap SetMarginMode:", err)
+	}
+	if err := ex.SetLeverage("BTC-USD-SWAP", 3, nil); err != nil {
+		t.Fatal("swap SetLeverage:", err)
+	}
+	leverage, err = ex.FetchLeverage("BTC-USD-SWAP", nil)
+	if err != nil || leverage.MarginMode != "isolated" || leverage.LongLeverage != 3 || leverage.ShortLeverage != 3 {
+		t.Error("swap leverage:", leverage, err)
+	}
+
+	if _, err := ex.FetchLeverage("BTC/USDT", nil); !errors.Is(err, base.NotSupported) {
+		t.Error("spot FetchLeverage:", err)
+	}
+	if err := ex.SetMarginMode("BTC-USD-SWAP", "portfolio", nil); !errors.Is(err, base.BadRequest) {
+		t.Error("unknown margin mode:", err)
+	}
+}
+
+func TestFakeFuturesOrderLeverage(t *testing.T) {
+	server := fake.NewOkex(&fake.Config{
+		ApiKey:    "fake-key",
+		Secret:    "fake-secret",
+		Password:  "fake-password",
+		Books:     map[string]*base.OrderBook{"BTC/USDT": {Bids: [][2]float64{{9990, 1}}, Asks: [][2]float64{{10010, 1}}}},
+		Contracts: []string{"BTC-USD-201225"},
+	})
+	defer server.Close()
+	ex := newFakeExchange(t, server, "fake-secret")
+	var sent []map[string]interface{}
+	ex.Use(func(call *base.Call, next base.Next) (interface{}, error) {
+		if call.Api != "futures" || call.Path != "order" {
+			return next(call)
+		}
+		sent = append(sent, call.Params)
+		return map[string]interface{}{"order_id": fmt.Sprint(len(sent)), "client_oid": "", "result": true}, nil
+	})
+
+	// the account's leverage applies unless the order asks for another one
+	if _, err := ex.CreateOrder("BTC-USD-201225", "1", "", 1, 10000, nil); err != nil {
+		t.Fatal("CreateOrder:", err)
+	}
+	if _, err := ex.CreateOrder("BTC-USD-201225", "1", "", 1, 10000, map[string]interface{}{"leverage": "20"}); err != nil {
+		t.Fatal("CreateOrder with leverage:", err)
+	}
+	if len(sent) != 2 || sent[0]["leverage"] != nil || sent[1]["leverage"] != "20" {
+		t.Error("leverage sent:", sent)
+	}
+}
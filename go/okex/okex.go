@@ -1,10 +1,10 @@
 package okex
 
 import (
-	"fmt"
 	. "github.com/georgexdz/ccxt/go/base"
 	"math"
 	"reflect"
+	"strconv"
 	"strings"
 )
 
@@ -14,7 +14,10 @@ type Okex struct {
 
 func New(config *ExchangeConfig) (ex *Okex, err error) {
 	ex = new(Okex)
-	err = ex.Init(config)
+	if err = ex.Init(config); err != nil {
+		ex = nil
+		return
+	}
 	ex.Child = ex
 
 	err = ex.InitDescribe()
@@ -48,7 +51,18 @@ func (self *Okex) Describe() []byte {
         "fetchDeposits": true,
         "fetchWithdrawals": true,
         "fetchTime": true,
-        "fetchTransactions": false,
+        "fetchTransactions": true,
+        "transfer": true,
+        "fetchTransfers": true,
+        "borrow": true,
+        "repay": true,
+        "fetchBorrowRate": true,
+        "fetchBorrowInterest": true,
+        "fetchMaxBorrowable": true,
+        "fetchPositions": true,
+        "fetchLeverage": true,
+        "setLeverage": true,
+        "setMarginMode": true,
         "fetchMyTrades": true,
         "fetchDepositAddress": true,
         "fetchOrderTrades": true,
@@ -634,6 +648,14 @@ func (self *Okex) Describe() []byte {
     "precisionMode": "TICK_SIZE",
     "options": {
         "createMarketBuyOrderRequiresPrice": true,
+        "accountsByType": {
+            "spot": "1",
+            "futures": "3",
+            "margin": "5",
+            "funding": "6",
+            "swap": "9",
+            "option": "12"
+        },
         "fetchMarkets": [
             "spot",
             "futures",
@@ -660,6 +682,16 @@ func (self *Okex) Describe() []byte {
 }`)
 }
 
+func (self *Okex) FetchTime(params map[string]interface{}) (timestamp int64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	response := self.ApiFunc("generalGetTime", params, nil, nil)
+	return self.Parse8601(self.SafeString(response, "iso", "")), nil
+}
+
 func (self *Okex) FetchMarkets(params map[string]interface{}) []interface{} {
 	types := self.SafeValue(self.Options, "fetchMarkets", nil)
 	result := []interface{}{}
@@ -878,49 +910,24 @@ func (self *Okex) ParseAccountBalance(response interface{}) *Account {
 	return self.ParseBalance(result)
 }
 
-/*
-TODO: 杠杆现货暂不支持，因为返回的格式不是Account类型，难以兼容
+// ParseMarginBalance parses the isolated margin account of one instrument,
+// with the debt and interest owed on it
 func (self *Okex) ParseMarginBalance(response interface{}) *Account {
 	result := map[string]interface{}{
 		"info": response,
 	}
-	for i := 0; i < self.Length(response); i++ {
-		balance := self.Member(response, i).(map[string]interface{})
-		marketId := self.SafeString(balance, "instrument_id", "")
-		market := self.SafeValue(self.MarketsById, marketId, nil)
-		var symbol interface{}
-		if self.ToBool(self.TestNil(market)) {
-			baseId, quoteId := self.Unpack2(strings.Split(marketId, "-"))
-			base := self.SafeCurrencyCode(baseId)
-			quote := self.SafeCurrencyCode(quoteId)
-			symbol = base + "/" + quote
-		} else {
-			symbol = self.Member(market, "symbol")
+	for key, marketBalance := range response.(map[string]interface{}) {
+		if !strings.HasPrefix(key, "currency:") {
+			continue
 		}
-		omittedBalance := self.Omit(balance, []interface{}{"instrument_id", "liquidation_price", "product_id", "risk_rate", "margin_ratio", "maint_margin_ratio", "tiers"})
-		keys := reflect.ValueOf(omittedBalance).MapKeys()
-		accounts := map[string]interface{}{}
-		for k := 0; k < self.Length(keys); k++ {
-			key := self.Member(keys, k).(string)
-			marketBalance := self.Member(balance, key)
-			if strings.Contains(key, ":") {
-				parts := strings.Split(key, ":")
-				currencyId := self.Member(parts, 1)
-				code := self.SafeCurrencyCode(currencyId)
-				account := self.Account()
-				self.SetValue(account, "total", self.SafeFloat(marketBalance, "balance", 0))
-				self.SetValue(account, "used", self.SafeFloat(marketBalance, "hold", 0))
-				self.SetValue(account, "free", self.SafeFloat(marketBalance, "available", 0))
-				self.SetValue(accounts, code, account)
-			} else {
-				self.RaiseException("NotSupported", self.Id+" margin balance response format has changed!")
-			}
+		account := map[string]interface{}{}
+		for field, name := range map[string]string{"total": "balance", "used": "hold", "free": "available", "debt": "borrowed", "interest": "lending_fee"} {
+			self.SetValue(account, field, self.SafeFloat(marketBalance, name, 0))
 		}
-		self.SetValue(result, symbol.(string), self.ParseBalance(accounts))
+		self.SetValue(result, self.SafeCurrencyCode(strings.TrimPrefix(key, "currency:")), account)
 	}
-	return result
+	return self.ParseBalance(result)
 }
-*/
 
 func (self *Okex) ParseFuturesBalance(response interface{}) *Account {
 	result := map[string]interface{}{
@@ -963,8 +970,6 @@ func (self *Okex) ParseSwapBalance(response interface{}) *Account {
 func (self *Okex) ParseBalanceByType(typ string, response interface{}) *Account {
 	if self.ToBool(typ == "account" || typ == "spot") {
 		return self.ParseAccountBalance(response)
-	} else if self.ToBool(typ == "margin") {
-		//return self.ParseMarginBalance(response)
 	} else if self.ToBool(typ == "futures") {
 		return self.ParseFuturesBalance(response)
 	} else if self.ToBool(typ == "swap") {
@@ -980,8 +985,7 @@ func (self *Okex) FetchBalance(params map[string]interface{}) (balanceResult *Ac
 			err = self.PanicToError(e)
 		}
 	}()
-	defaultType := self.SafeString2(self.Options, "fetchBalance", "defaultType", "")
-	typ := self.SafeString(params, "type", defaultType)
+	typ, query := self.ApiType("fetchBalance", nil, params)
 	if self.ToBool(self.TestNil(typ)) {
 		self.RaiseException("ArgumentsRequired", self.Id+" fetchBalance requires a type parameter (one of account, spot, margin, futures, swap)")
 	}
@@ -991,11 +995,47 @@ func (self *Okex) FetchBalance(params map[string]interface{}) (balanceResult *Ac
 		suffix = "Wallet"
 	}
 	method := typ + "Get" + suffix
-	query := self.Omit(params, "type")
+	if typ == "margin" {
+		// every instrument has an isolated account of its own, what is free
+		// on one cannot be spent on another, so they are not added up
+		if symbol := self.SafeString(query, "symbol", ""); symbol != "" {
+			self.SetValue(query, "instrument_id", self.MarketId(symbol))
+			query = self.Omit(query, "symbol")
+		}
+		if self.SafeString(query, "instrument_id", "") == "" {
+			self.RaiseException("ArgumentsRequired", self.Id+" fetchBalance requires a symbol or instrument_id param on the margin account")
+		}
+		response := self.ApiFunc("marginGetAccountsInstrumentId", query, nil, nil)
+		return self.ParseMarginBalance(response), nil
+	}
 	response := self.ApiFuncReturnList(method, query, nil, nil)
 	return self.ParseBalanceByType(typ, response), nil
 }
 
+// ApiType returns the api a call goes to, account, spot, margin, futures or
+// swap: the type param, else the type of a futures or swap market, else the
+// unified account type, else the defaultType option of method
+func (self *Okex) ApiType(method string, market *Market, params map[string]interface{}) (string, map[string]interface{}) {
+	typ := self.SafeString2(self.Options, method, "defaultType", "")
+	query := self.Extend(params).(map[string]interface{})
+	switch accountType := self.SelectAccountType(query, ""); accountType {
+	case "":
+	case "spot", "futures", "swap":
+		typ = accountType
+	case "margin", "isolated-margin":
+		typ = "margin"
+	case "funding":
+		typ = "account"
+	default:
+		self.RaiseException("NotSupported", self.Id+" has no "+accountType+" account")
+	}
+	if market != nil && (market.Future || market.Swap) {
+		typ = market.Type
+	}
+	typ = self.SafeString(query, "type", typ)
+	return typ, self.Omit(query, "type")
+}
+
 func (self *Okex) CreateOrder(symbol string, typ string, side string, amount float64, price float64, params map[string]interface{}) (result *Order, err error) {
 	defer func() {
 		if e := recover(); e != nil {
@@ -1004,6 +1044,7 @@ func (self *Okex) CreateOrder(symbol string, typ string, side string, amount flo
 	}()
 	self.LoadMarkets()
 	market := self.Market(symbol)
+	apiType, params := self.ApiType("createOrder", market, params)
 	request := map[string]interface{}{
 		"instrument_id": market.Id,
 	}
@@ -1023,12 +1064,13 @@ func (self *Okex) CreateOrder(symbol string, typ string, side string, amount flo
 			"size":  size,
 			"price": self.PriceToPrecision(symbol, price),
 		}).(map[string]interface{})
-		if market.Future {
-			self.SetValue(request, "leverage", "10")
-		}
 		method = market.Type + "PostOrder"
 	} else {
-		marginTrading := self.SafeString(params, "margin_trading", "1")
+		marginTrading := "1"
+		if apiType == "margin" {
+			marginTrading = "2"
+		}
+		marginTrading = self.SafeString(params, "margin_trading", marginTrading)
 		request = self.Extend(request, map[string]interface{}{
 			"side":           side,
 			"type":           typ,
@@ -1081,8 +1123,7 @@ func (self *Okex) CancelOrder(id string, symbol string, params map[string]interf
 	}
 	self.LoadMarkets()
 	market := self.Market(symbol)
-	defaultType := self.SafeString2(self.Options, "cancelOrder", "defaultType", market.Type)
-	typ := self.SafeString(params, "type", defaultType)
+	typ, params := self.ApiType("cancelOrder", market, params)
 	if self.ToBool(self.TestNil(typ)) {
 		self.RaiseException("ArgumentsRequired", self.Id+" cancelOrder requires a type parameter (one of spot, margin, futures, swap).")
 	}
@@ -1103,7 +1144,7 @@ func (self *Okex) CancelOrder(id string, symbol string, params map[string]interf
 		method += "OrderId"
 		self.SetValue(request, "order_id", id)
 	}
-	query := self.Omit(params, []interface{}{"type", "client_oid", "clientOrderId"})
+	query := self.Omit(params, []interface{}{"client_oid", "clientOrderId"})
 	response = self.ApiFunc(method, self.Extend(request, query), nil, nil)
 	result := self.IfThenElse(self.ToBool(self.InMap("result", response)), response, self.SafeValue(response, market.Id, map[string]interface{}{}))
 	return self.ParseOrder(result, market), nil
@@ -1229,8 +1270,7 @@ func (self *Okex) FetchOrder(id string, symbol string, params map[string]interfa
 	}
 	self.LoadMarkets()
 	market := self.Market(symbol)
-	defaultType := self.SafeString2(self.Options, "fetchOrder", "defaultType", market.Type)
-	typ := self.SafeString(params, "type", defaultType)
+	typ, query := self.ApiType("fetchOrder", market, params)
 	if self.ToBool(self.TestNil(typ)) {
 		self.RaiseException("ArgumentsRequired", self.Id+" fetchOrder requires a type parameter (one of spot, margin, futures, swap).")
 	}
@@ -1242,7 +1282,7 @@ func (self *Okex) FetchOrder(id string, symbol string, params map[string]interfa
 	request := map[string]interface{}{
 		"instrument_id": self.Member(market, "id"),
 	}
-	clientOid := self.SafeString(params, "client_oid", "")
+	clientOid := self.SafeString(query, "client_oid", "")
 	if self.ToBool(!self.TestNil(clientOid)) {
 		method += "ClientOid"
 		self.SetValue(request, "client_oid", clientOid)
@@ -1250,7 +1290,6 @@ func (self *Okex) FetchOrder(id string, symbol string, params map[string]interfa
 		method += "OrderId"
 		self.SetValue(request, "order_id", id)
 	}
-	query := self.Omit(params, "type")
 	response := self.ApiFunc(method, self.Extend(request, query), nil, nil)
 	return self.ToOrder(self.ParseOrder(response, market)), nil
 }
@@ -1261,8 +1300,7 @@ func (self *Okex) FetchOrdersByState(state string, symbol string, since int64, l
 	}
 	self.LoadMarkets()
 	market := self.Market(symbol)
-	defaultType := self.SafeString2(self.Options, "fetchOrder", "defaultType", market.Type)
-	typ := self.SafeString(params, "type", defaultType)
+	typ, query := self.ApiType("fetchOrder", market, params)
 	if self.ToBool(self.TestNil(typ)) {
 		self.RaiseException("ArgumentsRequired", self.Id+" fetchOrder requires a type parameter (one of spot, margin, futures, swap).")
 	}
@@ -1274,7 +1312,6 @@ func (self *Okex) FetchOrdersByState(state string, symbol string, since int64, l
 	if market.Future || market.Swap {
 		method += "InstrumentId"
 	}
-	query := self.Omit(params, "type")
 	response := self.ApiFuncReturnList(method, self.Extend(request, query), nil, nil)
 	if self.ToBool(self.Member(market, "type") == "swap" || self.Member(market, "type") == "futures") {
 		orders = self.SafeValue(response, "order_info", []interface{}{})
@@ -1303,6 +1340,426 @@ func (self *Okex) FetchOpenOrders(symbol string, since int64, limit int64, param
 	return self.ToOrders(self.FetchOrdersByState("6", symbol, since, limit, params)), nil
 }
 
+func (self *Okex) FetchDepositAddress(code string, network string, params map[string]interface{}) (result *DepositAddress, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"currency": self.CurrencyId(code),
+	}
+	response := self.ApiFuncReturnList("accountGetDepositAddress", self.Extend(request, params), nil, nil)
+	// one address per chain, the chain is missing for single chain currencies
+	for _, item := range response {
+		if self.NetworkMatches(self.SafeString(item, "chain", ""), network, code) {
+			address := self.SafeString(item, "address", "")
+			tag := self.SafeString2(item, "tag", "memo", "")
+			if tag == "" {
+				tag = self.SafeString(item, "payment_id", "")
+			}
+			return self.ToDepositAddress(code, address, tag, network, item), nil
+		}
+	}
+	return self.ToDepositAddress(code, "", "", network, response), nil
+}
+
+func (self *Okex) Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (result *Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.CheckAddress(address)
+	self.LoadMarkets()
+	fee := self.SafeString(params, "fee", "")
+	if fee == "" {
+		self.RaiseException("ArgumentsRequired", self.Id+" withdraw() requires a `fee` string parameter, network transaction fee must be ≥ 0. Withdrawals to OKCoin or OKEx are fee-free, please set '0'.")
+	}
+	toAddress := address
+	if tag != "" {
+		toAddress = address + ":" + tag
+	}
+	request := map[string]interface{}{
+		"currency":    self.CurrencyId(code),
+		"to_address":  toAddress,
+		"destination": "4", // 2 okcoin, 3 okex, 4 a digital asset address
+		"amount":      self.NumberToString(amount),
+		"fee":         fee,
+	}
+	if network != "" {
+		self.SetValue(request, "chain", strings.ToUpper(code)+"-"+strings.ToUpper(network))
+	}
+	password := self.SafeString2(params, "password", "trade_pwd", "")
+	if password == "" {
+		password = self.Credentials().Password
+	}
+	self.SetValue(request, "trade_pwd", password)
+	query := self.Omit(params, []interface{}{"fee", "password", "trade_pwd"})
+	response := self.ApiFunc("accountPostWithdrawal", self.Extend(request, query), nil, nil)
+	return &Transaction{
+		Id:       self.SafeString(response, "withdrawal_id", ""),
+		Type:     "withdrawal",
+		Currency: code,
+		Network:  network,
+		Address:  address,
+		Tag:      tag,
+		Amount:   amount,
+		Status:   "pending",
+		Info:     response,
+	}, nil
+}
+
+func (self *Okex) FetchDeposits(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("deposit", code, since, limit, params), nil
+}
+
+func (self *Okex) FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) (result []*Transaction, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.FetchTransactionsByType("withdrawal", code, since, limit, params), nil
+}
+
+// FetchTransactionsByType lists deposits or withdrawals. Okex v3 only
+// returns the latest 100 of them, with no time filter or cursor, so since
+// and limit are applied here and older history cannot be reached: a since
+// before the oldest of the 100 gets those 100 and nothing earlier.
+func (self *Okex) FetchTransactionsByType(typ string, code string, since int64, limit int64, params map[string]interface{}) []*Transaction {
+	self.LoadMarkets()
+	method := "accountGetDepositHistory"
+	if typ == "withdrawal" {
+		method = "accountGetWithdrawalHistory"
+	}
+	request := map[string]interface{}{}
+	if code != "" {
+		method += "Currency"
+		self.SetValue(request, "currency", self.CurrencyId(code))
+	}
+	response := self.ApiFuncReturnList(method, self.Extend(request, params), nil, nil)
+	result := []*Transaction{}
+	for _, transaction := range response {
+		result = append(result, self.ParseTransaction(transaction, typ))
+	}
+	return self.FilterTransactions(result, code, since, limit)
+}
+
+func (self *Okex) ParseTransactionStatus(typ string, status string) string {
+	statuses := map[string]interface{}{
+		"deposit": map[string]interface{}{
+			"0": "pending", // waiting for confirmations
+			"1": "ok",      // credited, not withdrawable yet
+			"2": "ok",
+		},
+		"withdrawal": map[string]interface{}{
+			"-3": "pending", // canceling
+			"-2": "canceled",
+			"-1": "failed",
+			"0":  "pending",
+			"1":  "pending", // sending
+			"2":  "ok",
+			"3":  "pending", // awaiting email confirmation
+			"4":  "pending", // awaiting manual verification
+			"5":  "pending", // awaiting identity verification
+		},
+	}
+	return self.SafeString(self.SafeValue(statuses, typ, nil), status, status)
+}
+
+func (self *Okex) ParseTransaction(transaction interface{}, typ string) *Transaction {
+	currencyId := self.SafeString(transaction, "currency", "")
+	code := self.SafeCurrencyCode(currencyId)
+	id := self.SafeString(transaction, "deposit_id", "")
+	if typ == "withdrawal" {
+		id = self.SafeString(transaction, "withdrawal_id", "")
+	}
+	tag := self.SafeString2(transaction, "tag", "memo", "")
+	if tag == "" {
+		tag = self.SafeString(transaction, "payment_id", "")
+	}
+	// the fee is suffixed with the currency, e.g. "0.01000000eth"
+	feeString := strings.TrimSuffix(strings.ToLower(self.SafeString(transaction, "fee", "")), strings.ToLower(currencyId))
+	fee, _ := strconv.ParseFloat(feeString, 64)
+	network := self.SafeString(transaction, "chain", "")
+	if strings.HasPrefix(strings.ToUpper(network), strings.ToUpper(code)+"-") {
+		network = network[len(code)+1:]
+	}
+	timestamp := self.Parse8601(self.SafeString(transaction, "timestamp", ""))
+	return &Transaction{
+		Id:        id,
+		Txid:      self.SafeString(transaction, "txid", ""),
+		Type:      typ,
+		Currency:  code,
+		Network:   network,
+		Address:   self.SafeString(transaction, "to", ""),
+		Tag:       tag,
+		Amount:    self.SafeFloat(transaction, "amount", 0),
+		Fee:       fee,
+		Status:    self.ParseTransactionStatus(typ, self.SafeString(transaction, "status", "")),
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Info:      transaction,
+	}
+}
+
+// Transfer moves funds between the okex accounts, margin transfers name the
+// instrument in the instrument_id or to_instrument_id param
+func (self *Okex) Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (result *TransferEntry, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"currency": self.CurrencyId(code),
+		"amount":   self.NumberToString(amount),
+		"from":     self.AccountId(fromAccount),
+		"to":       self.AccountId(toAccount),
+	}
+	response := self.ApiFunc("accountPostTransfer", self.Extend(request, params), nil, nil)
+	timestamp := self.Milliseconds()
+	return &TransferEntry{
+		Id:          self.SafeString(response, "transfer_id", ""),
+		Currency:    code,
+		Amount:      amount,
+		FromAccount: fromAccount,
+		ToAccount:   toAccount,
+		Status:      "ok",
+		Timestamp:   timestamp,
+		Datetime:    self.Iso8601(timestamp),
+		Info:        response,
+	}, nil
+}
+
+// FetchTransfers reads the funding account ledger, so it lists the
+// transfers into and out of the funding account and not those between two
+// trading accounts, e.g. spot to margin. The ledger lists the newest first
+// and pages back by ledger id, 100 entries at a time, so with since the
+// pages are followed back until one reaches past it.
+func (self *Okex) FetchTransfers(code string, since int64, limit int64, params map[string]interface{}) (result []*TransferEntry, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"limit": 100,
+	}
+	if code != "" {
+		self.SetValue(request, "currency", self.CurrencyId(code))
+	}
+	result = []*TransferEntry{}
+	for {
+		response := self.ApiFuncReturnList("accountGetLedger", self.Extend(request, params), nil, nil)
+		for _, entry := range response {
+			if transfer := self.ParseTransfer(entry); transfer != nil {
+				result = append(result, transfer)
+			}
+		}
+		if since <= 0 || len(response) < 100 {
+			break
+		}
+		last := response[len(response)-1]
+		if self.Parse8601(self.SafeString(last, "timestamp", "")) < since {
+			break
+		}
+		self.SetValue(request, "after", self.SafeString(last, "ledger_id", ""))
+	}
+	return self.FilterTransfers(result, since, limit), nil
+}
+
+// ParseTransfer reads a funding account ledger entry, nil for those that
+// are not transfers. The typename names the other account from the funding
+// account's side: "Into spot account" moved funds from funding to spot.
+func (self *Okex) ParseTransfer(entry interface{}) *TransferEntry {
+	accounts := map[string]interface{}{
+		"spot account":           "spot",
+		"futures account":        "futures",
+		"margin account":         "margin",
+		"perpetual swap account": "swap",
+		"option account":         "option",
+	}
+	typename := self.SafeString(entry, "typename", "")
+	from, to := "funding", ""
+	if account := strings.TrimPrefix(typename, "Into "); account != typename {
+		to = self.SafeString(accounts, account, "")
+	} else if account := strings.TrimPrefix(typename, "Out of "); account != typename {
+		from, to = self.SafeString(accounts, account, ""), "funding"
+	}
+	if from == "" || to == "" {
+		return nil
+	}
+	timestamp := self.Parse8601(self.SafeString(entry, "timestamp", ""))
+	return &TransferEntry{
+		Id:          self.SafeString(entry, "ledger_id", ""),
+		Currency:    self.SafeCurrencyCode(self.SafeString(entry, "currency", "")),
+		Amount:      math.Abs(self.SafeFloat(entry, "amount", 0)),
+		FromAccount: from,
+		ToAccount:   to,
+		Status:      "ok",
+		Timestamp:   timestamp,
+		Datetime:    self.Iso8601(timestamp),
+		Info:        entry,
+	}
+}
+
+// Borrow takes a loan on the isolated margin account of symbol, okex has
+// no cross margin
+func (self *Okex) Borrow(code string, amount float64, symbol string, params map[string]interface{}) (result *MarginLoan, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.MarginLoan("marginPostAccountsBorrow", "borrow_id", code, amount, symbol, params), nil
+}
+
+func (self *Okex) Repay(code string, amount float64, symbol string, params map[string]interface{}) (result *MarginLoan, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	return self.MarginLoan("marginPostAccountsRepayment", "repayment_id", code, amount, symbol, params), nil
+}
+
+func (self *Okex) MarginLoan(method string, idKey string, code string, amount float64, symbol string, params map[string]interface{}) *MarginLoan {
+	if symbol == "" {
+		self.RaiseException("ArgumentsRequired", self.Id+" margin loans require a symbol argument")
+	}
+	self.LoadMarkets()
+	request := map[string]interface{}{
+		"instrument_id": self.MarketId(symbol),
+		"currency":      self.CurrencyId(code),
+		"amount":        self.NumberToString(amount),
+	}
+	response := self.ApiFunc(method, self.Extend(request, params), nil, nil)
+	timestamp := self.Milliseconds()
+	return &MarginLoan{
+		Id:        self.SafeString(response, idKey, ""),
+		Currency:  code,
+		Amount:    amount,
+		Symbol:    symbol,
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Info:      response,
+	}
+}
+
+// MarginAvailability returns the "currency:" entry of code on the isolated
+// margin account of symbol, or on the first account that lends code if
+// symbol is empty
+func (self *Okex) MarginAvailability(code string, symbol string, params map[string]interface{}) interface{} {
+	self.LoadMarkets()
+	method := "marginGetAccountsAvailability"
+	request := map[string]interface{}{}
+	if symbol != "" {
+		method = "marginGetAccountsInstrumentIdAvailability"
+		self.SetValue(request, "instrument_id", self.MarketId(symbol))
+	}
+	response := self.ApiFuncReturnList(method, self.Extend(request, params), nil, nil)
+	for i := 0; i < self.Length(response); i++ {
+		if entry := self.SafeValue(self.Member(response, i), "currency:"+self.CurrencyId(code), nil); entry != nil {
+			return entry
+		}
+	}
+	self.RaiseException("ExchangeError", self.Id+" has no margin loans of "+code)
+	return nil
+}
+
+// FetchBorrowRate returns the daily rate, a symbol or instrument_id param
+// picks the isolated margin account, else it is the rate of the first
+// instrument that lends code
+func (self *Okex) FetchBorrowRate(code string, params map[string]interface{}) (result *BorrowRate, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	symbol := self.SafeString(params, "symbol", "")
+	if marketId := self.SafeString(params, "instrument_id", ""); marketId != "" {
+		market, ok := self.MarketsById[marketId]
+		if !ok {
+			self.RaiseException("BadSymbol", self.Id+" has no instrument "+marketId)
+		}
+		symbol = market.Symbol
+	}
+	query := self.Omit(self.Extend(params).(map[string]interface{}), []string{"symbol", "instrument_id"})
+	entry := self.MarginAvailability(code, symbol, query)
+	timestamp := self.Milliseconds()
+	return &BorrowRate{
+		Currency:  code,
+		Rate:      self.SafeFloat(entry, "rate", 0),
+		Period:    86400000,
+		Timestamp: timestamp,
+		Datetime:  self.Iso8601(timestamp),
+		Info:      entry,
+	}, nil
+}
+
+func (self *Okex) FetchMaxBorrowable(code string, symbol string, params map[string]interface{}) (amount float64, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	if symbol == "" {
+		self.RaiseException("ArgumentsRequired", self.Id+" fetchMaxBorrowable requires a symbol argument")
+	}
+	return self.SafeFloat(self.MarginAvailability(code, symbol, params), "available", 0), nil
+}
+
+// FetchBorrowInterest returns the interest charged so far on each loan
+func (self *Okex) FetchBorrowInterest(code string, symbol string, since int64, limit int64, params map[string]interface{}) (result []*BorrowInterest, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	method := "marginGetAccountsBorrowed"
+	request := map[string]interface{}{}
+	if symbol != "" {
+		method = "marginGetAccountsInstrumentIdBorrowed"
+		self.SetValue(request, "instrument_id", self.MarketId(symbol))
+	}
+	response := self.ApiFuncReturnList(method, self.Extend(request, params), nil, nil)
+	result = []*BorrowInterest{}
+	for i := 0; i < self.Length(response); i++ {
+		loan := self.Member(response, i)
+		currency := self.SafeCurrencyCode(self.SafeString(loan, "currency", ""))
+		if code != "" && currency != code {
+			continue
+		}
+		timestamp := self.Parse8601(self.SafeString(loan, "last_interest_time", ""))
+		interest := &BorrowInterest{
+			Currency:  currency,
+			Interest:  self.SafeFloat(loan, "interest", 0),
+			Rate:      self.SafeFloat(loan, "rate", 0),
+			Amount:    self.SafeFloat(loan, "amount", 0),
+			Timestamp: timestamp,
+			Datetime:  self.Iso8601(timestamp),
+			Info:      loan,
+		}
+		if market, ok := self.MarketsById[self.SafeString(loan, "instrument_id", "")]; ok {
+			interest.Symbol = market.Symbol
+		}
+		result = append(result, interest)
+	}
+	return self.FilterBorrowInterest(result, since, limit), nil
+}
+
 func (self *Okex) GetPathAuthenticationType(path string) string {
 	// https://github.com/ccxt/ccxt/issues/6651
 	// a special case to handle the optionGetUnderlying interefering with
@@ -1330,10 +1787,11 @@ func (self *Okex) Sign(path string, api string, method string, params map[string
 		}
 	} else if self.ToBool(typ == "private") {
 		self.CheckRequiredCredentials()
-		timestamp := self.Iso8601Okex(self.Milliseconds())
+		credentials := self.Credentials()
+		timestamp := self.Iso8601Okex(self.ServerMilliseconds())
 		headers = map[string]interface{}{
-			"OK-ACCESS-KEY":        self.ApiKey,
-			"OK-ACCESS-PASSPHRASE": self.Password,
+			"OK-ACCESS-KEY":        credentials.ApiKey,
+			"OK-ACCESS-PASSPHRASE": credentials.Password,
 			"OK-ACCESS-TIMESTAMP":  timestamp,
 		}
 		auth := timestamp + method + request
@@ -1350,7 +1808,7 @@ func (self *Okex) Sign(path string, api string, method string, params map[string
 			}
 			self.SetValue(headers, "Content-Type", "application/json")
 		}
-		signature := self.Hmac(self.Encode(auth), self.Encode(self.Secret), "sha256", "base64")
+		signature := self.Hmac(self.Encode(auth), self.Encode(credentials.Secret), "sha256", "base64")
 		self.SetValue(headers, "OK-ACCESS-SIGN", self.Decode(signature))
 	}
 	return map[string]interface{}{
@@ -1362,7 +1820,6 @@ func (self *Okex) Sign(path string, api string, method string, params map[string
 }
 
 func (self *Okex) HandleErrors(httpCode int64, reason string, url string, method string, headers interface{}, body string, response interface{}, requestHeaders interface{}, requestBody interface{}) {
-	fmt.Println(response)
 	feedback := self.Id + " " + body
 	if self.ToBool(httpCode == 503) {
 		self.RaiseException("ExchangeNotAvailable", feedback)
@@ -1373,9 +1830,7 @@ func (self *Okex) HandleErrors(httpCode int64, reason string, url string, method
 	message := self.SafeString(response, "message", "")
 	errorCode := self.SafeString2(response, "code", "error_code", "")
 	if self.ToBool(!self.TestNil(message)) {
-		self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), message, feedback)
-		self.ThrowBroadlyMatchedException(self.Member(self.Exceptions, "broad"), message, feedback)
-		self.ThrowExactlyMatchedException(self.Member(self.Exceptions, "exact"), errorCode, feedback)
+		self.ThrowMatchedException(feedback, message, errorCode)
 		nonEmptyMessage := message != ""
 		nonZeroErrorCode := !self.TestNil(errorCode) && errorCode != "0"
 		if self.ToBool(nonZeroErrorCode || nonEmptyMessage) {
@@ -1383,3 +1838,291 @@ func (self *Okex) HandleErrors(httpCode int64, reason string, url string, method
 		}
 	}
 }
+
+// FetchPositions returns the futures and swap positions, or those of the
+// type param only. The position rows carry no mark price, it is read from
+// each instrument's mark_price endpoint, once per instrument.
+func (self *Okex) FetchPositions(symbols []string, params map[string]interface{}) (result []*Position, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	types := []string{"futures", "swap"}
+	if typ := self.SafeString(params, "type", ""); typ != "" {
+		types = []string{typ}
+	}
+	query := self.Omit(params, "type")
+	result = []*Position{}
+	for _, typ := range types {
+		if typ == "futures" {
+			response := self.ApiFunc("futuresGetPosition", query, nil, nil)
+			instruments := self.SafeValue(response, "holding", []interface{}{})
+			for i := 0; i < self.Length(instruments); i++ {
+				// each instrument is a list of one row
+				rows := self.Member(instruments, i)
+				for j := 0; j < self.Length(rows); j++ {
+					result = append(result, self.ParseFuturesPositions(self.Member(rows, j))...)
+				}
+			}
+		} else if typ == "swap" {
+			response := self.ApiFuncReturnList("swapGetPosition", query, nil, nil)
+			for i := 0; i < self.Length(response); i++ {
+				instrument := self.Member(response, i)
+				marginMode := self.SafeString(instrument, "margin_mode", "")
+				rows := self.SafeValue(instrument, "holding", []interface{}{})
+				for j := 0; j < self.Length(rows); j++ {
+					result = append(result, self.ParseSwapPosition(self.Member(rows, j), marginMode))
+				}
+			}
+		} else {
+			self.RaiseException("NotSupported", self.Id+" fetchPositions does not support the "+typ+" type (the type must be futures or swap)")
+		}
+	}
+	result = self.FilterPositions(result, symbols)
+	// position rows only carry the last traded price, liquidation follows
+	// the mark price of the instrument
+	markPrices := map[string]float64{}
+	for _, position := range result {
+		market, ok := self.MarketsById[self.SafeString(position.Info, "instrument_id", "")]
+		if !ok {
+			continue
+		}
+		if _, ok := markPrices[market.Id]; !ok {
+			response := self.ApiFunc(market.Type+"GetInstrumentsInstrumentIdMarkPrice", map[string]interface{}{
+				"instrument_id": market.Id,
+			}, nil, nil)
+			markPrices[market.Id] = self.SafeFloat(response, "mark_price", 0)
+		}
+		position.MarkPrice = markPrices[market.Id]
+	}
+	return result, nil
+}
+
+// ParseMarginMode maps okex's crossed and fixed to cross and isolated
+func (self *Okex) ParseMarginMode(marginMode string) string {
+	modes := map[string]interface{}{
+		"crossed": "cross",
+		"fixed":   "isolated",
+	}
+	return self.SafeString(modes, marginMode, marginMode)
+}
+
+// ContractSymbol is the symbol of a futures or swap instrument id
+func (self *Okex) ContractSymbol(marketId string) string {
+	if market, ok := self.MarketsById[marketId]; ok {
+		return market.Symbol
+	}
+	return marketId
+}
+
+// ParseFuturesPositions parses the long and the short position of a futures
+// row, in fixed margin mode each side has its own leverage and
+// liquidation price. The row has no mark price, FetchPositions adds it.
+func (self *Okex) ParseFuturesPositions(row interface{}) []*Position {
+	result := []*Position{}
+	marginMode := self.SafeString(row, "margin_mode", "crossed")
+	timestamp := self.Parse8601(self.SafeString(row, "updated_at", ""))
+	for _, side := range []string{"long", "short"} {
+		liquidationPrice := self.SafeFloat(row, "liquidation_price", 0)
+		leverage := self.SafeFloat(row, "leverage", 0)
+		if marginMode == "fixed" {
+			liquidationPrice = self.SafeFloat(row, side+"_liqui_price", 0)
+			leverage = self.SafeFloat(row, side+"_leverage", 0)
+		}
+		result = append(result, &Position{
+			Symbol:           self.ContractSymbol(self.SafeString(row, "instrument_id", "")),
+			Side:             side,
+			Contracts:        self.SafeFloat(row, side+"_qty", 0),
+			EntryPrice:       self.SafeFloat(row, side+"_avg_cost", 0),
+			UnrealizedPnl:    self.SafeFloat(row, side+"_unrealised_pnl", 0),
+			LiquidationPrice: liquidationPrice,
+			MarginMode:       self.ParseMarginMode(marginMode),
+			Leverage:         leverage,
+			Timestamp:        timestamp,
+			Datetime:         self.Iso8601(timestamp),
+			Info:             row,
+		})
+	}
+	return result
+}
+
+// ParseSwapPosition parses one side of a swap instrument, without its mark
+// price, see ParseFuturesPositions
+func (self *Okex) ParseSwapPosition(row interface{}, marginMode string) *Position {
+	timestamp := self.Parse8601(self.SafeString(row, "timestamp", ""))
+	return &Position{
+		Symbol:           self.ContractSymbol(self.SafeString(row, "instrument_id", "")),
+		Side:             self.SafeString(row, "side", ""),
+		Contracts:        self.SafeFloat(row, "position", 0),
+		EntryPrice:       self.SafeFloat(row, "avg_cost", 0),
+		UnrealizedPnl:    self.SafeFloat(row, "unrealized_pnl", 0),
+		LiquidationPrice: self.SafeFloat(row, "liquidation_price", 0),
+		MarginMode:       self.ParseMarginMode(marginMode),
+		Leverage:         self.SafeFloat(row, "leverage", 0),
+		Timestamp:        timestamp,
+		Datetime:         self.Iso8601(timestamp),
+		Info:             row,
+	}
+}
+
+// ContractMarket is the futures or swap market of symbol
+func (self *Okex) ContractMarket(method string, symbol string) *Market {
+	market := self.Market(symbol)
+	if !market.Future && !market.Swap {
+		self.RaiseException("NotSupported", self.Id+" "+method+" only supports futures and swap markets")
+	}
+	return market
+}
+
+// ContractUnderlying is the underlying futures leverage and margin mode are
+// set on, such as BTC-USD
+func (self *Okex) ContractUnderlying(market *Market) string {
+	return self.SafeString(market.Info, "underlying", market.BaseId+"-"+market.QuoteId)
+}
+
+// OkexMarginMode is how okex names a unified margin mode
+func (self *Okex) OkexMarginMode(marginMode string) string {
+	modes := map[string]interface{}{
+		"cross":    "crossed",
+		"isolated": "fixed",
+	}
+	mode := self.SafeString(modes, marginMode, "")
+	if mode == "" {
+		self.RaiseException("BadRequest", self.Id+" margin mode must be cross or isolated, not "+marginMode)
+	}
+	return mode
+}
+
+func (self *Okex) FetchLeverage(symbol string, params map[string]interface{}) (result *Leverage, err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	market := self.ContractMarket("fetchLeverage", symbol)
+	if market.Swap {
+		response := self.ApiFunc("swapGetAccountsInstrumentIdSettings", self.Extend(map[string]interface{}{
+			"instrument_id": market.Id,
+		}, params), nil, nil)
+		return &Leverage{
+			Symbol:        market.Symbol,
+			MarginMode:    self.ParseMarginMode(self.SafeString(response, "margin_mode", "")),
+			LongLeverage:  self.SafeFloat(response, "long_leverage", 0),
+			ShortLeverage: self.SafeFloat(response, "short_leverage", 0),
+			Info:          response,
+		}, nil
+	}
+	response := self.ApiFunc("futuresGetAccountsUnderlyingLeverage", self.Extend(map[string]interface{}{
+		"underlying": self.ContractUnderlying(market),
+	}, params), nil, nil)
+	// crossed reports one leverage for the underlying, fixed one per
+	// instrument and side
+	result = &Leverage{
+		Symbol:        market.Symbol,
+		MarginMode:    self.ParseMarginMode(self.SafeString(response, "margin_mode", "")),
+		LongLeverage:  self.SafeFloat(response, "leverage", 0),
+		ShortLeverage: self.SafeFloat(response, "leverage", 0),
+		Info:          response,
+	}
+	if result.MarginMode == "isolated" {
+		instrument := self.SafeValue(response, market.Id, nil)
+		result.LongLeverage = self.SafeFloat(instrument, "long_leverage", 0)
+		result.ShortLeverage = self.SafeFloat(instrument, "short_leverage", 0)
+	}
+	return result, nil
+}
+
+// SetLeverage keeps the margin mode of symbol, in isolated mode it sets both
+// sides unless a direction (futures) or side (swap) param picks one
+func (self *Okex) SetLeverage(symbol string, leverage float64, params map[string]interface{}) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	market := self.ContractMarket("setLeverage", symbol)
+	current, err := self.FetchLeverage(symbol, nil)
+	if err != nil {
+		return err
+	}
+	if market.Swap {
+		sides := []string{"3"}
+		if current.MarginMode == "isolated" {
+			sides = []string{"1", "2"}
+		}
+		if side := self.SafeString(params, "side", ""); side != "" {
+			sides = []string{side}
+		}
+		for _, side := range sides {
+			self.ApiFunc("swapPostAccountsInstrumentIdLeverage", self.Extend(map[string]interface{}{
+				"instrument_id": market.Id,
+				"leverage":      self.NumberToString(leverage),
+				"side":          side,
+			}, params), nil, nil)
+		}
+		return nil
+	}
+	request := map[string]interface{}{
+		"underlying": self.ContractUnderlying(market),
+		"leverage":   self.NumberToString(leverage),
+	}
+	if current.MarginMode != "isolated" {
+		self.ApiFunc("futuresPostAccountsUnderlyingLeverage", self.Extend(request, params), nil, nil)
+		return nil
+	}
+	directions := []string{"long", "short"}
+	if direction := self.SafeString(params, "direction", ""); direction != "" {
+		directions = []string{direction}
+	}
+	for _, direction := range directions {
+		self.ApiFunc("futuresPostAccountsUnderlyingLeverage", self.Extend(request, map[string]interface{}{
+			"instrument_id": market.Id,
+			"direction":     direction,
+		}, params), nil, nil)
+	}
+	return nil
+}
+
+// SetMarginMode switches the underlying of a futures symbol, or a swap
+// instrument, keeping its leverage
+func (self *Okex) SetMarginMode(symbol string, marginMode string, params map[string]interface{}) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = self.PanicToError(e)
+		}
+	}()
+	self.LoadMarkets()
+	market := self.ContractMarket("setMarginMode", symbol)
+	mode := self.OkexMarginMode(marginMode)
+	if market.Future {
+		self.ApiFunc("futuresPostAccountsMarginMode", self.Extend(map[string]interface{}{
+			"underlying":  self.ContractUnderlying(market),
+			"margin_mode": mode,
+		}, params), nil, nil)
+		return nil
+	}
+	// a swap changes mode with the side its leverage is set on, 3 for both
+	// sides in crossed mode, 1 and 2 for long and short in fixed mode
+	current, err := self.FetchLeverage(symbol, nil)
+	if err != nil {
+		return err
+	}
+	sides := map[string]float64{"3": current.LongLeverage}
+	if mode == "fixed" {
+		sides = map[string]float64{"1": current.LongLeverage, "2": current.ShortLeverage}
+	}
+	for _, side := range []string{"1", "2", "3"} {
+		if leverage, ok := sides[side]; ok {
+			self.ApiFunc("swapPostAccountsInstrumentIdLeverage", self.Extend(map[string]interface{}{
+				"instrument_id": market.Id,
+				"leverage":      self.NumberToString(leverage),
+				"side":          side,
+			}, params), nil, nil)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,461 @@
+package paper
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// Fill is one simulated execution
+type Fill struct {
+	OrderId   string  `json:"orderId"`
+	Symbol    string  `json:"symbol"`
+	Side      string  `json:"side"`
+	Price     float64 `json:"price"`
+	Amount    float64 `json:"amount"`
+	Fee       float64 `json:"fee"` // always in quote currency
+	Maker     bool    `json:"maker"`
+	Timestamp int64   `json:"timestamp"`
+}
+
+// Engine holds simulated balances and orders and fills them against order
+// books supplied by the caller. It has no notion of where books come from,
+// so the same engine backs both the live paper exchange and the replay one.
+//
+// Matching model: a marketable order (or the marketable part of a limit
+// order) walks the book it is given and pays the taker fee. Resting limit
+// orders fill at their own price, paying the maker fee, once a later book
+// crosses them, oldest first. Our own fills use up the levels of a book, so
+// matching the same book again, or an identical one, fills nothing twice;
+// only a new book brings new liquidity. There is no queue position.
+type Engine struct {
+	sync.Mutex
+
+	Maker float64
+	Taker float64
+	// Now returns the current time in milliseconds, defaults to the wall clock
+	Now func() int64
+	// IdBase is added to the counter order ids come from, for engines that
+	// share an id space
+	IdBase int64
+
+	balances map[string]*Balance
+	orders   map[string]*Order
+	reserved map[string]float64 // funds still locked by each open order
+	fills    []Fill
+	nextId   int64
+	books    map[string]*bookUse // latest book of each symbol and what fills took of it
+}
+
+// bookUse is a book and the amount of each of its levels fills have used
+type bookUse struct {
+	book *OrderBook
+	used map[levelKey]float64
+}
+
+// levelKey is a price level of the side an order of side executes against
+type levelKey struct {
+	side  string
+	price float64
+}
+
+// NewEngine creates an engine with the given free balances
+func NewEngine(balances map[string]float64, maker float64, taker float64) *Engine {
+	e := &Engine{
+		Maker:    maker,
+		Taker:    taker,
+		balances: map[string]*Balance{},
+		orders:   map[string]*Order{},
+		reserved: map[string]float64{},
+		books:    map[string]*bookUse{},
+		Now: func() int64 {
+			return time.Now().UnixNano() / int64(time.Millisecond)
+		},
+	}
+	for code, amount := range balances {
+		e.balances[code] = &Balance{Free: amount, Total: amount}
+	}
+	return e
+}
+
+// SplitSymbol returns base and quote of a unified "BASE/QUOTE" symbol
+func SplitSymbol(symbol string) (base string, quote string, err error) {
+	parts := strings.Split(symbol, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", TypedError("BadSymbol", "paper trading needs a BASE/QUOTE symbol, got "+symbol)
+	}
+	return parts[0], parts[1], nil
+}
+
+func datetime(ms int64) string {
+	return time.Unix(0, ms*int64(time.Millisecond)).UTC().Format("2006-01-02T15:04:05.000Z")
+}
+
+func (e *Engine) balance(code string) *Balance {
+	b := e.balances[code]
+	if b == nil {
+		b = &Balance{}
+		e.balances[code] = b
+	}
+	return b
+}
+
+func (e *Engine) lock(code string, amount float64) error {
+	b := e.balance(code)
+	if b.Free < amount {
+		return TypedError("InsufficientFunds", fmt.Sprintf("paper %s free %v < required %v", code, b.Free, amount))
+	}
+	b.Free -= amount
+	b.Used += amount
+	return nil
+}
+
+func (e *Engine) unlock(code string, amount float64) {
+	b := e.balance(code)
+	b.Used -= amount
+	b.Free += amount
+}
+
+// CreateOrder places a simulated order and fills whatever part of it is
+// marketable against book right away.
+func (e *Engine) CreateOrder(symbol, typ, side string, amount, price float64, book *OrderBook) (*Order, error) {
+	e.Lock()
+	defer e.Unlock()
+
+	baseCode, quoteCode, err := SplitSymbol(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if side != "buy" && side != "sell" {
+		return nil, TypedError("InvalidOrder", "paper order side must be buy or sell, got "+side)
+	}
+	if amount <= 0 {
+		return nil, TypedError("InvalidOrder", fmt.Sprintf("paper order amount must be positive, got %v", amount))
+	}
+	if typ != "limit" && typ != "market" {
+		return nil, TypedError("InvalidOrder", "paper order type must be limit or market, got "+typ)
+	}
+	if typ == "limit" && price <= 0 {
+		return nil, TypedError("InvalidOrder", "paper limit order requires a price")
+	}
+
+	if book == nil {
+		book = &OrderBook{}
+	}
+
+	var lockCode string
+	var lockAmount float64
+	if side == "sell" {
+		lockCode, lockAmount = baseCode, amount
+	} else if typ == "limit" {
+		lockCode, lockAmount = quoteCode, amount*price*(1+math.Max(e.Maker, e.Taker))
+	} else {
+		avg, filled := book.VwapForAmount(side, amount)
+		lockCode, lockAmount = quoteCode, avg*filled*(1+e.Taker)
+	}
+	if err := e.lock(lockCode, lockAmount); err != nil {
+		return nil, err
+	}
+
+	e.nextId++
+	now := e.Now()
+	order := &Order{
+		Id:        strconv.FormatInt(e.IdBase+e.nextId, 10),
+		Timestamp: now,
+		Datetime:  datetime(now),
+		Symbol:    symbol,
+		Status:    "open",
+		Type:      typ,
+		Side:      side,
+		Price:     price,
+		Amount:    amount,
+		Remaining: amount,
+	}
+	e.orders[order.Id] = order
+	e.reserved[order.Id] = lockAmount
+
+	e.take(order, book)
+	if typ == "market" && order.Status == "open" {
+		// whatever the book could not absorb is dropped, as on a real exchange
+		e.close(order, "closed")
+	}
+	copied := *order
+	return &copied, nil
+}
+
+// take fills order against the opposite side of book as a taker
+func (e *Engine) take(order *Order, book *OrderBook) {
+	if book == nil {
+		return
+	}
+	use := e.use(order.Symbol, book)
+	for _, level := range opposite(book, order.Side) {
+		if order.Remaining <= 0 {
+			break
+		}
+		if order.Type == "limit" && (order.Side == "buy" && level[0] > order.Price || order.Side == "sell" && level[0] < order.Price) {
+			break
+		}
+		key := levelKey{order.Side, level[0]}
+		if free := level[1] - use.used[key]; free > 0 {
+			amount := math.Min(free, order.Remaining)
+			use.used[key] += amount
+			e.fill(order, level[0], amount, false)
+		}
+	}
+}
+
+// use returns what fills took of book so far. A book with the timestamp
+// and nonce of the previous one of symbol, or the same levels, is the same
+// book; any other starts afresh.
+func (e *Engine) use(symbol string, book *OrderBook) *bookUse {
+	last := e.books[symbol]
+	if last != nil && (sameLevels(last.book, book) || (book.Timestamp != 0 || book.Nonce != 0) && last.book.Timestamp == book.Timestamp && last.book.Nonce == book.Nonce) {
+		return last
+	}
+	copied := *book
+	copied.Bids = append([][2]float64(nil), book.Bids...)
+	copied.Asks = append([][2]float64(nil), book.Asks...)
+	last = &bookUse{book: &copied, used: map[levelKey]float64{}}
+	e.books[symbol] = last
+	return last
+}
+
+func sameLevels(a, b *OrderBook) bool {
+	if len(a.Bids) != len(b.Bids) || len(a.Asks) != len(b.Asks) {
+		return false
+	}
+	for i := range a.Bids {
+		if a.Bids[i] != b.Bids[i] {
+			return false
+		}
+	}
+	for i := range a.Asks {
+		if a.Asks[i] != b.Asks[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// fill books one execution of amount at price against order
+func (e *Engine) fill(order *Order, price, amount float64, maker bool) {
+	baseCode, quoteCode, _ := SplitSymbol(order.Symbol)
+	rate := e.Taker
+	if maker {
+		rate = e.Maker
+	}
+	cost := price * amount
+	fee := cost * rate
+	baseBalance, quoteBalance := e.balance(baseCode), e.balance(quoteCode)
+	if order.Side == "buy" {
+		release := math.Min(e.reserved[order.Id], cost+fee)
+		e.reserved[order.Id] -= release
+		quoteBalance.Used -= release
+		quoteBalance.Total -= cost + fee
+		quoteBalance.Free -= cost + fee - release
+		baseBalance.Free += amount
+		baseBalance.Total += amount
+	} else {
+		e.reserved[order.Id] -= amount
+		baseBalance.Used -= amount
+		baseBalance.Total -= amount
+		quoteBalance.Free += cost - fee
+		quoteBalance.Total += cost - fee
+	}
+
+	order.Cost += cost
+	order.Filled += amount
+	order.Remaining = order.Amount - order.Filled
+	order.Fee += fee
+	if order.Type == "market" {
+		order.Price = order.Cost / order.Filled
+	}
+	e.fills = append(e.fills, Fill{
+		OrderId:   order.Id,
+		Symbol:    order.Symbol,
+		Side:      order.Side,
+		Price:     price,
+		Amount:    amount,
+		Fee:       fee,
+		Maker:     maker,
+		Timestamp: e.Now(),
+	})
+	if order.Remaining <= 1e-12 {
+		order.Remaining = 0
+		e.close(order, "closed")
+	}
+}
+
+// close finalizes order and returns any funds it still had locked
+func (e *Engine) close(order *Order, status string) {
+	baseCode, quoteCode, _ := SplitSymbol(order.Symbol)
+	code := quoteCode
+	if order.Side == "sell" {
+		code = baseCode
+	}
+	e.unlock(code, e.reserved[order.Id])
+	delete(e.reserved, order.Id)
+	order.Status = status
+}
+
+// Match fills resting limit orders on symbol that book now crosses, oldest
+// first, with what earlier fills left of it. They are treated as makers and
+// fill at their own limit price.
+func (e *Engine) Match(symbol string, book *OrderBook) {
+	e.Lock()
+	defer e.Unlock()
+	if book == nil {
+		return
+	}
+	use := e.use(symbol, book)
+	for _, order := range e.sortedOpen(symbol) {
+		var amount float64
+		for _, level := range opposite(book, order.Side) {
+			if amount >= order.Remaining || order.Side == "buy" && level[0] > order.Price || order.Side == "sell" && level[0] < order.Price {
+				break
+			}
+			key := levelKey{order.Side, level[0]}
+			if free := level[1] - use.used[key]; free > 0 {
+				take := math.Min(free, order.Remaining-amount)
+				use.used[key] += take
+				amount += take
+			}
+		}
+		if amount > 0 {
+			e.fill(order, order.Price, amount, true)
+		}
+	}
+}
+
+// opposite returns the side of book an order of side executes against
+func opposite(book *OrderBook, side string) [][2]float64 {
+	if side == "buy" {
+		return book.Asks
+	}
+	return book.Bids
+}
+
+// CancelOrder cancels an open order and unlocks its funds
+func (e *Engine) CancelOrder(id string) (*Order, error) {
+	e.Lock()
+	defer e.Unlock()
+	order := e.orders[id]
+	if order == nil {
+		return nil, TypedError("OrderNotFound", "paper order "+id+" not found")
+	}
+	if order.Status != "open" {
+		return nil, TypedError("OrderNotFound", "paper order "+id+" is "+order.Status)
+	}
+	e.close(order, "canceled")
+	copied := *order
+	return &copied, nil
+}
+
+// FetchOrder returns a copy of the order with the given id
+func (e *Engine) FetchOrder(id string) (*Order, error) {
+	e.Lock()
+	defer e.Unlock()
+	order := e.orders[id]
+	if order == nil {
+		return nil, TypedError("OrderNotFound", "paper order "+id+" not found")
+	}
+	copied := *order
+	return &copied, nil
+}
+
+func (e *Engine) sortedOpen(symbol string) (result []*Order) {
+	for _, order := range e.orders {
+		if order.Status == "open" && (symbol == "" || order.Symbol == symbol) {
+			result = append(result, order)
+		}
+	}
+	// ids come from a counter, so numeric order is placement order
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := strconv.ParseInt(result[i].Id, 10, 64)
+		b, _ := strconv.ParseInt(result[j].Id, 10, 64)
+		return a < b
+	})
+	return
+}
+
+// OpenOrders returns copies of the open orders on symbol, or on every
+// symbol if symbol is empty, oldest first
+func (e *Engine) OpenOrders(symbol string) (result []*Order) {
+	e.Lock()
+	defer e.Unlock()
+	for _, order := range e.sortedOpen(symbol) {
+		copied := *order
+		result = append(result, &copied)
+	}
+	return
+}
+
+// OpenSymbols returns the symbols that currently have open orders
+func (e *Engine) OpenSymbols() (result []string) {
+	e.Lock()
+	defer e.Unlock()
+	seen := map[string]bool{}
+	for _, order := range e.sortedOpen("") {
+		if !seen[order.Symbol] {
+			seen[order.Symbol] = true
+			result = append(result, order.Symbol)
+		}
+	}
+	return
+}
+
+// Deposit adds amount of code to the free balance
+func (e *Engine) Deposit(code string, amount float64) {
+	e.Lock()
+	defer e.Unlock()
+	b := e.balance(code)
+	b.Free += amount
+	b.Total += amount
+}
+
+// Withdraw takes amount of code out of the free balance
+func (e *Engine) Withdraw(code string, amount float64) error {
+	e.Lock()
+	defer e.Unlock()
+	b := e.balance(code)
+	if b.Free < amount {
+		return TypedError("InsufficientFunds", fmt.Sprintf("paper %s free %v < withdrawn %v", code, b.Free, amount))
+	}
+	b.Free -= amount
+	b.Total -= amount
+	return nil
+}
+
+// Balance returns a snapshot of the simulated account
+func (e *Engine) Balance() *Account {
+	e.Lock()
+	defer e.Unlock()
+	account := &Account{
+		Free:    map[string]float64{},
+		Used:    map[string]float64{},
+		Total:   map[string]float64{},
+		Account: map[string]*Balance{},
+	}
+	for code, b := range e.balances {
+		account.Free[code] = b.Free
+		account.Used[code] = b.Used
+		account.Total[code] = b.Total
+		copied := *b
+		account.Account[code] = &copied
+	}
+	return account
+}
+
+// Fills returns every execution so far in the order they happened
+func (e *Engine) Fills() []Fill {
+	e.Lock()
+	defer e.Unlock()
+	return append([]Fill(nil), e.fills...)
+}
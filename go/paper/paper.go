@@ -0,0 +1,320 @@
+package paper
+
+import (
+	"strconv"
+	"sync"
+	"time"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// Config for the paper exchange
+type Config struct {
+	// Balances are the initial free balances by currency code
+	Balances map[string]float64
+	// Maker and Taker are fee rates, e.g. 0.001 for 10 bps
+	Maker float64
+	Taker float64
+	// Latency is slept before every simulated order operation to mimic
+	// the round trip to the real exchange
+	Latency time.Duration
+	// BookDepth is the limit passed to FetchOrderBook when matching,
+	// 0 uses the adapter default
+	BookDepth int64
+}
+
+// Paper wraps a real adapter: market data calls go to the wrapped exchange,
+// order and balance calls are simulated locally by an Engine against the
+// live order book, and the other account calls are simulated or
+// NotSupported. No private request ever reaches the wrapped exchange.
+//
+// Paper implements every method of ExchangeInterface itself rather than
+// embedding the adapter, so a method added to the interface does not build
+// until it is decided here whether it is forwarded.
+type Paper struct {
+	public ExchangeInterface
+
+	Engine *Engine
+	config Config
+
+	mu          sync.Mutex
+	withdrawals []*Transaction
+}
+
+var _ ExchangeInterface = (*Paper)(nil)
+
+func New(ex ExchangeInterface, config *Config) (p *Paper, err error) {
+	if ex == nil {
+		return nil, TypedError("ArgumentsRequired", "paper requires an exchange to wrap")
+	}
+	p = &Paper{public: ex}
+	if config != nil {
+		p.config = *config
+	}
+	p.Engine = NewEngine(p.config.Balances, p.config.Maker, p.config.Taker)
+	p.Engine.Now = ex.Milliseconds
+	return
+}
+
+func (self *Paper) sleep() {
+	if self.config.Latency > 0 {
+		time.Sleep(self.config.Latency)
+	}
+}
+
+func (self *Paper) book(symbol string) (*OrderBook, error) {
+	return self.public.FetchOrderBook(symbol, self.config.BookDepth, nil)
+}
+
+// match fills resting orders of symbol (or of every symbol with open
+// orders if symbol is empty) against the current books
+func (self *Paper) match(symbol string) error {
+	symbols := []string{symbol}
+	if symbol == "" {
+		symbols = self.Engine.OpenSymbols()
+	}
+	for _, s := range symbols {
+		if len(self.Engine.OpenOrders(s)) == 0 {
+			continue
+		}
+		book, err := self.book(s)
+		if err != nil {
+			return err
+		}
+		self.Engine.Match(s, book)
+	}
+	return nil
+}
+
+func (self *Paper) FetchOrderBook(symbol string, limit int64, params map[string]interface{}) (*OrderBook, error) {
+	return self.public.FetchOrderBook(symbol, limit, params)
+}
+
+func (self *Paper) FetchMarkets(params map[string]interface{}) []interface{} {
+	return self.public.FetchMarkets(params)
+}
+
+func (self *Paper) SetMarkets(markets []interface{}, currencies map[string]interface{}) map[string]*Market {
+	return self.public.SetMarkets(markets, currencies)
+}
+
+func (self *Paper) LoadMarkets() map[string]*Market {
+	return self.public.LoadMarkets()
+}
+
+func (self *Paper) FetchCurrencies(params map[string]interface{}) map[string]interface{} {
+	return self.public.FetchCurrencies(params)
+}
+
+func (self *Paper) FetchTime(params map[string]interface{}) (int64, error) {
+	return self.public.FetchTime(params)
+}
+
+func (self *Paper) Milliseconds() int64 {
+	return self.public.Milliseconds()
+}
+
+func (self *Paper) SetBaseUrl(url string) {
+	self.public.SetBaseUrl(url)
+}
+
+func (self *Paper) BaseUrl() string {
+	return self.public.BaseUrl()
+}
+
+// SetApiKey and the other credential setters do nothing, paper signs no
+// request and the wrapped exchange keeps its own credentials
+func (self *Paper) SetApiKey(string) {}
+
+func (self *Paper) SetSecret(string) {}
+
+func (self *Paper) SetPassword(string) {}
+
+func (self *Paper) SetUid(string) {}
+
+func (self *Paper) SetCredentialProvider(CredentialProvider) {}
+
+func (self *Paper) CreateOrder(symbol, typ, side string, amount float64, price float64, params map[string]interface{}) (*Order, error) {
+	self.sleep()
+	book, err := self.book(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return self.Engine.CreateOrder(symbol, typ, side, amount, price, book)
+}
+
+func (self *Paper) LimitBuy(symbol string, price, amount float64, params map[string]interface{}) (*Order, error) {
+	return self.CreateOrder(symbol, "limit", "buy", amount, price, params)
+}
+
+func (self *Paper) LimitSell(symbol string, price, amount float64, params map[string]interface{}) (*Order, error) {
+	return self.CreateOrder(symbol, "limit", "sell", amount, price, params)
+}
+
+func (self *Paper) CancelOrder(id string, symbol string, params map[string]interface{}) (interface{}, error) {
+	self.sleep()
+	// a fill that happened before the cancel arrived must win
+	if err := self.match(symbol); err != nil {
+		return nil, err
+	}
+	return self.Engine.CancelOrder(id)
+}
+
+func (self *Paper) FetchOrder(id string, symbol string, params map[string]interface{}) (*Order, error) {
+	self.sleep()
+	if err := self.match(symbol); err != nil {
+		return nil, err
+	}
+	return self.Engine.FetchOrder(id)
+}
+
+func (self *Paper) FetchOpenOrders(symbol string, since int64, limit int64, params map[string]interface{}) ([]*Order, error) {
+	self.sleep()
+	if err := self.match(symbol); err != nil {
+		return nil, err
+	}
+	result := []*Order{}
+	for _, order := range self.Engine.OpenOrders(symbol) {
+		if order.Timestamp < since {
+			continue
+		}
+		if limit > 0 && int64(len(result)) >= limit {
+			break
+		}
+		result = append(result, order)
+	}
+	return result, nil
+}
+
+func (self *Paper) FetchBalance(params map[string]interface{}) (*Account, error) {
+	self.sleep()
+	if err := self.match(""); err != nil {
+		return nil, err
+	}
+	return self.Engine.Balance(), nil
+}
+
+func (self *Paper) FetchAccounts(params map[string]interface{}) []interface{} {
+	return []interface{}{map[string]interface{}{"id": "paper", "type": "spot"}}
+}
+
+// Withdraw takes amount out of the simulated balance, the withdrawal is done
+// at once and nothing is sent anywhere
+func (self *Paper) Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (*Transaction, error) {
+	self.sleep()
+	if err := self.Engine.Withdraw(code, amount); err != nil {
+		return nil, err
+	}
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	timestamp := self.Engine.Now()
+	withdrawal := &Transaction{
+		Id:        "paper-" + strconv.Itoa(len(self.withdrawals)+1),
+		Type:      "withdrawal",
+		Currency:  code,
+		Network:   network,
+		Address:   address,
+		Tag:       tag,
+		Amount:    amount,
+		Status:    "ok",
+		Timestamp: timestamp,
+		Datetime:  datetime(timestamp),
+		Updated:   timestamp,
+	}
+	self.withdrawals = append(self.withdrawals, withdrawal)
+	copied := *withdrawal
+	return &copied, nil
+}
+
+// FetchWithdrawals returns the simulated withdrawals of code, every code if
+// empty
+func (self *Paper) FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error) {
+	self.mu.Lock()
+	defer self.mu.Unlock()
+	// withdrawals are kept in the order they were made, oldest first
+	result := []*Transaction{}
+	for _, withdrawal := range self.withdrawals {
+		if limit > 0 && int64(len(result)) >= limit {
+			break
+		}
+		if (code == "" || withdrawal.Currency == code) && withdrawal.Timestamp >= since {
+			copied := *withdrawal
+			result = append(result, &copied)
+		}
+	}
+	return result, nil
+}
+
+// FetchDeposits is always empty, paper balances only come from Config
+func (self *Paper) FetchDeposits(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error) {
+	return []*Transaction{}, nil
+}
+
+func (self *Paper) FetchTransactions(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error) {
+	return self.FetchWithdrawals(code, since, limit, params)
+}
+
+// FetchDepositAddress returns a placeholder, a paper account cannot be
+// deposited to
+func (self *Paper) FetchDepositAddress(code string, network string, params map[string]interface{}) (*DepositAddress, error) {
+	return &DepositAddress{Currency: code, Address: "paper-" + code, Network: network, Status: "ok"}, nil
+}
+
+func (self *Paper) CreateDepositAddress(code string, network string, params map[string]interface{}) (*DepositAddress, error) {
+	return self.FetchDepositAddress(code, network, params)
+}
+
+// Transfer is not supported, a paper session has a single spot account
+func (self *Paper) Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (*TransferEntry, error) {
+	return nil, TypedError("NotSupported", "paper has a single account to transfer from and to")
+}
+
+// FetchTransfers is always empty, see Transfer
+func (self *Paper) FetchTransfers(code string, since int64, limit int64, params map[string]interface{}) ([]*TransferEntry, error) {
+	return []*TransferEntry{}, nil
+}
+
+// Borrow is not supported, the paper account has no margin
+func (self *Paper) Borrow(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error) {
+	return nil, TypedError("NotSupported", "paper cannot borrow")
+}
+
+// Repay is not supported, see Borrow
+func (self *Paper) Repay(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error) {
+	return nil, TypedError("NotSupported", "paper cannot repay")
+}
+
+// FetchBorrowRate is not supported, the live rate is for the live account
+func (self *Paper) FetchBorrowRate(code string, params map[string]interface{}) (*BorrowRate, error) {
+	return nil, TypedError("NotSupported", "paper cannot borrow")
+}
+
+// FetchBorrowInterest is always empty, see Borrow
+func (self *Paper) FetchBorrowInterest(code string, symbol string, since int64, limit int64, params map[string]interface{}) ([]*BorrowInterest, error) {
+	return []*BorrowInterest{}, nil
+}
+
+// FetchMaxBorrowable is always 0, see Borrow
+func (self *Paper) FetchMaxBorrowable(code string, symbol string, params map[string]interface{}) (float64, error) {
+	return 0, nil
+}
+
+// FetchPositions is always empty, the paper account only trades spot
+func (self *Paper) FetchPositions(symbols []string, params map[string]interface{}) ([]*Position, error) {
+	return []*Position{}, nil
+}
+
+// FetchLeverage is not supported, see FetchPositions
+func (self *Paper) FetchLeverage(symbol string, params map[string]interface{}) (*Leverage, error) {
+	return nil, TypedError("NotSupported", "paper has no leverage")
+}
+
+// SetLeverage is not supported, see FetchPositions
+func (self *Paper) SetLeverage(symbol string, leverage float64, params map[string]interface{}) error {
+	return TypedError("NotSupported", "paper has no leverage")
+}
+
+// SetMarginMode is not supported, see FetchPositions
+func (self *Paper) SetMarginMode(symbol string, marginMode string, params map[string]interface{}) error {
+	return TypedError("NotSupported", "paper has no margin mode")
+}
@@ -0,0 +1,327 @@
+package paper
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// stubExchange serves a fixed order book and records the private methods
+// that reach it
+type stubExchange struct {
+	Exchange
+	book    *OrderBook
+	private []string
+}
+
+func (self *stubExchange) Withdraw(code string, amount float64, address string, tag string, network string, params map[string]interface{}) (*Transaction, error) {
+	self.private = append(self.private, "Withdraw")
+	return &Transaction{}, nil
+}
+
+func (self *stubExchange) CreateDepositAddress(code string, network string, params map[string]interface{}) (*DepositAddress, error) {
+	self.private = append(self.private, "CreateDepositAddress")
+	return &DepositAddress{}, nil
+}
+
+func (self *stubExchange) Transfer(code string, amount float64, fromAccount string, toAccount string, params map[string]interface{}) (*TransferEntry, error) {
+	self.private = append(self.private, "Transfer")
+	return &TransferEntry{}, nil
+}
+
+func (self *stubExchange) Borrow(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error) {
+	self.private = append(self.private, "Borrow")
+	return &MarginLoan{}, nil
+}
+
+func (self *stubExchange) Repay(code string, amount float64, symbol string, params map[string]interface{}) (*MarginLoan, error) {
+	self.private = append(self.private, "Repay")
+	return &MarginLoan{}, nil
+}
+
+func (self *stubExchange) SetLeverage(symbol string, leverage float64, params map[string]interface{}) error {
+	self.private = append(self.private, "SetLeverage")
+	return nil
+}
+
+func (self *stubExchange) FetchPositions(symbols []string, params map[string]interface{}) ([]*Position, error) {
+	self.private = append(self.private, "FetchPositions")
+	return []*Position{{Symbol: "BTC/USDT", Contracts: 1}}, nil
+}
+
+func (self *stubExchange) FetchWithdrawals(code string, since int64, limit int64, params map[string]interface{}) ([]*Transaction, error) {
+	self.private = append(self.private, "FetchWithdrawals")
+	return nil, nil
+}
+
+func (self *stubExchange) FetchOrderBook(symbol string, limit int64, params map[string]interface{}) (*OrderBook, error) {
+	copied := *self.book
+	return &copied, nil
+}
+
+func (self *stubExchange) LoadMarkets() map[string]*Market {
+	return nil
+}
+
+func newPaper(t *testing.T) (*Paper, *stubExchange) {
+	stub := &stubExchange{book: &OrderBook{
+		Bids: [][2]float64{{99, 1}, {98, 2}},
+		Asks: [][2]float64{{101, 1}, {102, 2}},
+	}}
+	p, err := New(stub, &Config{
+		Balances: map[string]float64{"USDT": 1000, "BTC": 1},
+		Maker:    0.001,
+		Taker:    0.002,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p, stub
+}
+
+func TestMarketOrderWalksBook(t *testing.T) {
+	p, _ := newPaper(t)
+	order, err := p.CreateOrder("BTC/USDT", "market", "buy", 2, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "closed" || order.Filled != 2 || order.Cost != 101+102 {
+		t.Fatalf("unexpected order: %+v", order)
+	}
+	balance, _ := p.FetchBalance(nil)
+	if balance.Total["BTC"] != 3 {
+		t.Fatal("BTC total:", balance.Total["BTC"])
+	}
+	wantUSDT := 1000 - 203*1.002
+	if !almostEqual(balance.Total["USDT"], wantUSDT) || !almostEqual(balance.Free["USDT"], wantUSDT) || !almostEqual(balance.Used["USDT"], 0) {
+		t.Fatal("USDT balance:", balance.Account["USDT"])
+	}
+}
+
+func TestLimitOrderRestsThenFills(t *testing.T) {
+	p, stub := newPaper(t)
+	order, err := p.LimitSell("BTC/USDT", 105, 0.5, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != "open" || order.Filled != 0 {
+		t.Fatalf("order should rest: %+v", order)
+	}
+	balance, _ := p.FetchBalance(nil)
+	if balance.Used["BTC"] != 0.5 || balance.Free["BTC"] != 0.5 {
+		t.Fatal("BTC should be locked:", balance.Account["BTC"])
+	}
+
+	stub.book = &OrderBook{Bids: [][2]float64{{106, 1}}, Asks: [][2]float64{{107, 1}}}
+	open, err := p.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(open) != 0 {
+		t.Fatal("order should have filled:", open)
+	}
+	filled, _ := p.FetchOrder(order.Id, "BTC/USDT", nil)
+	if filled.Status != "closed" || filled.Cost != 52.5 {
+		t.Fatalf("maker fill should be at limit price: %+v", filled)
+	}
+	fills := p.Engine.Fills()
+	if len(fills) != 1 || !fills[0].Maker || !almostEqual(fills[0].Fee, 52.5*0.001) {
+		t.Fatal("fills:", fills)
+	}
+}
+
+func TestPollingOneBookFillsOnce(t *testing.T) {
+	p, stub := newPaper(t)
+	order, err := p.LimitSell("BTC/USDT", 105, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// only 0.4 of the bid crosses the order
+	stub.book = &OrderBook{Bids: [][2]float64{{106, 0.4}, {104, 5}}, Asks: [][2]float64{{107, 1}}, Nonce: 7}
+	first, err := p.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil || !almostEqual(first.Filled, 0.4) {
+		t.Fatal("first poll:", first, err)
+	}
+	second, err := p.FetchOrder(order.Id, "BTC/USDT", nil)
+	if err != nil || second.Filled != first.Filled || second.Status != "open" {
+		t.Fatal("second poll of the same book:", second, err)
+	}
+	p.FetchBalance(nil)
+	p.FetchOpenOrders("BTC/USDT", 0, 0, nil)
+	if fills := p.Engine.Fills(); len(fills) != 1 {
+		t.Fatal("fills:", fills)
+	}
+
+	// an identical snapshot is the same liquidity, a changed one is new
+	stub.book = &OrderBook{Bids: [][2]float64{{106, 0.4}, {104, 5}}, Asks: [][2]float64{{107, 1}}, Nonce: 8}
+	if third, _ := p.FetchOrder(order.Id, "BTC/USDT", nil); !almostEqual(third.Filled, 0.4) {
+		t.Fatal("identical book:", third)
+	}
+	stub.book = &OrderBook{Bids: [][2]float64{{106, 0.5}, {104, 5}}, Asks: [][2]float64{{107, 1}}, Nonce: 9}
+	if fourth, _ := p.FetchOrder(order.Id, "BTC/USDT", nil); !almostEqual(fourth.Filled, 0.9) {
+		t.Fatal("new book:", fourth)
+	}
+}
+
+func TestOrdersShareALevel(t *testing.T) {
+	p, stub := newPaper(t)
+	older, _ := p.LimitBuy("BTC/USDT", 100, 1, nil)
+	newer, _ := p.LimitBuy("BTC/USDT", 100, 1, nil)
+
+	stub.book = &OrderBook{Bids: [][2]float64{{98, 1}}, Asks: [][2]float64{{99, 1.5}}}
+	a, _ := p.FetchOrder(older.Id, "BTC/USDT", nil)
+	b, _ := p.FetchOrder(newer.Id, "BTC/USDT", nil)
+	if a.Filled != 1 || !almostEqual(b.Filled, 0.5) {
+		t.Fatal("1.5 available: older filled", a.Filled, "newer filled", b.Filled)
+	}
+}
+
+func TestCancelUnlocksFunds(t *testing.T) {
+	p, _ := newPaper(t)
+	order, err := p.LimitBuy("BTC/USDT", 90, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.CancelOrder(order.Id, "BTC/USDT", nil); err != nil {
+		t.Fatal(err)
+	}
+	balance, _ := p.FetchBalance(nil)
+	if balance.Free["USDT"] != 1000 || balance.Used["USDT"] != 0 {
+		t.Fatal("USDT should be unlocked:", balance.Account["USDT"])
+	}
+	if _, err := p.CancelOrder(order.Id, "BTC/USDT", nil); !errors.Is(err, OrderNotFound) {
+		t.Fatal("second cancel should be OrderNotFound:", err)
+	}
+}
+
+func TestInsufficientFunds(t *testing.T) {
+	p, _ := newPaper(t)
+	_, err := p.LimitBuy("BTC/USDT", 100, 100, nil)
+	if !errors.Is(err, InsufficientFunds) {
+		t.Fatal("expected InsufficientFunds:", err)
+	}
+}
+
+func TestWithdrawIsSimulated(t *testing.T) {
+	p, stub := newPaper(t)
+	withdrawal, err := p.Withdraw("USDT", 400, "0xabc", "", "ERC20", nil)
+	if err != nil || withdrawal.Status != "ok" || withdrawal.Amount != 400 || withdrawal.Timestamp == 0 {
+		t.Fatal("Withdraw:", withdrawal, err)
+	}
+	if balance, _ := p.FetchBalance(nil); balance.Free["USDT"] != 600 || balance.Total["USDT"] != 600 {
+		t.Error("USDT after withdrawal:", balance.Account["USDT"])
+	}
+	if _, err := p.Withdraw("USDT", 1000, "0xabc", "", "", nil); !errors.Is(err, InsufficientFunds) {
+		t.Error("withdrawal over the balance:", err)
+	}
+	if withdrawals, err := p.FetchWithdrawals("USDT", 0, 0, nil); err != nil || len(withdrawals) != 1 || withdrawals[0].Id != withdrawal.Id {
+		t.Error("FetchWithdrawals:", withdrawals, err)
+	}
+	if address, err := p.CreateDepositAddress("BTC", "", nil); err != nil || address.Address == "" {
+		t.Error("CreateDepositAddress:", address, err)
+	}
+	if len(stub.private) != 0 {
+		t.Error("reached the wrapped exchange:", stub.private)
+	}
+}
+
+func TestTransferIsNotForwarded(t *testing.T) {
+	p, stub := newPaper(t)
+	if _, err := p.Transfer("USDT", 100, "spot", "futures", nil); !errors.Is(err, NotSupported) {
+		t.Error("Transfer:", err)
+	}
+	if transfers, err := p.FetchTransfers("", 0, 0, nil); err != nil || len(transfers) != 0 {
+		t.Error("FetchTransfers:", transfers, err)
+	}
+	if len(stub.private) != 0 {
+		t.Error("reached the wrapped exchange:", stub.private)
+	}
+}
+
+func TestBorrowIsNotForwarded(t *testing.T) {
+	p, stub := newPaper(t)
+	if _, err := p.Borrow("USDT", 100, "", nil); !errors.Is(err, NotSupported) {
+		t.Error("Borrow:", err)
+	}
+	if _, err := p.Repay("USDT", 100, "", nil); !errors.Is(err, NotSupported) {
+		t.Error("Repay:", err)
+	}
+	if borrowable, err := p.FetchMaxBorrowable("USDT", "", nil); err != nil || borrowable != 0 {
+		t.Error("FetchMaxBorrowable:", borrowable, err)
+	}
+	if len(stub.private) != 0 {
+		t.Error("reached the wrapped exchange:", stub.private)
+	}
+}
+
+func TestLeverageIsNotForwarded(t *testing.T) {
+	p, stub := newPaper(t)
+	if err := p.SetLeverage("BTC/USDT", 20, nil); !errors.Is(err, NotSupported) {
+		t.Error("SetLeverage:", err)
+	}
+	if err := p.SetMarginMode("BTC/USDT", "isolated", nil); !errors.Is(err, NotSupported) {
+		t.Error("SetMarginMode:", err)
+	}
+	if _, err := p.FetchLeverage("BTC/USDT", nil); !errors.Is(err, NotSupported) {
+		t.Error("FetchLeverage:", err)
+	}
+	if positions, err := p.FetchPositions(nil, nil); err != nil || len(positions) != 0 {
+		t.Error("FetchPositions:", positions, err)
+	}
+	if len(stub.private) != 0 {
+		t.Error("reached the wrapped exchange:", stub.private)
+	}
+}
+
+// publicOnly has nothing behind its private methods, calling one panics
+type publicOnly struct {
+	ExchangeInterface
+	book *OrderBook
+}
+
+func (self *publicOnly) FetchOrderBook(symbol string, limit int64, params map[string]interface{}) (*OrderBook, error) {
+	copied := *self.book
+	return &copied, nil
+}
+
+func (self *publicOnly) LoadMarkets() map[string]*Market                               { return nil }
+func (self *publicOnly) FetchMarkets(map[string]interface{}) []interface{}             { return nil }
+func (self *publicOnly) FetchCurrencies(map[string]interface{}) map[string]interface{} { return nil }
+func (self *publicOnly) FetchTime(map[string]interface{}) (int64, error)               { return 0, nil }
+func (self *publicOnly) Milliseconds() int64                                           { return 1 }
+func (self *publicOnly) SetBaseUrl(string)                                             {}
+func (self *publicOnly) BaseUrl() string                                               { return "" }
+func (self *publicOnly) SetMarkets([]interface{}, map[string]interface{}) map[string]*Market {
+	return nil
+}
+
+func TestOnlyPublicMethodsAreForwarded(t *testing.T) {
+	p, err := New(&publicOnly{book: &OrderBook{Bids: [][2]float64{{99, 1}}, Asks: [][2]float64{{101, 1}}}}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	methods := reflect.TypeOf((*ExchangeInterface)(nil)).Elem()
+	for i := 0; i < methods.NumMethod(); i++ {
+		method := reflect.ValueOf(p).MethodByName(methods.Method(i).Name)
+		args := []reflect.Value{}
+		for j := 0; j < method.Type().NumIn(); j++ {
+			args = append(args, reflect.Zero(method.Type().In(j)))
+		}
+		func() {
+			defer func() {
+				if e := recover(); e != nil {
+					t.Error(methods.Method(i).Name, "reached the wrapped exchange:", e)
+				}
+			}()
+			method.Call(args)
+		}()
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
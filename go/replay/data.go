@@ -0,0 +1,144 @@
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	. "github.com/georgexdz/ccxt/go/base"
+)
+
+// Event is one line of a recording. Files are JSON lines, one event per line:
+//
+//	{"type":"orderbook","symbol":"BTC/USDT","timestamp":1600000000000,"bids":[[99,1]],"asks":[[101,1]]}
+//	{"type":"trade","symbol":"BTC/USDT","timestamp":1600000000100,"price":100,"amount":0.1,"side":"buy"}
+//	{"type":"ohlcv","symbol":"BTC/USDT","timestamp":1600000000000,"timeframe":"1m","ohlcv":[1600000000000,99,101,98,100,12]}
+//
+// For ohlcv events timestamp is the candle open time, the candle becomes
+// visible once it has closed.
+type Event struct {
+	Type      string       `json:"type"`
+	Symbol    string       `json:"symbol"`
+	Timestamp int64        `json:"timestamp"`
+	Bids      [][2]float64 `json:"bids,omitempty"`
+	Asks      [][2]float64 `json:"asks,omitempty"`
+	Id        string       `json:"id,omitempty"`
+	Price     float64      `json:"price,omitempty"`
+	Amount    float64      `json:"amount,omitempty"`
+	Side      string       `json:"side,omitempty"`
+	Timeframe string       `json:"timeframe,omitempty"`
+	OHLCV     []float64    `json:"ohlcv,omitempty"`
+}
+
+// LoadFile reads the events recorded in a JSON lines file
+func LoadFile(path string) (events []Event, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		var event Event
+		if err = json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		if err = event.validate(); err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", path, line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+func (e *Event) validate() error {
+	switch e.Type {
+	case "orderbook", "trade":
+	case "ohlcv":
+		if len(e.OHLCV) != 6 {
+			return fmt.Errorf("ohlcv event needs 6 values, got %d", len(e.OHLCV))
+		}
+		if _, err := TimeframeToMilliseconds(e.Timeframe); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("unknown event type %q", e.Type)
+	}
+	if e.Symbol == "" {
+		return fmt.Errorf("%s event without symbol", e.Type)
+	}
+	return nil
+}
+
+// TimeframeToMilliseconds parses a unified timeframe such as "1m", "4h" or "1d"
+func TimeframeToMilliseconds(timeframe string) (int64, error) {
+	if len(timeframe) < 2 {
+		return 0, fmt.Errorf("bad timeframe %q", timeframe)
+	}
+	amount, err := strconv.ParseInt(timeframe[:len(timeframe)-1], 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("bad timeframe %q", timeframe)
+	}
+	var unit int64
+	switch timeframe[len(timeframe)-1] {
+	case 's':
+		unit = 1000
+	case 'm':
+		unit = 60 * 1000
+	case 'h':
+		unit = 60 * 60 * 1000
+	case 'd':
+		unit = 24 * 60 * 60 * 1000
+	case 'w':
+		unit = 7 * 24 * 60 * 60 * 1000
+	case 'M':
+		unit = 30 * 24 * 60 * 60 * 1000
+	case 'y':
+		unit = 365 * 24 * 60 * 60 * 1000
+	default:
+		return 0, fmt.Errorf("bad timeframe %q", timeframe)
+	}
+	return amount * unit, nil
+}
+
+func (e *Event) orderBook() *OrderBook {
+	book := &OrderBook{
+		Bids:      append([][2]float64(nil), e.Bids...),
+		Asks:      append([][2]float64(nil), e.Asks...),
+		Timestamp: e.Timestamp,
+	}
+	SortSliceByIndex(book.Bids, 0, true)
+	SortSliceByIndex(book.Asks, 0, false)
+	return book
+}
+
+func (e *Event) trade() Trade {
+	return Trade{
+		Id:        e.Id,
+		Symbol:    e.Symbol,
+		Amount:    e.Amount,
+		Price:     e.Price,
+		Timestamp: JSONTime(e.Timestamp),
+		Side:      e.Side,
+	}
+}
+
+func (e *Event) ohlcv() OHLCV {
+	return OHLCV{
+		Timestamp: JSONTime(e.OHLCV[0]),
+		O:         e.OHLCV[1],
+		H:         e.OHLCV[2],
+		L:         e.OHLCV[3],
+		C:         e.OHLCV[4],
+		V:         e.OHLCV[5],
+	}
+}
@@ -0,0 +1,286 @@
+package replay
+
+import (
+	"fmt"
+	"sort"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+// Config for a replay run
+type Config struct {
+	// Files are JSON lines recordings, see Event; they are merged by timestamp
+	Files []string
+	// Balances are the initial free balances by currency code
+	Balances map[string]float64
+	Maker    float64
+	Taker    float64
+	// Currency the report values balances in, e.g. "USDT"
+	Currency string
+}
+
+// Replay is an ExchangeInterface backed by recorded market data. Time only
+// moves when the caller steps the clock, every call sees the data recorded
+// up to the current simulated time, and orders fill deterministically
+// through a paper.Engine against the recorded books.
+type Replay struct {
+	Exchange
+
+	Engine *paper.Engine
+
+	config  Config
+	events  []Event
+	next    int
+	now     int64
+	begin   int64
+	books   map[string]*OrderBook
+	first   map[string]*OrderBook // first book of each symbol, for the report
+	trades  map[string][]Trade
+	candles map[string]map[string][]OHLCV // symbol -> timeframe -> closed candles
+	start   map[string]float64
+}
+
+func New(config *Config) (ex *Replay, err error) {
+	ex = &Replay{
+		books:   map[string]*OrderBook{},
+		first:   map[string]*OrderBook{},
+		trades:  map[string][]Trade{},
+		candles: map[string]map[string][]OHLCV{},
+	}
+	if config != nil {
+		ex.config = *config
+	}
+	ex.Id = "replay"
+	ex.Name = "Replay"
+	ex.Child = ex
+	ex.Clock = ex.Now
+	ex.Urls = map[string]interface{}{}
+	ex.Engine = paper.NewEngine(ex.config.Balances, ex.config.Maker, ex.config.Taker)
+	ex.Engine.Now = ex.Now
+	ex.start = ex.Engine.Balance().Total
+
+	var events []Event
+	for _, file := range ex.config.Files {
+		loaded, err := LoadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, loaded...)
+	}
+	ex.AddEvents(events)
+	return
+}
+
+// AddEvents merges events into the recording, they must not be older than
+// the current simulated time
+func (self *Replay) AddEvents(events []Event) {
+	pending := append(append([]Event(nil), self.events[self.next:]...), events...)
+	sort.SliceStable(pending, func(i, j int) bool {
+		return self.visibleAt(&pending[i]) < self.visibleAt(&pending[j])
+	})
+	self.events = append(self.events[:self.next], pending...)
+	if self.next == 0 && len(pending) > 0 {
+		self.begin = self.visibleAt(&pending[0])
+		self.now = self.begin
+	}
+}
+
+// visibleAt is the simulated time from which an event may be observed
+func (self *Replay) visibleAt(e *Event) int64 {
+	if e.Type == "ohlcv" {
+		duration, _ := TimeframeToMilliseconds(e.Timeframe)
+		return e.Timestamp + duration
+	}
+	return e.Timestamp
+}
+
+// Now returns the simulated time in milliseconds
+func (self *Replay) Now() int64 {
+	return self.now
+}
+
+// Done reports whether every recorded event has been replayed
+func (self *Replay) Done() bool {
+	return self.next >= len(self.events)
+}
+
+// Step advances the clock to the next recorded event and applies every
+// event visible at that time. It returns false once the recording is over.
+func (self *Replay) Step() bool {
+	if self.Done() {
+		return false
+	}
+	self.AdvanceTo(self.visibleAt(&self.events[self.next]))
+	return true
+}
+
+// AdvanceTo moves the clock forward to timestamp, applying the events on the way
+func (self *Replay) AdvanceTo(timestamp int64) {
+	for !self.Done() && self.visibleAt(&self.events[self.next]) <= timestamp {
+		event := &self.events[self.next]
+		self.now = self.visibleAt(event)
+		self.apply(event)
+		self.next++
+	}
+	if timestamp > self.now {
+		self.now = timestamp
+	}
+}
+
+func (self *Replay) apply(e *Event) {
+	switch e.Type {
+	case "orderbook":
+		book := e.orderBook()
+		self.books[e.Symbol] = book
+		if self.first[e.Symbol] == nil {
+			self.first[e.Symbol] = book
+		}
+		self.Engine.Match(e.Symbol, book)
+	case "trade":
+		self.trades[e.Symbol] = append(self.trades[e.Symbol], e.trade())
+	case "ohlcv":
+		if self.candles[e.Symbol] == nil {
+			self.candles[e.Symbol] = map[string][]OHLCV{}
+		}
+		self.candles[e.Symbol][e.Timeframe] = append(self.candles[e.Symbol][e.Timeframe], e.ohlcv())
+	}
+}
+
+// Run steps through the whole recording, calling onTick after each step,
+// and returns the report. It stops early if onTick returns an error.
+func (self *Replay) Run(onTick func(now int64) error) (*Report, error) {
+	for self.Step() {
+		if onTick != nil {
+			if err := onTick(self.now); err != nil {
+				return self.Report(), err
+			}
+		}
+	}
+	return self.Report(), nil
+}
+
+func (self *Replay) LoadMarkets() map[string]*Market {
+	if self.Markets != nil {
+		return self.Markets
+	}
+	return self.SetMarkets(self.FetchMarkets(nil), nil)
+}
+
+// FetchMarkets lists a spot market for every symbol in the recording
+func (self *Replay) FetchMarkets(params map[string]interface{}) []interface{} {
+	seen := map[string]bool{}
+	result := []interface{}{}
+	for _, e := range self.events {
+		if seen[e.Symbol] {
+			continue
+		}
+		seen[e.Symbol] = true
+		base, quote, err := paper.SplitSymbol(e.Symbol)
+		if err != nil {
+			continue
+		}
+		result = append(result, map[string]interface{}{
+			"id":     e.Symbol,
+			"symbol": e.Symbol,
+			"base":   base,
+			"quote":  quote,
+			"baseId": base,
+			"type":   "spot",
+			"spot":   true,
+			"taker":  self.config.Taker,
+			"maker":  self.config.Maker,
+		})
+	}
+	return result
+}
+
+// FetchOrderBook returns the latest recorded book at the simulated time
+func (self *Replay) FetchOrderBook(symbol string, limit int64, params map[string]interface{}) (*OrderBook, error) {
+	book := self.books[symbol]
+	if book == nil {
+		return nil, TypedError("BadSymbol", fmt.Sprintf("replay has no order book for %s at %d", symbol, self.now))
+	}
+	result := &OrderBook{
+		Bids:      append([][2]float64(nil), book.Bids...),
+		Asks:      append([][2]float64(nil), book.Asks...),
+		Timestamp: book.Timestamp,
+		Datetime:  self.Iso8601(book.Timestamp),
+	}
+	if limit > 0 {
+		if int64(len(result.Bids)) > limit {
+			result.Bids = result.Bids[:limit]
+		}
+		if int64(len(result.Asks)) > limit {
+			result.Asks = result.Asks[:limit]
+		}
+	}
+	return result, nil
+}
+
+// FetchTrades returns recorded public trades up to the simulated time
+func (self *Replay) FetchTrades(symbol string, since int64, limit int64, params map[string]interface{}) ([]Trade, error) {
+	result := []Trade{}
+	for _, trade := range self.trades[symbol] {
+		if int64(trade.Timestamp) >= since {
+			result = append(result, trade)
+		}
+	}
+	if limit > 0 && int64(len(result)) > limit {
+		result = result[int64(len(result))-limit:]
+	}
+	return result, nil
+}
+
+// FetchOHLCV returns the candles of timeframe that have closed by the simulated time
+func (self *Replay) FetchOHLCV(symbol string, timeframe string, since int64, limit int64, params map[string]interface{}) ([]OHLCV, error) {
+	result := []OHLCV{}
+	for _, candle := range self.candles[symbol][timeframe] {
+		if int64(candle.Timestamp) >= since {
+			result = append(result, candle)
+		}
+	}
+	if limit > 0 && int64(len(result)) > limit {
+		result = result[int64(len(result))-limit:]
+	}
+	return result, nil
+}
+
+func (self *Replay) CreateOrder(symbol string, typ string, side string, amount float64, price float64, params map[string]interface{}) (*Order, error) {
+	return self.Engine.CreateOrder(symbol, typ, side, amount, price, self.books[symbol])
+}
+
+func (self *Replay) CancelOrder(id string, symbol string, params map[string]interface{}) (interface{}, error) {
+	return self.Engine.CancelOrder(id)
+}
+
+func (self *Replay) FetchOrder(id string, symbol string, params map[string]interface{}) (*Order, error) {
+	return self.Engine.FetchOrder(id)
+}
+
+func (self *Replay) FetchOpenOrders(symbol string, since int64, limit int64, params map[string]interface{}) ([]*Order, error) {
+	result := []*Order{}
+	for _, order := range self.Engine.OpenOrders(symbol) {
+		if order.Timestamp < since {
+			continue
+		}
+		if limit > 0 && int64(len(result)) >= limit {
+			break
+		}
+		result = append(result, order)
+	}
+	return result, nil
+}
+
+func (self *Replay) FetchBalance(params map[string]interface{}) (*Account, error) {
+	return self.Engine.Balance(), nil
+}
+
+// FetchTime returns the simulated time
+func (self *Replay) FetchTime(params map[string]interface{}) (int64, error) {
+	return self.Now(), nil
+}
+
+func (self *Replay) FetchAccounts(params map[string]interface{}) []interface{} {
+	return []interface{}{map[string]interface{}{"id": "replay", "type": "spot"}}
+}
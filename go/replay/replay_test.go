@@ -0,0 +1,152 @@
+package replay
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/georgexdz/ccxt/go/base"
+)
+
+func newReplay(t *testing.T) *Replay {
+	ex, err := New(&Config{
+		Files:    []string{"testdata/btc_usdt.jsonl"},
+		Balances: map[string]float64{"USDT": 1000},
+		Maker:    0.001,
+		Taker:    0.001,
+		Currency: "USDT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ex
+}
+
+func TestReplayClockHidesTheFuture(t *testing.T) {
+	ex := newReplay(t)
+	var iex base.ExchangeInterface = ex
+
+	if !ex.Step() || iex.Milliseconds() != 1600000000000 {
+		t.Fatal("first step should land on the first event:", iex.Milliseconds())
+	}
+	candles, _ := ex.FetchOHLCV("BTC/USDT", "1s", 0, 0, nil)
+	if len(candles) != 0 {
+		t.Fatal("a candle must not be visible before it closes")
+	}
+	trades, _ := ex.FetchTrades("BTC/USDT", 0, 0, nil)
+	if len(trades) != 0 {
+		t.Fatal("future trade visible:", trades)
+	}
+
+	ex.AdvanceTo(1600000000999)
+	trades, _ = ex.FetchTrades("BTC/USDT", 0, 0, nil)
+	candles, _ = ex.FetchOHLCV("BTC/USDT", "1s", 0, 0, nil)
+	if len(trades) != 1 || len(candles) != 0 {
+		t.Fatal("at .999:", trades, candles)
+	}
+	book, _ := ex.FetchOrderBook("BTC/USDT", 0, nil)
+	if book.BestAsk() != 101 {
+		t.Fatal("book should still be the first one:", book.Asks)
+	}
+
+	ex.Step()
+	candles, _ = ex.FetchOHLCV("BTC/USDT", "1s", 0, 0, nil)
+	book, _ = ex.FetchOrderBook("BTC/USDT", 0, nil)
+	if len(candles) != 1 || book.BestAsk() != 106 {
+		t.Fatal("at 1s:", candles, book.Asks)
+	}
+}
+
+func TestReplayRunReport(t *testing.T) {
+	ex := newReplay(t)
+	var order *base.Order
+	report, err := ex.Run(func(now int64) error {
+		if order != nil {
+			return nil
+		}
+		var err error
+		// buy 1 at market on the first book, then rest a sell that the last book crosses
+		if _, err = ex.CreateOrder("BTC/USDT", "market", "buy", 1, 0, nil); err != nil {
+			return err
+		}
+		order, err = ex.LimitSell("BTC/USDT", 108, 1, nil)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	final, _ := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if final.Status != "closed" {
+		t.Fatalf("limit sell should have filled: %+v", final)
+	}
+	if len(report.Fills) != 2 {
+		t.Fatal("fills:", report.Fills)
+	}
+	wantFees := 101*0.001 + 108*0.001
+	if !almostEqual(report.Fees, wantFees) {
+		t.Fatal("fees:", report.Fees)
+	}
+	if !almostEqual(report.PnL, 108-101-wantFees) {
+		t.Fatal("pnl:", report.PnL, report.InitialValue, report.FinalValue)
+	}
+	if report.Start != 1600000000000 || report.End != 1600000002000 {
+		t.Fatal("report window:", report.Start, report.End)
+	}
+}
+
+func TestReplayIsDeterministic(t *testing.T) {
+	run := func() *Report {
+		ex := newReplay(t)
+		report, _ := ex.Run(func(now int64) error {
+			_, err := ex.LimitBuy("BTC/USDT", 100, 0.1, nil)
+			return err
+		})
+		return report
+	}
+	a, b := run(), run()
+	if a.PnL != b.PnL || len(a.Fills) != len(b.Fills) {
+		t.Fatal("two runs differ:", a, b)
+	}
+}
+
+func TestReplayRepeatedSnapshotsFillOnce(t *testing.T) {
+	ex, err := New(&Config{
+		Files:    []string{"testdata/repeated_book.jsonl"},
+		Balances: map[string]float64{"USDT": 1000},
+		Currency: "USDT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ex.Step()
+	order, err := ex.LimitBuy("BTC/USDT", 100, 1, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// three identical snapshots each offer the same 0.3 below the order
+	for ex.Step() {
+	}
+	final, _ := ex.FetchOrder(order.Id, "BTC/USDT", nil)
+	if !almostEqual(final.Filled, 0.3) || final.Status != "open" {
+		t.Fatalf("filled from one snapshot only: %+v", final)
+	}
+}
+
+func TestReplayReportListsUnpricedSorted(t *testing.T) {
+	ex, err := New(&Config{
+		Files:    []string{"testdata/btc_usdt.jsonl"},
+		Balances: map[string]float64{"USDT": 1000, "XRP": 1, "ADA": 1, "ETH": 1, "DOT": 1},
+		Currency: "USDT",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, _ := ex.Run(nil)
+	if fmt.Sprint(report.Unpriced) != "[ADA DOT ETH XRP]" {
+		t.Fatal("unpriced:", report.Unpriced)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	d := a - b
+	return d < 1e-9 && d > -1e-9
+}
@@ -0,0 +1,75 @@
+package replay
+
+import (
+	"sort"
+
+	. "github.com/georgexdz/ccxt/go/base"
+	"github.com/georgexdz/ccxt/go/paper"
+)
+
+// Report summarizes a replay run
+type Report struct {
+	Start    int64  `json:"start"`
+	End      int64  `json:"end"`
+	Currency string `json:"currency"`
+
+	InitialBalance map[string]float64 `json:"initialBalance"`
+	FinalBalance   map[string]float64 `json:"finalBalance"`
+	// InitialValue is the initial balance at the first recorded mid prices,
+	// FinalValue the final balance at the last ones, both in Currency
+	InitialValue float64 `json:"initialValue"`
+	FinalValue   float64 `json:"finalValue"`
+	PnL          float64 `json:"pnl"`
+	// Unpriced lists currencies held without a Currency market to value them,
+	// they are left out of InitialValue and FinalValue
+	Unpriced []string `json:"unpriced"`
+
+	Fees   float64      `json:"fees"`
+	Volume float64      `json:"volume"` // traded quote volume
+	Fills  []paper.Fill `json:"fills"`
+}
+
+// Report builds the report for the run so far
+func (self *Replay) Report() *Report {
+	report := &Report{
+		Start:          self.begin,
+		End:            self.now,
+		Currency:       self.config.Currency,
+		InitialBalance: self.start,
+		FinalBalance:   self.Engine.Balance().Total,
+		Fills:          self.Engine.Fills(),
+	}
+	for _, fill := range report.Fills {
+		report.Fees += fill.Fee
+		report.Volume += fill.Price * fill.Amount
+	}
+	unpriced := map[string]bool{}
+	report.InitialValue = self.value(report.InitialBalance, self.first, unpriced)
+	report.FinalValue = self.value(report.FinalBalance, self.books, unpriced)
+	report.PnL = report.FinalValue - report.InitialValue
+	for code := range unpriced {
+		report.Unpriced = append(report.Unpriced, code)
+	}
+	// the map's order changes from run to run, reports must not
+	sort.Strings(report.Unpriced)
+	return report
+}
+
+func (self *Replay) value(balances map[string]float64, books map[string]*OrderBook, unpriced map[string]bool) (total float64) {
+	for code, amount := range balances {
+		if code == self.config.Currency {
+			total += amount
+			continue
+		}
+		if amount == 0 {
+			continue
+		}
+		book := books[code+"/"+self.config.Currency]
+		if book == nil || book.MidPrice() == 0 {
+			unpriced[code] = true
+			continue
+		}
+		total += amount * book.MidPrice()
+	}
+	return
+}